@@ -1,7 +1,11 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
 	"skillsrock-test-task/internal/app"
+	"skillsrock-test-task/internal/config"
 )
 
 // @title SkillsRock Test Task
@@ -10,5 +14,42 @@ import (
 // @host localhost:8080
 // @schemes http
 func main() {
-	app.Run()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+
+	flags, configFile := newFlagSet(os.Args[0])
+	printConfig := flags.Bool("print-config", false, "Print the settings with the secrets redacted and exit")
+	flags.Parse(os.Args[1:])
+
+	cfg := loadConfig(*configFile)
+
+	if *printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	app.Run(cfg)
+}
+
+// newFlagSet has the flags of the settings and the config file, which both the server and migrate read.
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "YAML or TOML file of settings, overridden by the environment and the flags (CONFIG_FILE)")
+	config.RegisterFlags(flags)
+
+	return flags, configFile
+}
+
+func loadConfig(file string) *config.Config {
+	cfg, err := config.New(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	return cfg
 }
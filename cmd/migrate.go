@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"skillsrock-test-task/internal/config"
+	"skillsrock-test-task/pkg/migrator"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+)
+
+const (
+	defaultMigrationsDir = "migrations"
+
+	migrateUsage = `Usage: %s migrate [flags] <command>
+
+Commands:
+  up [N]       apply all migrations, or the next N
+  down [N]     roll back the last N migrations, 1 by default
+  goto V       migrate up or down to version V
+  version      print the applied version
+  force V      set the version without migrating, -1 for none, after a failed migration was fixed by hand
+  create NAME  create the files of a new migration in the MIGRATIONS_PATH setting, ./migrations by default
+
+Flags:
+`
+)
+
+// runMigrate runs a migrate command and returns the exit code, 2 for a command that is not valid.
+func runMigrate(args []string) int {
+	flags, configFile := newFlagSet(os.Args[0] + " migrate")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), migrateUsage, os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	command, args := flags.Arg(0), flags.Args()[min(1, flags.NArg()):]
+
+	// A new migration is written before there is a database to migrate, it only needs the migrations settings.
+	if command == "create" {
+		if len(args) != 1 {
+			flags.Usage()
+			return 2
+		}
+
+		migrations, err := config.NewMigrations(*configFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		dir := migrations.Path
+		if dir == "" {
+			dir = defaultMigrationsDir
+		}
+
+		paths, err := migrator.Create(dir, args[0], time.Now())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		for _, path := range paths {
+			fmt.Println(path)
+		}
+		return 0
+	}
+
+	run, ok := migrateCommand(command, args)
+	if !ok {
+		flags.Usage()
+		return 2
+	}
+
+	cfg := loadConfig(*configFile)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	m, err := migrator.New(ctx, cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer m.Close()
+
+	if err := run(ctx, m); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return printVersion(m)
+}
+
+// migrateCommand parses the command and its arguments, the commands are run by the Migrator.
+func migrateCommand(command string, args []string) (func(ctx context.Context, m *migrator.Migrator) error, bool) {
+	switch {
+	case command == "up" && len(args) <= 1:
+		n, ok := count(args, 0)
+		return func(ctx context.Context, m *migrator.Migrator) error { return m.Up(ctx, n) }, ok
+	case command == "down" && len(args) <= 1:
+		n, ok := count(args, 1)
+		return func(ctx context.Context, m *migrator.Migrator) error { return m.Down(ctx, n) }, ok && n > 0
+	case command == "goto" && len(args) == 1:
+		version, err := strconv.ParseUint(args[0], 10, 64)
+		return func(ctx context.Context, m *migrator.Migrator) error { return m.Goto(ctx, uint(version)) }, err == nil
+	case command == "force" && len(args) == 1:
+		version, err := strconv.Atoi(args[0])
+		return func(ctx context.Context, m *migrator.Migrator) error { return m.Force(ctx, version) }, err == nil && version >= -1
+	case command == "version" && len(args) == 0:
+		return func(context.Context, *migrator.Migrator) error { return nil }, true
+	default:
+		return nil, false
+	}
+}
+
+// count parses the optional number of migrations of up and down.
+func count(args []string, fallback int) (int, bool) {
+	if len(args) == 0 {
+		return fallback, true
+	}
+
+	n, err := strconv.Atoi(args[0])
+	return n, err == nil && n >= 0
+}
+
+func printVersion(m *migrator.Migrator) int {
+	version, dirty, err := m.Version()
+	switch {
+	case errors.Is(err, migrate.ErrNilVersion):
+		fmt.Println("no migration applied")
+	case err != nil:
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	case dirty:
+		fmt.Printf("version %d (dirty, fix the database and force the version)\n", version)
+	default:
+		fmt.Printf("version %d\n", version)
+	}
+
+	return 0
+}
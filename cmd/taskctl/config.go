@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultServer = "http://localhost:8080"
+
+// config is read from the config file, the environment overrides it and the flags override both.
+type config struct {
+	Server string `yaml:"server,omitempty" env:"TASKCTL_SERVER"`
+	Token  string `yaml:"token,omitempty" env:"TASKCTL_TOKEN"`
+}
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "taskctl.yaml"
+	}
+
+	return filepath.Join(dir, "taskctl", "config.yaml")
+}
+
+func loadConfig(path string) (*config, error) {
+	cfg, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cleanenv.ReadEnv(cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.Server == "" {
+		cfg.Server = defaultServer
+	}
+
+	return cfg, nil
+}
+
+// readConfigFile returns an empty config when the file does not exist.
+func readConfigFile(path string) (*config, error) {
+	var cfg config
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return &cfg, nil
+}
+
+// writeConfigFile keeps the file private, it holds the access token.
+func writeConfigFile(path string, cfg *config) error {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o600)
+}
+
+func newConfigCmd(c *cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show or change the config file",
+	}
+
+	keys := []string{"server", "token"}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:       "set KEY VALUE",
+		Short:     "Set server or token in the config file",
+		Args:      cobra.ExactArgs(2),
+		ValidArgs: keys,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := readConfigFile(c.configPath)
+			if err != nil {
+				return err
+			}
+
+			switch args[0] {
+			case "server":
+				cfg.Server = strings.TrimSuffix(args[1], "/")
+			case "token":
+				cfg.Token = args[1]
+			default:
+				return fmt.Errorf("unknown key %q, expected one of %s", args[0], strings.Join(keys, ", "))
+			}
+
+			return writeConfigFile(c.configPath, cfg)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "show",
+		Short: "Show the settings in effect, the token is masked",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := c.config()
+			if err != nil {
+				return err
+			}
+
+			token := ""
+			if cfg.Token != "" {
+				token = "********"
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "config: %s\n", c.configPath)
+			fmt.Fprintf(out, "server: %s\n", cfg.Server)
+			fmt.Fprintf(out, "token:  %s\n", token)
+
+			return nil
+		},
+	})
+
+	return cmd
+}
@@ -0,0 +1,19 @@
+// taskctl manages tasks from the terminal through the REST API, run taskctl --help for the commands.
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := newRootCmd().ExecuteContext(ctx); err != nil {
+		stop()
+		os.Exit(1)
+	}
+}
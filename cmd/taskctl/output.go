@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"skillsrock-test-task/pkg/client"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+var outputFormats = []string{formatTable, formatJSON, formatYAML}
+
+func printTasks(w io.Writer, format string, tasks []*client.Task) error {
+	if format != formatTable {
+		return printValue(w, format, tasks)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATUS\tOWNER\tTITLE\tDUE\tCOMMENTS\tUPDATED")
+	for _, task := range tasks {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%d\t%s\n",
+			task.ID, task.Status, task.Owner, task.Title, formatDue(task.DueAt), task.CommentsCount, task.UpdatedAt.Local().Format(time.DateTime))
+	}
+
+	return tw.Flush()
+}
+
+// formatDue shows a due date without a time, which is due at midnight UTC, as a date.
+func formatDue(dueAt *time.Time) string {
+	switch {
+	case dueAt == nil:
+		return ""
+	case dueAt.UTC().Truncate(24 * time.Hour).Equal(*dueAt):
+		return dueAt.UTC().Format(time.DateOnly)
+	default:
+		return dueAt.Local().Format(time.DateTime)
+	}
+}
+
+// printValue writes JSON or YAML. YAML keeps the field names and order of the JSON of the API.
+func printValue(w io.Writer, format string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if format == formatJSON {
+		_, err := fmt.Fprintf(w, "%s\n", data)
+		return err
+	}
+
+	// JSON is valid YAML, decoding it into a node keeps the order of the fields.
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// blockStyle drops the flow style of the decoded JSON, so that the output looks like hand-written YAML.
+func blockStyle(node *yaml.Node) {
+	node.Style &^= yaml.FlowStyle | yaml.DoubleQuotedStyle
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
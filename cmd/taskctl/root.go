@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"skillsrock-test-task/pkg/client"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type cli struct {
+	configPath string
+	server     string
+	token      string
+	output     string
+	timeout    time.Duration
+	retries    int
+}
+
+func newRootCmd() *cobra.Command {
+	c := &cli{}
+
+	cmd := &cobra.Command{
+		Use:   "taskctl",
+		Short: "Manage tasks through the tasks API",
+		Long: "taskctl manages tasks through the REST API.\n\n" +
+			"The server and access token are read from the config file, the TASKCTL_SERVER and\n" +
+			"TASKCTL_TOKEN environment variables and the flags, later ones take precedence.",
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return validateFormat(c.output, outputFormats)
+		},
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&c.configPath, "config", defaultConfigPath(), "config file")
+	flags.StringVar(&c.server, "server", "", "server URL, e.g. "+defaultServer)
+	flags.StringVar(&c.token, "token", "", "access token")
+	flags.StringVarP(&c.output, "output", "o", formatTable, "output format: table, json or yaml")
+	flags.DurationVar(&c.timeout, "timeout", 30*time.Second, "timeout of a single request")
+	flags.IntVar(&c.retries, "retries", 3, "how many times a failed request is retried")
+
+	_ = cmd.RegisterFlagCompletionFunc("output", fixedCompletion(outputFormats...))
+
+	cmd.AddCommand(
+		newCreateCmd(c),
+		newListCmd(c),
+		newGetCmd(c),
+		newUpdateCmd(c),
+		newDoneCmd(c),
+		newDeleteCmd(c),
+		newImportCmd(c),
+		newImportFromCmd(c),
+		newExportCmd(c),
+		newConfigCmd(c),
+	)
+
+	return cmd
+}
+
+func (c *cli) config() (*config, error) {
+	cfg, err := loadConfig(c.configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.server != "" {
+		cfg.Server = c.server
+	}
+	if c.token != "" {
+		cfg.Token = c.token
+	}
+
+	return cfg, nil
+}
+
+func (c *cli) client() (*client.Client, error) {
+	return c.clientWithTimeout(c.timeout)
+}
+
+// clientWithTimeout returns a client whose requests time out after timeout, 0 means no timeout.
+func (c *cli) clientWithTimeout(timeout time.Duration) (*client.Client, error) {
+	cfg, err := c.config()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []client.Option{
+		client.WithHTTPClient(&http.Client{Timeout: timeout}),
+		client.WithRetries(c.retries, 100*time.Millisecond, 2*time.Second),
+	}
+	if cfg.Token != "" {
+		opts = append(opts, client.WithToken(cfg.Token))
+	}
+
+	return client.New(cfg.Server, opts...)
+}
+
+func validateFormat(format string, formats []string) error {
+	for _, f := range formats {
+		if f == format {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown format %q, expected one of %v", format, formats)
+}
+
+func fixedCompletion(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"fmt"
+	"skillsrock-test-task/pkg/client"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var statuses = []string{"new", "in_progress", "done"}
+
+func newCreateCmd(c *cli) *cobra.Command {
+	var (
+		task client.CreateTaskRequest
+		due  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a task",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dueAt, err := parseDue(due)
+			if err != nil {
+				return err
+			}
+			task.DueAt = dueAt
+
+			api, err := c.client()
+			if err != nil {
+				return err
+			}
+
+			res, err := api.CreateTask(cmd.Context(), &task)
+			if err != nil {
+				return err
+			}
+
+			if c.output == formatTable {
+				fmt.Fprintf(cmd.OutOrStdout(), "Task %d created\n", res.ID)
+				return nil
+			}
+
+			return printValue(cmd.OutOrStdout(), c.output, res)
+		},
+	}
+
+	cmd.Flags().StringVar(&task.Title, "title", "", "title of the task")
+	cmd.Flags().StringVar(&task.Description, "description", "", "description of the task")
+	cmd.Flags().StringVar(&task.Owner, "owner", "", "owner of the task")
+	cmd.Flags().StringVar(&due, "due", "", "due date of the task, an RFC 3339 time or a date like 2006-01-02")
+	_ = cmd.MarkFlagRequired("title")
+
+	return cmd
+}
+
+func newListCmd(c *cli) *cobra.Command {
+	var (
+		opts client.ListTasksOptions
+		all  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List tasks",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			api, err := c.client()
+			if err != nil {
+				return err
+			}
+
+			var tasks []*client.Task
+			if all {
+				for page, err := range api.ListTasks(cmd.Context(), opts) {
+					if err != nil {
+						return err
+					}
+					tasks = append(tasks, page...)
+				}
+			} else {
+				if tasks, err = api.GetTasks(cmd.Context(), opts); err != nil {
+					return err
+				}
+			}
+
+			return printTasks(cmd.OutOrStdout(), c.output, tasks)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Status, "status", "", "only tasks with this status")
+	cmd.Flags().StringVar(&opts.Owner, "owner", "", "only tasks of this owner")
+	cmd.Flags().Uint64Var(&opts.Page, "page", 1, "page to show")
+	cmd.Flags().Uint64Var(&opts.Limit, "limit", 0, "tasks per page, the server default when 0")
+	cmd.Flags().BoolVar(&all, "all", false, "list the tasks of all pages starting at --page")
+	_ = cmd.RegisterFlagCompletionFunc("status", fixedCompletion(statuses...))
+
+	return cmd
+}
+
+func newGetCmd(c *cli) *cobra.Command {
+	return &cobra.Command{
+		Use:               "get ID",
+		Short:             "Show a task",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			api, err := c.client()
+			if err != nil {
+				return err
+			}
+
+			task, err := api.GetTask(cmd.Context(), id)
+			if err != nil {
+				return err
+			}
+
+			return printTasks(cmd.OutOrStdout(), c.output, []*client.Task{task})
+		},
+	}
+}
+
+func newUpdateCmd(c *cli) *cobra.Command {
+	var (
+		task client.UpdateTaskRequest
+		due  string
+	)
+
+	cmd := &cobra.Command{
+		Use:               "update ID",
+		Short:             "Change the title, description, status or due date of a task, other fields are kept",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			flags := cmd.Flags()
+			if !flags.Changed("title") && !flags.Changed("description") && !flags.Changed("status") && !flags.Changed("due") {
+				return fmt.Errorf("nothing to update, set --title, --description, --status or --due")
+			}
+
+			dueAt, err := parseDue(due)
+			if err != nil {
+				return err
+			}
+
+			api, err := c.client()
+			if err != nil {
+				return err
+			}
+
+			// The API replaces the whole task, so the fields that are not changed are sent as they are.
+			current, err := api.GetTask(cmd.Context(), id)
+			if err != nil {
+				return err
+			}
+
+			if !flags.Changed("title") {
+				task.Title = current.Title
+			}
+			if !flags.Changed("description") {
+				task.Description = current.Description
+			}
+			if !flags.Changed("status") {
+				task.Status = current.Status
+			}
+			task.DueAt = dueAt
+			if !flags.Changed("due") {
+				task.DueAt = current.DueAt
+			}
+
+			if err := api.UpdateTask(cmd.Context(), id, &task); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.ErrOrStderr(), "Task %d updated\n", id)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&task.Title, "title", "", "new title")
+	cmd.Flags().StringVar(&task.Description, "description", "", "new description")
+	cmd.Flags().StringVar(&task.Status, "status", "", "new status: new, in_progress or done")
+	cmd.Flags().StringVar(&due, "due", "", "new due date, an RFC 3339 time or a date like 2006-01-02, empty removes it")
+	_ = cmd.RegisterFlagCompletionFunc("status", fixedCompletion(statuses...))
+
+	return cmd
+}
+
+func newDoneCmd(c *cli) *cobra.Command {
+	return &cobra.Command{
+		Use:               "done ID...",
+		Short:             "Mark tasks as done",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseIDs(args)
+			if err != nil {
+				return err
+			}
+
+			api, err := c.client()
+			if err != nil {
+				return err
+			}
+
+			for _, id := range ids {
+				task, err := api.GetTask(cmd.Context(), id)
+				if err != nil {
+					return fmt.Errorf("task %d: %w", id, err)
+				}
+
+				err = api.UpdateTask(cmd.Context(), id, &client.UpdateTaskRequest{
+					Title:       task.Title,
+					Description: task.Description,
+					Status:      "done",
+					DueAt:       task.DueAt,
+				})
+				if err != nil {
+					return fmt.Errorf("task %d: %w", id, err)
+				}
+
+				fmt.Fprintf(cmd.ErrOrStderr(), "Task %d done\n", id)
+			}
+
+			return nil
+		},
+	}
+}
+
+func newDeleteCmd(c *cli) *cobra.Command {
+	return &cobra.Command{
+		Use:               "delete ID...",
+		Short:             "Delete tasks",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseIDs(args)
+			if err != nil {
+				return err
+			}
+
+			api, err := c.client()
+			if err != nil {
+				return err
+			}
+
+			for _, id := range ids {
+				if err := api.DeleteTask(cmd.Context(), id); err != nil {
+					return fmt.Errorf("task %d: %w", id, err)
+				}
+
+				fmt.Fprintf(cmd.ErrOrStderr(), "Task %d deleted\n", id)
+			}
+
+			return nil
+		},
+	}
+}
+
+func parseID(arg string) (uint64, error) {
+	id, err := strconv.ParseUint(arg, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid task id %q", arg)
+	}
+
+	return id, nil
+}
+
+// parseDue reads the --due flag, a date is due at its start in UTC. An empty flag is no due date.
+func parseDue(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if dueAt, err := time.Parse(layout, value); err == nil {
+			return &dueAt, nil
+		}
+	}
+
+	return nil, fmt.Errorf("invalid due date %q, expected an RFC 3339 time or a date like 2006-01-02", value)
+}
+
+func parseIDs(args []string) ([]uint64, error) {
+	ids := make([]uint64, 0, len(args))
+	for _, arg := range args {
+		id, err := parseID(arg)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"skillsrock-test-task/pkg/client"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	fileFormatJSON   = "json"
+	fileFormatNDJSON = "ndjson"
+	fileFormatCSV    = "csv"
+)
+
+var (
+	fileFormats   = []string{fileFormatJSON, fileFormatNDJSON, fileFormatCSV}
+	sources       = []string{client.SourceTrello, client.SourceJira, client.SourceGitHub}
+	exportColumns = []string{"id", "title", "description", "status", "owner", "due_at", "comments_count", "created_at", "updated_at"}
+)
+
+// record is an imported task. Exported files can be imported, the fields the API sets are ignored.
+type record struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Owner       string `json:"owner"`
+	Status      string `json:"status"`
+	DueAt       string `json:"due_at"`
+}
+
+func newExportCmd(c *cli) *cobra.Command {
+	var (
+		opts client.ExportOptions
+		path string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Download all tasks matching the filters as JSON, NDJSON or CSV",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateFormat(opts.Format, fileFormats); err != nil {
+				return err
+			}
+
+			// The server streams the export, the timeout of single requests would cut large ones short.
+			api, err := c.clientWithTimeout(0)
+			if err != nil {
+				return err
+			}
+
+			body, err := api.ExportTasks(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			defer body.Close()
+
+			if path == "" || path == "-" {
+				_, err := io.Copy(cmd.OutOrStdout(), body)
+				return err
+			}
+
+			file, err := os.Create(path)
+			if err != nil {
+				return err
+			}
+
+			if _, err := io.Copy(file, body); err != nil {
+				file.Close()
+				return err
+			}
+
+			return file.Close()
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Status, "status", "", "only tasks with this status")
+	cmd.Flags().StringVar(&opts.Owner, "owner", "", "only tasks of this owner")
+	cmd.Flags().StringVar(&opts.Format, "format", fileFormatJSON, "file format: json, ndjson or csv")
+	cmd.Flags().StringSliceVar(&opts.Columns, "columns", nil, "exported columns, all by default")
+	cmd.Flags().BoolVar(&opts.Gzip, "gzip", false, "compress the file with gzip")
+	cmd.Flags().StringVarP(&path, "file", "f", "", "file to write, standard output when empty")
+	_ = cmd.RegisterFlagCompletionFunc("status", fixedCompletion(statuses...))
+	_ = cmd.RegisterFlagCompletionFunc("format", fixedCompletion(fileFormats...))
+	_ = cmd.RegisterFlagCompletionFunc("columns", fixedCompletion(exportColumns...))
+
+	return cmd
+}
+
+func newImportCmd(c *cli) *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Create the tasks of a JSON, NDJSON or CSV file, - reads standard input",
+		Long: "Create the tasks of a JSON, NDJSON or CSV file, - reads standard input.\n\n" +
+			"Records have a title, description, owner and optionally a status and a due_at,\n" +
+			"CSV files name the columns in their first line. Files written by export can be imported.\n" +
+			"The format is taken from the file extension unless --format is set.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format == "" {
+				format = strings.TrimPrefix(filepath.Ext(args[0]), ".")
+			}
+			if err := validateFormat(format, fileFormats); err != nil {
+				return err
+			}
+
+			in := cmd.InOrStdin()
+			if args[0] != "-" {
+				file, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				in = file
+			}
+
+			records, err := readRecords(in, format)
+			if err != nil {
+				return err
+			}
+
+			api, err := c.client()
+			if err != nil {
+				return err
+			}
+
+			for i, rec := range records {
+				dueAt, err := parseDue(rec.DueAt)
+				if err != nil {
+					return fmt.Errorf("record %d: %w, %d tasks were imported", i+1, err, i)
+				}
+
+				res, err := api.CreateTask(cmd.Context(), &client.CreateTaskRequest{
+					Title:       rec.Title,
+					Description: rec.Description,
+					Owner:       rec.Owner,
+					DueAt:       dueAt,
+				})
+				if err != nil {
+					return fmt.Errorf("record %d: %w, %d tasks were imported", i+1, err, i)
+				}
+
+				// Tasks are created as new, other statuses are set afterwards.
+				if rec.Status != "" && rec.Status != res.Status {
+					err := api.UpdateTask(cmd.Context(), res.ID, &client.UpdateTaskRequest{
+						Title:       rec.Title,
+						Description: rec.Description,
+						Status:      rec.Status,
+						DueAt:       dueAt,
+					})
+					if err != nil {
+						return fmt.Errorf("record %d: task %d was created, setting its status failed: %w", i+1, res.ID, err)
+					}
+				}
+			}
+
+			fmt.Fprintf(cmd.ErrOrStderr(), "%d tasks imported\n", len(records))
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "", "file format: json, ndjson or csv")
+	_ = cmd.RegisterFlagCompletionFunc("format", fixedCompletion(fileFormats...))
+
+	return cmd
+}
+
+func newImportFromCmd(c *cli) *cobra.Command {
+	var opts client.ImportFromOptions
+
+	cmd := &cobra.Command{
+		Use:   "import-from SOURCE FILE",
+		Short: "Import the JSON export of a Trello board, Jira issues or GitHub issues, - reads standard input",
+		Long: "Import the JSON export of a Trello board, Jira issues or GitHub issues, - reads standard input.\n\n" +
+			"SOURCE is trello, jira or github. Titles, descriptions, states and creation dates are imported,\n" +
+			"importing an export again updates the tasks imported before. The server guesses the status of a\n" +
+			"state from its name unless --map sets it, the report lists the status every state got.",
+		Example: "  gh issue list --state all --json url,title,body,state,createdAt | taskctl import-from github -\n" +
+			"  taskctl import-from trello board.json --map 'QA=in_progress,Icebox=new' --dry-run",
+		Args: cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return sources, cobra.ShellCompDirectiveNoFileComp
+			}
+			return nil, cobra.ShellCompDirectiveDefault
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(sources, args[0]) {
+				return fmt.Errorf("unknown source %q, expected one of %v", args[0], sources)
+			}
+
+			in := cmd.InOrStdin()
+			if args[1] != "-" {
+				file, err := os.Open(args[1])
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				in = file
+			}
+
+			// Large exports take longer than the timeout of single requests.
+			api, err := c.clientWithTimeout(0)
+			if err != nil {
+				return err
+			}
+
+			report, err := api.ImportFrom(cmd.Context(), args[0], in, opts)
+			if err != nil {
+				return err
+			}
+
+			return printImportReport(cmd.OutOrStdout(), c.output, report)
+		},
+	}
+
+	cmd.Flags().StringToStringVar(&opts.Mapping, "map", nil, "status of states of the tracker, e.g. 'In Review=in_progress'")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "only report what the import would change")
+
+	return cmd
+}
+
+func printImportReport(w io.Writer, format string, report *client.ExternalImportReport) error {
+	if format != formatTable {
+		return printValue(w, format, report)
+	}
+
+	prefix := ""
+	if report.DryRun {
+		prefix = "dry run: "
+	}
+	fmt.Fprintf(w, "%s%d items, %d created, %d updated, %d unchanged, %d skipped\n\n",
+		prefix, report.TotalItems, report.Created, report.Updated, report.Unchanged, report.Skipped)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATE\tSTATUS\tITEMS")
+	for _, state := range report.States {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", state.State, state.Status, state.Count)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(report.Errors) > 0 {
+		fmt.Fprintln(w)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ITEM\tSOURCE ID\tSKIPPED BECAUSE")
+		for _, e := range report.Errors {
+			fmt.Fprintf(tw, "%d\t%s\t%s\n", e.Item, e.SourceID, e.Error)
+		}
+		return tw.Flush()
+	}
+
+	return nil
+}
+
+// readRecords reads the whole file before anything is created, so that a malformed file creates no tasks.
+func readRecords(r io.Reader, format string) ([]*record, error) {
+	var records []*record
+
+	switch format {
+	case fileFormatJSON:
+		if err := json.NewDecoder(r).Decode(&records); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+	case fileFormatNDJSON:
+		dec := json.NewDecoder(r)
+		for line := 1; ; line++ {
+			var rec record
+			if err := dec.Decode(&rec); errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to parse record %d: %w", line, err)
+			}
+			records = append(records, &rec)
+		}
+	case fileFormatCSV:
+		var err error
+		if records, err = readCSV(r); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, rec := range records {
+		if rec == nil || rec.Title == "" {
+			return nil, fmt.Errorf("record %d has no title", i+1)
+		}
+	}
+
+	return records, nil
+}
+
+func readCSV(r io.Reader) ([]*record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, fmt.Errorf("CSV header has no title column")
+	}
+
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	var records []*record
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV: %w", err)
+		}
+
+		records = append(records, &record{
+			Title:       field(row, "title"),
+			Description: field(row, "description"),
+			Owner:       field(row, "owner"),
+			Status:      field(row, "status"),
+			DueAt:       field(row, "due_at"),
+		})
+	}
+}
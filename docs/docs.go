@@ -15,312 +15,2390 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/tasks": {
+        "/board": {
+            "get": {
+                "description": "Bidirectional JSON messages. Clients send \"subscribe\" (subscription, status, owner, last_event_id),\n\"unsubscribe\" (subscription), \"move\" (task_id, status), \"update\" (task_id, title, description, status, due_at),\n\"view\" and \"leave\" (task_id), each with an optional \"ref\" echoed in the \"ack\" or \"error\" reply.\nThe server pushes \"event\", \"reset\" and \"presence\" messages. Clients may send up to 10 messages per second,\nconnections that do not read their messages fast enough are closed.",
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Collaborative board over WebSocket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token, when it is not sent in the Authorization header",
+                        "name": "access_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching protocols",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid access token",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "426": {
+                        "description": "WebSocket upgrade required",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/calendar.ics": {
             "get": {
-                "description": "Retrieves a paginated list of tasks",
+                "description": "Renders the tasks with a due date of the authenticated user as an iCalendar feed, to be subscribed to by\ncalendar clients. Tasks are to-dos (VTODO) whose status follows the task, or all-day and timed events\n(VEVENT). The feed has a strong ETag, a request whose If-None-Match has it is answered with 304 without\nreading the tasks. Calendar clients usually can not set headers, so the token is sent as access_token.",
                 "produces": [
-                    "application/json"
+                    "text/calendar"
                 ],
                 "tags": [
                     "tasks"
                 ],
-                "summary": "Get tasks",
+                "summary": "Calendar feed of tasks",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Page number",
-                        "name": "page",
+                        "description": "Access token, when it is not sent in the Authorization header",
+                        "name": "access_token",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Items per page",
-                        "name": "limit",
+                        "description": "Only tasks with these comma separated statuses",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only tasks due at or after this RFC 3339 time or date",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only tasks due before this RFC 3339 time or date",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "vtodo (default) or vevent",
+                        "name": "component",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the feed the client has",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of tasks",
+                        "description": "iCalendar feed",
                         "schema": {
-                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.GetTasksResponse"
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "Feed did not change",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Invalid pagination parameters",
+                        "description": "Invalid status, range or component",
                         "schema": {
-                            "$ref": "#/definitions/internal_delivery_http_v1_handler.ErrorResponse"
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
                         }
                     },
-                    "404": {
-                        "description": "No tasks found",
+                    "401": {
+                        "description": "Missing or invalid access token",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
                         "schema": {
-                            "$ref": "#/definitions/internal_delivery_http_v1_handler.ErrorResponse"
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
                         }
                     },
                     "500": {
                         "description": "Unknown error occurred",
                         "schema": {
-                            "$ref": "#/definitions/internal_delivery_http_v1_handler.ErrorResponse"
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/graphql": {
+            "get": {
+                "description": "Speaks the graphql-transport-ws protocol, queries and mutations can be sent over it as well.\nThe handshake needs a token from AUTH_TOKENS, as Authorization: Bearer \u003ctoken\u003e or ?access_token=\u003ctoken\u003e.",
+                "tags": [
+                    "graphql"
+                ],
+                "summary": "GraphQL subscriptions over WebSocket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token, when the client can not set the Authorization header",
+                        "name": "access_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching protocols",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid access token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "426": {
+                        "description": "WebSocket upgrade required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             },
             "post": {
-                "description": "Creates a new task",
+                "description": "The schema is served by introspection. Queries deeper than GRAPHQL_MAX_DEPTH or more complex than\nGRAPHQL_MAX_COMPLEXITY are rejected, every field costs 1 and the selections of a field with a first\nargument count once per requested item. Subscriptions are served over WebSocket on the same path.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "tasks"
+                    "graphql"
                 ],
-                "summary": "Create a new task",
+                "summary": "Execute a GraphQL query or mutation",
                 "parameters": [
                     {
-                        "description": "Task",
-                        "name": "book",
+                        "description": "GraphQL request",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.CreateTaskRequest"
+                            "$ref": "#/definitions/internal_delivery_graphql.Request"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "GraphQL response with data and errors",
                         "schema": {
-                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.CreateTaskResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid request body",
+                        "description": "Request body is not valid JSON",
                         "schema": {
-                            "$ref": "#/definitions/internal_delivery_http_v1_handler.ErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Uknown error occured while creating the task",
+                    "429": {
+                        "description": "Too many requests",
                         "schema": {
-                            "$ref": "#/definitions/internal_delivery_http_v1_handler.ErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/tasks/{id}": {
+        "/tasks": {
             "get": {
-                "description": "Retrieves a task by its ID",
+                "description": "Retrieves a paginated list of tasks, optionally filtered by status and owner",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "tasks"
                 ],
-                "summary": "Get a task by ID",
+                "summary": "Get tasks",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Task ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only tasks with this status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only tasks of this owner",
+                        "name": "owner",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Task details",
+                        "description": "List of tasks",
                         "schema": {
-                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.GetTaskByIDResponse"
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.GetTasksResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid task ID",
+                        "description": "Invalid pagination parameters or status",
                         "schema": {
-                            "$ref": "#/definitions/internal_delivery_http_v1_handler.ErrorResponse"
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
                         }
                     },
                     "404": {
-                        "description": "Task not found",
+                        "description": "No tasks found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
                         "schema": {
-                            "$ref": "#/definitions/internal_delivery_http_v1_handler.ErrorResponse"
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
                         }
                     },
                     "500": {
                         "description": "Unknown error occurred",
                         "schema": {
-                            "$ref": "#/definitions/internal_delivery_http_v1_handler.ErrorResponse"
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
                         }
                     }
                 }
             },
-            "put": {
-                "description": "Updates a task's data",
-                "consumes": [
-                    "application/json"
-                ],
+            "post": {
+                "description": "Creates a new task",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "tasks"
                 ],
-                "summary": "Update a task by ID",
+                "summary": "Create a new task",
                 "parameters": [
+                    {
+                        "description": "Task",
+                        "name": "book",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.CreateTaskRequest"
+                        }
+                    },
                     {
                         "type": "string",
-                        "description": "Task ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Repeating a request with the same key replays the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.CreateTaskResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or empty title",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Request with the same idempotency key is still in progress",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency key was used with a different request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
                     },
+                    "500": {
+                        "description": "Unknown error occurred while creating the task",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/bulk": {
+            "post": {
+                "description": "Applies a list of operations in one request. In \"atomic\" mode (default) all operations are committed or none;\na rolled back request is answered with 422 and per-item results. In \"best_effort\" mode every operation is applied independently.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Create, update and delete tasks in bulk",
+                "parameters": [
                     {
-                        "description": "Task payload",
-                        "name": "task",
+                        "description": "Operations",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.UpdateTaskRequest"
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.BulkTasksRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Updated successfully",
+                        "description": "Per-item results",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.BulkTasksResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid input or task ID",
+                        "description": "Invalid request body or mode",
                         "schema": {
-                            "$ref": "#/definitions/internal_delivery_http_v1_handler.ErrorResponse"
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Task not found",
+                    "413": {
+                        "description": "Too many operations",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Atomic request was rolled back",
+                        "schema": {
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.BulkTasksResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
                         "schema": {
-                            "$ref": "#/definitions/internal_delivery_http_v1_handler.ErrorResponse"
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
                         }
                     },
                     "500": {
                         "description": "Unknown error occurred",
                         "schema": {
-                            "$ref": "#/definitions/internal_delivery_http_v1_handler.ErrorResponse"
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Deletes a task by its ID",
+            }
+        },
+        "/tasks/export": {
+            "get": {
+                "description": "Streams all tasks matching the filters, oldest first, as a file download. The export is a consistent\nsnapshot and is not limited by the timeout of the other endpoints. An error after the download\nstarted cuts the file short, so a JSON export is not valid JSON then.",
                 "produces": [
-                    "application/json"
+                    "text/csv",
+                    "application/json",
+                    "application/x-ndjson"
                 ],
                 "tags": [
                     "tasks"
                 ],
-                "summary": "Delete a task by ID",
+                "summary": "Export tasks",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Task ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "csv (default), json or ndjson",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated columns, all by default: id,title,description,status,owner,due_at,comments_count,created_at,updated_at",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only tasks with this status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only tasks of this owner",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Compress the file with gzip",
+                        "name": "gzip",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Deleted successfully",
+                        "description": "Exported tasks",
                         "schema": {
-                            "type": "string"
+                            "type": "file"
                         }
                     },
                     "400": {
-                        "description": "Invalid task ID",
+                        "description": "Invalid format, columns or status",
                         "schema": {
-                            "$ref": "#/definitions/internal_delivery_http_v1_handler.ErrorResponse"
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Task not found",
+                    "429": {
+                        "description": "Too many requests",
                         "schema": {
-                            "$ref": "#/definitions/internal_delivery_http_v1_handler.ErrorResponse"
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
                         }
                     },
                     "500": {
                         "description": "Unknown error occurred",
                         "schema": {
-                            "$ref": "#/definitions/internal_delivery_http_v1_handler.ErrorResponse"
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "internal_delivery_http_v1_handler.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string"
-                }
-            }
         },
-        "skillsrock-test-task_internal_dto.CreateTaskRequest": {
-            "type": "object",
+        "/tasks/import": {
+            "post": {
+                "description": "Creates tasks from a CSV or NDJSON file uploaded as multipart/form-data. Every row is validated like a\ncreated task and may set the status, rows that are not valid are skipped and reported. The valid rows\nare imported together or not at all. Small files are imported during the request, larger ones are\nimported by a job whose status is polled at the Location of the response.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Import tasks",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV file with a header line or NDJSON file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "csv or ndjson, taken from the file extension by default",
+                        "name": "format",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON object mapping the fields title, description, owner, status and due_at to columns, e.g. {\\",
+                        "name": "mapping",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only validate the rows",
+                        "name": "dry_run",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Report of the import",
+                        "schema": {
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.ImportTasksResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Job importing the file",
+                        "schema": {
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.ImportTasksResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid form, format, mapping or file",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "File is too large",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Unknown error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/import/{id}": {
+            "get": {
+                "description": "Retrieves the status and the report of a job importing a large file. The report is updated while the\njob runs, its imported rows are committed when the job succeeds",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Get an import job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Import job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Import job",
+                        "schema": {
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.GetImportJobResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid import job ID",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Import job not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Unknown error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/import/{source}": {
+            "post": {
+                "description": "Creates tasks from the JSON export of a Trello board, Jira issues (a search response or an array) or\nGitHub issues (an array from the REST API or gh issue list --json). Titles, descriptions, states,\ncreation and due dates are imported. The tracker IDs are kept, so importing an export again updates the\ntasks imported before. The report tells which status every state got and which task every item became",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Import tasks from another tracker",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "trello, jira or github",
+                        "name": "source",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "JSON export",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON object mapping states of the tracker to statuses, e.g. {\\",
+                        "name": "mapping",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only report what the import would change",
+                        "name": "dry_run",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Report of the import",
+                        "schema": {
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.ImportFromTrackerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid form, mapping or file",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown source",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "File is too large",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Unknown error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/stream": {
+            "get": {
+                "description": "Pushes task.created, task.updated, task.status_changed and task.deleted events as Server-Sent Events.\nThe id of every event can be sent back in the Last-Event-ID header to resume after a reconnect,\na \"reset\" event tells that the missed events are not available anymore and the tasks should be reloaded.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Stream task events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only events of tasks with this status, status changes match the previous status too",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only events of tasks of this owner",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the last received event",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Event stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid status",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Server is shutting down",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}": {
+            "get": {
+                "description": "Retrieves a task by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Get a task by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Task details",
+                        "schema": {
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.GetTaskByIDResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid task ID",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Unknown error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Updates a task's data",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Update a task by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Task payload",
+                        "name": "task",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.UpdateTaskRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input or task ID",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Unknown error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a task by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Delete a task by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid task ID",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Unknown error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/attachments": {
+            "get": {
+                "description": "Retrieves metadata of all files attached to a task",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Get task attachments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of attachments",
+                        "schema": {
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.GetAttachmentsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid task ID",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Unknown error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Uploads a file as multipart/form-data. The size and the sniffed MIME type are checked against the configured limits",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Attach a file to a task",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "File",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Uploader",
+                        "name": "uploader",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.UploadAttachmentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input or task ID",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "File is too large",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "File type is not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Unknown error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/attachments/{attachmentID}": {
+            "get": {
+                "description": "Streams the file content. A single byte range can be requested with the Range header",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Download an attachment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Attachment ID",
+                        "name": "attachmentID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Byte range, e.g. bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "File content",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "Requested range of the file content",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid IDs",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Attachment not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "416": {
+                        "description": "Range not satisfiable",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Unknown error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes the attachment metadata, the stored file is removed in the background",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Delete an attachment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Attachment ID",
+                        "name": "attachmentID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid IDs",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Attachment not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Unknown error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/comments": {
+            "get": {
+                "description": "Retrieves the comments of a task in chronological order using cursor pagination",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Get task comments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned as next_cursor by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of comments",
+                        "schema": {
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.GetCommentsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid task ID or pagination parameters",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Unknown error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Adds a comment to the task, optionally as a reply to another comment of the same task",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Comment on a task",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Comment",
+                        "name": "comment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.CreateCommentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.CreateCommentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input or task ID",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Unknown error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/comments/{commentID}": {
+            "put": {
+                "description": "Replaces the body of a comment and marks it as edited",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Edit a comment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comment ID",
+                        "name": "commentID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Comment payload",
+                        "name": "comment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.UpdateCommentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input or IDs",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Comment not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Unknown error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Soft-deletes a comment, replies to it are kept",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Delete a comment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comment ID",
+                        "name": "commentID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid IDs",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Comment not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Unknown error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "description": "Retrieves the webhook subscriptions of the user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get webhooks",
+                "responses": {
+                    "200": {
+                        "description": "List of webhooks",
+                        "schema": {
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.GetWebhooksResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid access token",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Unknown error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Registers a webhook for the given events: task.created, task.updated, task.status_changed, task.deleted.\nDeliveries are signed with HMAC-SHA256 over \"\u003cX-Webhook-Timestamp\u003e.\u003cbody\u003e\" and sent in X-Webhook-Signature.\nThe secret is generated when omitted and is only returned in this response.\nThe url must resolve to public addresses, loopback, link-local and private networks are rejected.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Subscribe to task events",
+                "parameters": [
+                    {
+                        "description": "Webhook",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.CreateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.CreateWebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid url or events",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid access token",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Unknown error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "delete": {
+                "description": "Deletes the subscription together with its delivery log",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete a webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid webhook ID",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid access token",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Unknown error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "description": "Retrieves the delivery log of a webhook, newest first, using cursor pagination",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned as next_cursor by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of deliveries",
+                        "schema": {
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.GetDeliveriesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid webhook ID or pagination parameters",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid access token",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Unknown error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries/{deliveryID}/redeliver": {
+            "post": {
+                "description": "Queues a new delivery with the payload of an existing one, e.g. after it ended up dead",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Redeliver a webhook event",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delivery ID",
+                        "name": "deliveryID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/skillsrock-test-task_internal_dto.RedeliverResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid IDs",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid access token",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Delivery not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Unknown error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http_v1_handler.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "internal_delivery_graphql.Request": {
+            "type": "object",
+            "properties": {
+                "operationName": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "variables": {
+                    "type": "object",
+                    "additionalProperties": true
+                }
+            }
+        },
+        "internal_delivery_http_v1_handler.Problem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_delivery_http_v1_handler.ProblemField"
+                    }
+                },
+                "instance": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_delivery_http_v1_handler.ProblemField": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "field": {
+                    "type": "string"
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.BulkOperation": {
+            "type": "object",
+            "required": [
+                "op"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 10000
+                },
+                "due_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete"
+                    ]
+                },
+                "owner": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "new",
+                        "in_progress",
+                        "done"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 200
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.BulkOperationResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "op": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.BulkTasksRequest": {
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "type": "string",
+                    "enum": [
+                        "atomic",
+                        "best_effort"
+                    ]
+                },
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/skillsrock-test-task_internal_dto.BulkOperation"
+                    }
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.BulkTasksResponse": {
+            "type": "object",
+            "properties": {
+                "committed": {
+                    "type": "boolean"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/skillsrock-test-task_internal_dto.BulkOperationResult"
+                    }
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.CreateCommentRequest": {
+            "type": "object",
+            "required": [
+                "author",
+                "body"
+            ],
+            "properties": {
+                "author": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "body": {
+                    "type": "string",
+                    "maxLength": 10000
+                },
+                "reply_to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.CreateCommentResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.CreateTaskRequest": {
+            "type": "object",
+            "required": [
+                "title"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 10000
+                },
+                "due_at": {
+                    "type": "string"
+                },
+                "owner": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 200
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.CreateTaskResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.CreateWebhookRequest": {
+            "type": "object",
+            "required": [
+                "events",
+                "url"
+            ],
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "maxItems": 16,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "type": "string",
+                    "maxLength": 256
+                },
+                "url": {
+                    "type": "string",
+                    "maxLength": 2048
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.CreateWebhookResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "secret": {
+                    "type": "string"
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.GetAttachmentsResponse": {
+            "type": "object",
+            "properties": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/skillsrock-test-task_internal_models.Attachment"
+                    }
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.GetCommentsResponse": {
+            "type": "object",
+            "properties": {
+                "comments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/skillsrock-test-task_internal_models.Comment"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.GetDeliveriesResponse": {
+            "type": "object",
+            "properties": {
+                "deliveries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/skillsrock-test-task_internal_models.WebhookDelivery"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.GetImportJobResponse": {
+            "type": "object",
+            "properties": {
+                "job": {
+                    "$ref": "#/definitions/skillsrock-test-task_internal_models.ImportJob"
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.GetTaskByIDResponse": {
+            "type": "object",
+            "properties": {
+                "task": {
+                    "$ref": "#/definitions/skillsrock-test-task_internal_models.Task"
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.GetTasksResponse": {
+            "type": "object",
+            "properties": {
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/skillsrock-test-task_internal_models.Task"
+                    }
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.GetWebhooksResponse": {
+            "type": "object",
+            "properties": {
+                "webhooks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/skillsrock-test-task_internal_models.Webhook"
+                    }
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.ImportFromTrackerResponse": {
+            "type": "object",
+            "properties": {
+                "report": {
+                    "$ref": "#/definitions/skillsrock-test-task_internal_models.ExternalImportReport"
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.ImportTasksResponse": {
+            "type": "object",
+            "properties": {
+                "job": {
+                    "$ref": "#/definitions/skillsrock-test-task_internal_models.ImportJob"
+                },
+                "report": {
+                    "$ref": "#/definitions/skillsrock-test-task_internal_models.ImportReport"
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.RedeliverResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.UpdateCommentRequest": {
+            "type": "object",
+            "required": [
+                "body"
+            ],
+            "properties": {
+                "body": {
+                    "type": "string",
+                    "maxLength": 10000
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.UpdateTaskRequest": {
+            "type": "object",
+            "required": [
+                "status",
+                "title"
+            ],
             "properties": {
                 "description": {
+                    "type": "string",
+                    "maxLength": 10000
+                },
+                "due_at": {
                     "type": "string"
                 },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "new",
+                        "in_progress",
+                        "done"
+                    ]
+                },
                 "title": {
+                    "type": "string",
+                    "maxLength": 200
+                }
+            }
+        },
+        "skillsrock-test-task_internal_dto.UploadAttachmentResponse": {
+            "type": "object",
+            "properties": {
+                "attachment": {
+                    "$ref": "#/definitions/skillsrock-test-task_internal_models.Attachment"
+                }
+            }
+        },
+        "skillsrock-test-task_internal_models.Attachment": {
+            "type": "object",
+            "properties": {
+                "checksum": {
+                    "type": "string"
+                },
+                "content_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "task_id": {
+                    "type": "integer"
+                },
+                "uploader": {
                     "type": "string"
                 }
             }
         },
-        "skillsrock-test-task_internal_dto.CreateTaskResponse": {
+        "skillsrock-test-task_internal_models.Comment": {
             "type": "object",
             "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "body": {
+                    "type": "string"
+                },
                 "created_at": {
                     "type": "string"
                 },
+                "deleted": {
+                    "type": "boolean"
+                },
+                "edited": {
+                    "type": "boolean"
+                },
                 "id": {
                     "type": "integer"
                 },
-                "status": {
+                "reply_to": {
+                    "type": "integer"
+                },
+                "task_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "skillsrock-test-task_internal_dto.GetTaskByIDResponse": {
+        "skillsrock-test-task_internal_models.ExternalImportError": {
             "type": "object",
             "properties": {
-                "task": {
-                    "$ref": "#/definitions/skillsrock-test-task_internal_models.Task"
+                "error": {
+                    "type": "string"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "item": {
+                    "type": "integer"
+                },
+                "source_id": {
+                    "type": "string"
                 }
             }
         },
-        "skillsrock-test-task_internal_dto.GetTasksResponse": {
+        "skillsrock-test-task_internal_models.ExternalImportReport": {
             "type": "object",
             "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/skillsrock-test-task_internal_models.ExternalImportError"
+                    }
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "states": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/skillsrock-test-task_internal_models.StateMapping"
+                    }
+                },
                 "tasks": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/skillsrock-test-task_internal_models.Task"
+                        "$ref": "#/definitions/skillsrock-test-task_internal_models.ExternalTaskMapping"
                     }
+                },
+                "total_items": {
+                    "type": "integer"
+                },
+                "unchanged": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
                 }
             }
         },
-        "skillsrock-test-task_internal_dto.UpdateTaskRequest": {
+        "skillsrock-test-task_internal_models.ExternalTaskMapping": {
             "type": "object",
             "properties": {
-                "description": {
+                "outcome": {
+                    "type": "string"
+                },
+                "source_id": {
+                    "type": "string"
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "skillsrock-test-task_internal_models.ImportJob": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "format": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "mapping": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "report": {
+                    "$ref": "#/definitions/skillsrock-test-task_internal_models.ImportReport"
+                },
+                "started_at": {
                     "type": "string"
                 },
                 "status": {
                     "type": "string"
+                }
+            }
+        },
+        "skillsrock-test-task_internal_models.ImportReport": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "type": "boolean"
                 },
-                "title": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/skillsrock-test-task_internal_models.ImportRowError"
+                    }
+                },
+                "failed_rows": {
+                    "type": "integer"
+                },
+                "imported_rows": {
+                    "type": "integer"
+                },
+                "total_rows": {
+                    "type": "integer"
+                }
+            }
+        },
+        "skillsrock-test-task_internal_models.ImportRowError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "row": {
+                    "type": "integer"
+                }
+            }
+        },
+        "skillsrock-test-task_internal_models.StateMapping": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "status": {
                     "type": "string"
                 }
             }
@@ -328,15 +2406,24 @@ const docTemplate = `{
         "skillsrock-test-task_internal_models.Task": {
             "type": "object",
             "properties": {
+                "comments_count": {
+                    "type": "integer"
+                },
                 "created_at": {
                     "type": "string"
                 },
                 "description": {
                     "type": "string"
                 },
+                "due_at": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "owner": {
+                    "type": "string"
+                },
                 "status": {
                     "type": "string"
                 },
@@ -347,6 +2434,70 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "skillsrock-test-task_internal_models.Webhook": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "skillsrock-test-task_internal_models.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_status_code": {
+                    "type": "integer"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "webhook_id": {
+                    "type": "integer"
+                }
+            }
         }
     }
 }`
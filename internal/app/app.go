@@ -2,26 +2,46 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
 	"os"
 	"os/signal"
 	"skillsrock-test-task/internal/config"
 	"skillsrock-test-task/internal/database/postgres"
+	"skillsrock-test-task/internal/delivery/graphql"
+	grpcdelivery "skillsrock-test-task/internal/delivery/grpc"
 	"skillsrock-test-task/internal/delivery/http/v1/handler"
+	"skillsrock-test-task/internal/delivery/middleware"
 	"skillsrock-test-task/internal/delivery/routes"
+	"skillsrock-test-task/internal/publisher"
 	"skillsrock-test-task/internal/repository"
 	"skillsrock-test-task/internal/service"
+	"skillsrock-test-task/pkg/blobstore"
 	"skillsrock-test-task/pkg/logger"
 	"skillsrock-test-task/pkg/migrator"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/helmet"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 )
 
-const shutdownTimeout = 5 * time.Second
+const (
+	shutdownTimeout            = 5 * time.Second
+	blobCleanupInterval        = time.Minute
+	idempotencyCleanupInterval = 10 * time.Minute
+	rateLimitCleanupInterval   = time.Minute
+	outboxCleanupInterval      = time.Hour
+	natsTimeout                = 5 * time.Second
+	taskEventsChannel          = "task_events"
+)
 
-func Run() {
+func Run(cfg *config.Config) {
 	log, err := logger.New()
 	if err != nil {
 		panic(err)
@@ -29,46 +49,350 @@ func Run() {
 
 	ctx := logger.SetToCtx(context.Background(), log)
 
-	cfg, err := config.New()
+	db, err := postgres.NewDatabase(ctx, cfg.Postgres)
 	if err != nil {
-		log.Fatal(ctx, "Failed to load configuration", zap.Error(err))
+		log.Fatal(ctx, "Failed to connect to the database", zap.Error(err))
+	}
+	defer db.Close()
+
+	// With prefork the master runs the migrations, the gRPC server and the workers, its children serve HTTP.
+	child := fiber.IsChild()
+
+	// Without auto migration the migrations are applied with the migrate command before a release.
+	if !child && cfg.Migrations.Auto {
+		err = migrator.Start(ctx, cfg)
+		if err != nil {
+			log.Fatal(ctx, "Failed to run migrations", zap.Error(err))
+		}
 	}
 
-	db, err := postgres.NewDatabase(cfg.Postgres)
+	authServ, err := service.NewAuthService(cfg.Auth.Tokens)
 	if err != nil {
-		log.Fatal(ctx, "Failed to connect to the database", zap.Error(err))
+		log.Fatal(ctx, "Failed to load the auth tokens", zap.Error(err))
 	}
-	defer db.Close()
 
-	err = migrator.Start(cfg)
+	webhookRepo := repository.NewWebhookRepository(db)
+	webhookServ := service.NewWebhookService(
+		webhookRepo,
+		service.NewWebhookClient(cfg.Webhooks.Timeout, cfg.Webhooks.AllowPrivate),
+		cfg.Webhooks.MaxAttempts,
+		cfg.Webhooks.AllowPrivate,
+		log,
+	)
+
+	publisher, err := newEventPublisher(cfg, log, webhookServ)
+	if err != nil {
+		log.Fatal(ctx, "Failed to initialize the event publishers", zap.Error(err))
+	}
+
+	outboxRepo := repository.NewOutboxRepository(db)
+	outboxServ := service.NewOutboxService(outboxRepo, publisher)
+	streamServ := service.NewStreamService(outboxRepo, taskEventsChannel)
+	presenceServ := service.NewPresenceService()
+
+	repo := repository.NewTaskRepository(db, taskEventsChannel)
+	serv := service.NewTaskService(repo, cfg.Bulk.MaxOperations)
+
+	commentRepo := repository.NewCommentRepository(db)
+	commentServ := service.NewCommentService(commentRepo)
+
+	store, err := newBlobStore(cfg)
 	if err != nil {
-		log.Fatal(ctx, "Failed to run migrations", zap.Error(err))
+		log.Fatal(ctx, "Failed to initialize the blob storage", zap.Error(err))
 	}
 
-	repo := repository.NewTaskRepository(db)
-	serv := service.NewTaskService(repo)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	attachmentServ := service.NewAttachmentService(
+		attachmentRepo,
+		store,
+		cfg.Attachments.MaxSize,
+		strings.Split(cfg.Attachments.AllowedTypes, ","),
+	)
+
+	importRepo := repository.NewImportRepository(db, repo)
+	importServ := service.NewImportService(importRepo, repo, store, cfg.Import.MaxSize, cfg.Import.SyncMaxSize, log)
+
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	idempotencyServ := service.NewIdempotencyService(idempotencyRepo, cfg.Idempotency.TTL)
 
-	app := fiber.New()
+	workersCtx, stopWorkers := context.WithCancel(ctx)
+	defer stopWorkers()
 
-	routes.RegistrateRoutes(app, log, handler.NewHandler(serv, log))
+	rateLimiter, err := newRateLimiter(workersCtx, cfg, db)
+	if err != nil {
+		log.Fatal(ctx, "Failed to initialize the rate limiter", zap.Error(err))
+	}
+
+	corsConfig, err := newCORSConfig(cfg)
+	if err != nil {
+		log.Fatal(ctx, "Invalid CORS configuration", zap.Error(err))
+	}
+
+	var certs *certReloader
+	if cfg.HTTP.TLSEnabled() {
+		certs, err = newCertReloader(cfg.HTTP.TLS)
+		if err != nil {
+			log.Fatal(ctx, "Invalid TLS configuration", zap.Error(err))
+		}
+	}
+
+	if !child {
+		go attachmentServ.RunCleanup(workersCtx, blobCleanupInterval)
+		go idempotencyServ.RunCleanup(workersCtx, idempotencyCleanupInterval)
+		go webhookServ.RunDispatcher(workersCtx, cfg.Webhooks.PollInterval)
+		go outboxServ.RunRelay(workersCtx, cfg.Outbox.RelayInterval)
+		go outboxServ.RunCleanup(workersCtx, outboxCleanupInterval)
+		go importServ.RunWorker(workersCtx, cfg.Import.PollInterval)
+	}
+	// Every process serving HTTP streams the task events to its own clients and reads from the replicas.
+	go streamServ.RunListener(workersCtx)
+	go db.RunReplicaChecks(workersCtx, cfg.Postgres.ReplicaCheckInterval)
+
+	app := fiber.New(newServerConfig(cfg, log))
+
+	gqlHandler, err := graphql.NewHandler(
+		serv,
+		commentServ,
+		attachmentServ,
+		streamServ,
+		log,
+		cfg.GraphQL.MaxDepth,
+		cfg.GraphQL.MaxComplexity,
+	)
+	if err != nil {
+		log.Fatal(ctx, "Failed to load the GraphQL schema", zap.Error(err))
+	}
+
+	routes.RegistrateRoutes(app, log, handler.NewHandler(
+		serv,
+		commentServ,
+		attachmentServ,
+		importServ,
+		webhookServ,
+		streamServ,
+		presenceServ,
+		log,
+		cfg.HTTP.MaxJSONBody,
+	), gqlHandler, idempotencyServ, authServ, routes.Options{
+		CORS:                      corsConfig,
+		Security:                  securityConfig(cfg),
+		DocsContentSecurityPolicy: cfg.Security.DocsContentSecurityPolicy,
+		Docs:                      cfg.Docs.Enabled,
+		Board:                     cfg.Board.Enabled,
+		RateLimiter:               rateLimiter,
+		RateLimitBy:               cfg.RateLimit.By,
+		ReadYourWrites:            readYourWrites(cfg),
+	})
 
 	go func() {
-		if err := app.Listen(":" + cfg.HTTP.Port); err != nil {
+		if err := serveHTTP(app, cfg.HTTP.Port, certs); err != nil {
 			log.Fatal(ctx, "Failed running the server", zap.Error(err))
 		}
 	}()
 
+	var grpcServer *grpc.Server
+	if !child {
+		grpcServer = grpcdelivery.NewServer(serv, streamServ, authServ, log, readYourWrites(cfg))
+
+		lis, err := net.Listen("tcp", ":"+cfg.GRPC.Port)
+		if err != nil {
+			log.Fatal(ctx, "Failed to listen on the gRPC port", zap.Error(err))
+		}
+
+		go func() {
+			if err := grpcServer.Serve(lis); err != nil {
+				log.Fatal(ctx, "Failed running the gRPC server", zap.Error(err))
+			}
+		}()
+	}
+
 	c := make(chan os.Signal, 1)
-	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
-	<-c
+	// SIGHUP reloads the certificate, e.g. after it was renewed, any other signal stops the server.
+	for sig := <-c; sig == syscall.SIGHUP; sig = <-c {
+		if certs == nil {
+			log.Info(ctx, "Ignoring SIGHUP, TLS is off")
+			continue
+		}
+		if err := certs.Reload(); err != nil {
+			log.Error(ctx, "Failed to reload the TLS certificate, keeping the current one", zap.Error(err))
+			continue
+		}
+		log.Info(ctx, "Reloaded the TLS certificate")
+	}
 
 	ctx, shutdown := context.WithTimeout(ctx, shutdownTimeout)
 	defer shutdown()
 
+	// Open event streams would otherwise hold the shutdown until the timeout.
+	streamServ.Close()
+
 	if err = app.ShutdownWithContext(ctx); err != nil {
 		log.Error(ctx, "Failed shutting down the server", zap.Error(err))
 	}
 
+	if grpcServer != nil {
+		stopGRPCServer(ctx, grpcServer)
+	}
+
 	log.Info(ctx, "Server gracefully stopped")
 }
+
+// newServerConfig trusts the proxy header only when it comes from a trusted proxy, anyone could send it otherwise.
+func newServerConfig(cfg *config.Config, log logger.Logger) fiber.Config {
+	serverConfig := fiber.Config{
+		BodyLimit:    int(cfg.HTTP.MaxBodySize),
+		ReadTimeout:  cfg.HTTP.ReadTimeout,
+		WriteTimeout: cfg.HTTP.WriteTimeout,
+		IdleTimeout:  cfg.HTTP.IdleTimeout,
+		Prefork:      cfg.HTTP.Prefork,
+		ErrorHandler: handler.ErrorHandler(log),
+	}
+
+	if proxies := cfg.HTTP.TrustedProxyList(); len(proxies) > 0 {
+		serverConfig.ProxyHeader = cfg.HTTP.ProxyHeader
+		serverConfig.EnableTrustedProxyCheck = true
+		serverConfig.TrustedProxies = proxies
+	}
+
+	return serverConfig
+}
+
+// serveHTTP serves HTTPS when there are certificates, the listener takes them from the reloader.
+func serveHTTP(app *fiber.App, port string, certs *certReloader) error {
+	if certs == nil {
+		return app.Listen(":" + port)
+	}
+
+	lis, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return err
+	}
+
+	return app.Listener(tls.NewListener(lis, certs.Config()))
+}
+
+// stopGRPCServer waits for the running calls until the context expires, then closes them.
+func stopGRPCServer(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+	}
+}
+
+// newCORSConfig returns no config when no origin is allowed, the API then answers same-origin requests only.
+func newCORSConfig(cfg *config.Config) (*cors.Config, error) {
+	if cfg.CORS.AllowOrigins == "" {
+		return nil, nil
+	}
+	if cfg.CORS.AllowCredentials && cfg.CORS.AllowOrigins == "*" {
+		return nil, fmt.Errorf("credentials can not be allowed for any origin, list the origins")
+	}
+
+	return &cors.Config{
+		AllowOrigins:     cfg.CORS.AllowOrigins,
+		AllowMethods:     cfg.CORS.AllowMethods,
+		AllowHeaders:     cfg.CORS.AllowHeaders,
+		ExposeHeaders:    cfg.CORS.ExposeHeaders,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		MaxAge:           int(cfg.CORS.MaxAge.Seconds()),
+	}, nil
+}
+
+// securityConfig sends HSTS over HTTPS only, browsers ignore it on plain HTTP.
+func securityConfig(cfg *config.Config) helmet.Config {
+	return helmet.Config{
+		XFrameOptions:         cfg.Security.FrameOptions,
+		HSTSMaxAge:            int(cfg.Security.HSTSMaxAge.Seconds()),
+		HSTSPreloadEnabled:    cfg.Security.HSTSPreload,
+		ContentSecurityPolicy: cfg.Security.ContentSecurityPolicy,
+	}
+}
+
+// readYourWrites is the read-your-writes window, there is none without replicas as every read goes to the primary.
+func readYourWrites(cfg *config.Config) time.Duration {
+	if cfg.Postgres.Replicas == "" {
+		return 0
+	}
+	return cfg.Postgres.ReadYourWrites
+}
+
+// newRateLimiter returns no limiter when rate limiting is off. Buckets in memory are for a single instance,
+// replicas share the buckets in Postgres.
+func newRateLimiter(ctx context.Context, cfg *config.Config, db *postgres.Database) (middleware.RateLimiter, error) {
+	var repo service.RateLimitRepository
+
+	switch cfg.RateLimit.Store {
+	case config.RateLimitStoreOff:
+		return nil, nil
+	case config.RateLimitStoreMemory:
+		repo = repository.NewMemoryRateLimitRepository()
+	case config.RateLimitStorePostgres:
+		repo = repository.NewRateLimitRepository(db)
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", cfg.RateLimit.Store)
+	}
+
+	switch cfg.RateLimit.By {
+	case middleware.RateLimitByKey, middleware.RateLimitByUser, middleware.RateLimitByIP:
+	default:
+		return nil, fmt.Errorf("unknown rate limit client %q", cfg.RateLimit.By)
+	}
+
+	serv, err := service.NewRateLimitService(repo, cfg.RateLimit.Read, cfg.RateLimit.Write, cfg.RateLimit.Groups)
+	if err != nil {
+		return nil, err
+	}
+
+	go serv.RunCleanup(ctx, rateLimitCleanupInterval)
+
+	return serv, nil
+}
+
+func newBlobStore(cfg *config.Config) (blobstore.BlobStore, error) {
+	switch cfg.Attachments.Storage {
+	case config.StorageLocal:
+		return blobstore.NewLocalStore(cfg.Attachments.LocalPath)
+	case config.StorageS3:
+		return blobstore.NewS3Store(blobstore.S3Options{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+		})
+	default:
+		return nil, fmt.Errorf("unknown attachments storage %q", cfg.Attachments.Storage)
+	}
+}
+
+func newEventPublisher(cfg *config.Config, log logger.Logger, webhooks *service.WebhookService) (*publisher.Multi, error) {
+	multi := publisher.NewMulti()
+
+	for _, name := range strings.Split(cfg.Outbox.Publishers, ",") {
+		switch name = strings.TrimSpace(name); name {
+		case config.PublisherLog:
+			multi.Add(name, publisher.NewLogPublisher(log))
+		case config.PublisherWebhook:
+			multi.Add(name, webhooks)
+		case config.PublisherNATS:
+			nats, err := publisher.NewNATSPublisher(cfg.NATS.URL, cfg.NATS.SubjectPrefix, natsTimeout)
+			if err != nil {
+				return nil, err
+			}
+			multi.Add(name, nats)
+		default:
+			return nil, fmt.Errorf("unknown event publisher %q", name)
+		}
+	}
+
+	return multi, nil
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"skillsrock-test-task/internal/config"
+	"sync/atomic"
+)
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	config.ClientAuthNone:    tls.NoClientCert,
+	config.ClientAuthRequest: tls.VerifyClientCertIfGiven,
+	config.ClientAuthRequire: tls.RequireAndVerifyClientCert,
+}
+
+// certReloader serves the certificate and the client CAs last loaded from the files, Reload swaps them
+// without dropping the connections of the listener.
+type certReloader struct {
+	cfg     config.TLSConfig
+	current atomic.Pointer[tls.Config]
+}
+
+func newCertReloader(cfg config.TLSConfig) (*certReloader, error) {
+	r := &certReloader{cfg: cfg}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Reload loads the files again. A certificate that fails to load leaves the one in use.
+func (r *certReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load the TLS certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   clientAuthTypes[r.cfg.ClientAuth],
+	}
+
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read the client CAs: %w", err)
+		}
+
+		tlsConfig.ClientCAs = x509.NewCertPool()
+		if !tlsConfig.ClientCAs.AppendCertsFromPEM(pem) {
+			return errors.New("no client CA certificate found in " + r.cfg.ClientCAFile)
+		}
+	}
+
+	r.current.Store(tlsConfig)
+
+	return nil
+}
+
+// Config is the config of the listener, every handshake takes the config loaded last.
+func (r *certReloader) Config() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.current.Load(), nil
+		},
+	}
+}
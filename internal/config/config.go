@@ -3,50 +3,597 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
-var (
-	ErrEmptyConfig = errors.New("config is empty")
-)
-
 type (
 	HTTPConfig struct {
-		Port string `env:"HTTP_PORT"`
+		Port           string        `yaml:"port" toml:"port" env:"HTTP_PORT" env-description:"Port of the HTTP server"`
+		MaxJSONBody    int64         `yaml:"max_json_body" toml:"max_json_body" env:"HTTP_MAX_JSON_BODY" env-description:"Limit of a JSON body in bytes"`
+		MaxBodySize    int64         `yaml:"max_body_size" toml:"max_body_size" env:"HTTP_MAX_BODY_SIZE" env-description:"Limit of any body in bytes, 0 for the largest upload and 1MB"`
+		ReadTimeout    time.Duration `yaml:"read_timeout" toml:"read_timeout" env:"HTTP_READ_TIMEOUT" env-description:"Time to read a request, 0 for none"`
+		WriteTimeout   time.Duration `yaml:"write_timeout" toml:"write_timeout" env:"HTTP_WRITE_TIMEOUT" env-description:"Time to write a response, 0 for none"`
+		IdleTimeout    time.Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" env-description:"Time a keep-alive connection waits for a request"`
+		TrustedProxies string        `yaml:"trusted_proxies" toml:"trusted_proxies" env:"HTTP_TRUSTED_PROXIES" env-description:"Comma separated IPs and CIDR ranges of the reverse proxies"`
+		ProxyHeader    string        `yaml:"proxy_header" toml:"proxy_header" env:"HTTP_PROXY_HEADER" env-description:"Header the trusted proxies send the client IP in"`
+		Prefork        bool          `yaml:"prefork" toml:"prefork" env:"HTTP_PREFORK" env-description:"Serve HTTP from a process per CPU"`
+		TLS            TLSConfig     `yaml:"tls" toml:"tls"`
+	}
+
+	TLSConfig struct {
+		CertFile     string `yaml:"cert_file" toml:"cert_file" env:"HTTP_TLS_CERT_FILE" env-description:"Certificate of the HTTPS server, reloaded on SIGHUP"`
+		KeyFile      string `yaml:"key_file" toml:"key_file" env:"HTTP_TLS_KEY_FILE" env-description:"Private key of the certificate"`
+		ClientCAFile string `yaml:"client_ca_file" toml:"client_ca_file" env:"HTTP_TLS_CLIENT_CA_FILE" env-description:"CAs client certificates are verified with"`
+		ClientAuth   string `yaml:"client_auth" toml:"client_auth" env:"HTTP_TLS_CLIENT_AUTH" env-description:"Client certificates: none, request or require, require by default with client CAs"`
+	}
+
+	GRPCConfig struct {
+		Port string `yaml:"port" toml:"port" env:"GRPC_PORT" env-description:"Port of the gRPC server"`
 	}
 
 	PostgresConfig struct {
-		Host     string `env:"POSTGRES_HOST"`
-		Port     int    `env:"POSTGRES_PORT"`
-		Name     string `env:"POSTGRES_DB"`
-		User     string `env:"POSTGRES_USER"`
-		Password string `env:"POSTGRES_PASSWORD"`
-		SSLMode  string `env:"POSTGRES_SSL"`
+		Host     string `yaml:"host" toml:"host" env:"POSTGRES_HOST" env-required:"true" env-description:"Host of the database"`
+		Port     int    `yaml:"port" toml:"port" env:"POSTGRES_PORT" env-description:"Port of the database"`
+		Name     string `yaml:"name" toml:"name" env:"POSTGRES_DB" env-required:"true" env-description:"Name of the database"`
+		User     string `yaml:"user" toml:"user" env:"POSTGRES_USER" env-required:"true" env-description:"User of the database"`
+		Password string `yaml:"password" toml:"password" env:"POSTGRES_PASSWORD" env-description:"Password of the user" secret:"true"`
+		SSLMode  string `yaml:"ssl_mode" toml:"ssl_mode" env:"POSTGRES_SSL" env-description:"sslmode of the connection"`
+
+		MaxConns          int32         `yaml:"max_conns" toml:"max_conns" env:"POSTGRES_MAX_CONNS" env-description:"Size of the pool, 0 for 4 or the number of CPUs when larger"`
+		MinConns          int32         `yaml:"min_conns" toml:"min_conns" env:"POSTGRES_MIN_CONNS" env-description:"Connections the pool keeps open when idle"`
+		MaxConnLifetime   time.Duration `yaml:"max_conn_lifetime" toml:"max_conn_lifetime" env:"POSTGRES_MAX_CONN_LIFETIME" env-description:"Time after which a connection is closed"`
+		MaxConnIdleTime   time.Duration `yaml:"max_conn_idle_time" toml:"max_conn_idle_time" env:"POSTGRES_MAX_CONN_IDLE_TIME" env-description:"Time after which an idle connection is closed"`
+		HealthCheckPeriod time.Duration `yaml:"health_check_period" toml:"health_check_period" env:"POSTGRES_HEALTH_CHECK_PERIOD" env-description:"Interval the idle connections are checked at"`
+		ConnectTimeout    time.Duration `yaml:"connect_timeout" toml:"connect_timeout" env:"POSTGRES_CONNECT_TIMEOUT" env-description:"Timeout of a connection attempt"`
+		ConnectAttempts   int           `yaml:"connect_attempts" toml:"connect_attempts" env:"POSTGRES_CONNECT_ATTEMPTS" env-description:"Attempts to reach the database at startup"`
+		ConnectBackoff    time.Duration `yaml:"connect_backoff" toml:"connect_backoff" env:"POSTGRES_CONNECT_BACKOFF" env-description:"Wait after the first failed attempt, doubled after every other one"`
+
+		Replicas             string        `yaml:"replicas" toml:"replicas" env:"POSTGRES_REPLICAS" env-description:"Comma separated host or host:port of read replicas, reached with the credentials of the primary"`
+		ReplicaCheckInterval time.Duration `yaml:"replica_check_interval" toml:"replica_check_interval" env:"POSTGRES_REPLICA_CHECK_INTERVAL" env-description:"Interval the replicas are checked at"`
+		ReadYourWrites       time.Duration `yaml:"read_your_writes" toml:"read_your_writes" env:"POSTGRES_READ_YOUR_WRITES" env-description:"Time a client reads from the primary after a write, 0 for off"`
+	}
+
+	AttachmentsConfig struct {
+		Storage      string `yaml:"storage" toml:"storage" env:"ATTACHMENTS_STORAGE" env-description:"Where attachments are stored: local or s3"`
+		LocalPath    string `yaml:"local_path" toml:"local_path" env:"ATTACHMENTS_LOCAL_PATH" env-description:"Directory of the local storage"`
+		MaxSize      int64  `yaml:"max_size" toml:"max_size" env:"ATTACHMENTS_MAX_SIZE" env-description:"Limit of an attachment in bytes"`
+		AllowedTypes string `yaml:"allowed_types" toml:"allowed_types" env:"ATTACHMENTS_ALLOWED_TYPES" env-description:"Comma separated content types of attachments, type/* for any subtype"`
+	}
+
+	ImportConfig struct {
+		MaxSize      int64         `yaml:"max_size" toml:"max_size" env:"IMPORT_MAX_SIZE" env-description:"Limit of an import file in bytes"`
+		SyncMaxSize  int64         `yaml:"sync_max_size" toml:"sync_max_size" env:"IMPORT_SYNC_MAX_SIZE" env-description:"Largest file imported while the request waits"`
+		PollInterval time.Duration `yaml:"poll_interval" toml:"poll_interval" env:"IMPORT_POLL_INTERVAL" env-description:"Interval the import worker looks for jobs at"`
+	}
+
+	S3Config struct {
+		Endpoint  string `yaml:"endpoint" toml:"endpoint" env:"S3_ENDPOINT" env-description:"Endpoint of the S3 storage"`
+		Region    string `yaml:"region" toml:"region" env:"S3_REGION" env-description:"Region of the bucket"`
+		Bucket    string `yaml:"bucket" toml:"bucket" env:"S3_BUCKET" env-description:"Bucket of the attachments"`
+		AccessKey string `yaml:"access_key" toml:"access_key" env:"S3_ACCESS_KEY" env-description:"Access key of the storage"`
+		SecretKey string `yaml:"secret_key" toml:"secret_key" env:"S3_SECRET_KEY" env-description:"Secret key of the storage" secret:"true"`
+	}
+
+	BulkConfig struct {
+		MaxOperations int `yaml:"max_operations" toml:"max_operations" env:"BULK_MAX_OPERATIONS" env-description:"Limit of operations in a bulk request"`
+	}
+
+	IdempotencyConfig struct {
+		TTL time.Duration `yaml:"ttl" toml:"ttl" env:"IDEMPOTENCY_TTL" env-description:"Time idempotency keys are kept"`
+	}
+
+	WebhooksConfig struct {
+		MaxAttempts  int           `yaml:"max_attempts" toml:"max_attempts" env:"WEBHOOKS_MAX_ATTEMPTS" env-description:"Attempts of a delivery before it fails"`
+		Timeout      time.Duration `yaml:"timeout" toml:"timeout" env:"WEBHOOKS_TIMEOUT" env-description:"Timeout of a delivery"`
+		PollInterval time.Duration `yaml:"poll_interval" toml:"poll_interval" env:"WEBHOOKS_POLL_INTERVAL" env-description:"Interval the dispatcher looks for deliveries at"`
+		AllowPrivate bool          `yaml:"allow_private" toml:"allow_private" env:"WEBHOOKS_ALLOW_PRIVATE" env-description:"Allow targets on loopback, link-local and private networks"`
+	}
+
+	OutboxConfig struct {
+		Publishers    string        `yaml:"publishers" toml:"publishers" env:"OUTBOX_PUBLISHERS" env-description:"Comma separated publishers of task events: log, webhook, nats"`
+		RelayInterval time.Duration `yaml:"relay_interval" toml:"relay_interval" env:"OUTBOX_RELAY_INTERVAL" env-description:"Interval the relay looks for events at"`
+	}
+
+	NATSConfig struct {
+		URL           string `yaml:"url" toml:"url" env:"NATS_URL" env-description:"URL of the NATS server" secret:"userinfo"`
+		SubjectPrefix string `yaml:"subject_prefix" toml:"subject_prefix" env:"NATS_SUBJECT_PREFIX" env-description:"Prefix of the subjects of task events"`
+	}
+
+	GraphQLConfig struct {
+		MaxDepth      int `yaml:"max_depth" toml:"max_depth" env:"GRAPHQL_MAX_DEPTH" env-description:"Limit of the depth of a query"`
+		MaxComplexity int `yaml:"max_complexity" toml:"max_complexity" env:"GRAPHQL_MAX_COMPLEXITY" env-description:"Limit of the complexity of a query"`
+	}
+
+	AuthConfig struct {
+		Tokens string `yaml:"tokens" toml:"tokens" env:"AUTH_TOKENS" env-description:"Comma separated <token>:<user> API keys" secret:"true"`
+	}
+
+	CORSConfig struct {
+		AllowOrigins     string        `yaml:"allow_origins" toml:"allow_origins" env:"CORS_ALLOW_ORIGINS" env-description:"Comma separated origins allowed to call the API, none for same-origin only"`
+		AllowMethods     string        `yaml:"allow_methods" toml:"allow_methods" env:"CORS_ALLOW_METHODS" env-description:"Methods of cross-origin requests"`
+		AllowHeaders     string        `yaml:"allow_headers" toml:"allow_headers" env:"CORS_ALLOW_HEADERS" env-description:"Headers of cross-origin requests"`
+		ExposeHeaders    string        `yaml:"expose_headers" toml:"expose_headers" env:"CORS_EXPOSE_HEADERS" env-description:"Response headers exposed to cross-origin requests"`
+		AllowCredentials bool          `yaml:"allow_credentials" toml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS" env-description:"Allow cross-origin requests with credentials"`
+		MaxAge           time.Duration `yaml:"max_age" toml:"max_age" env:"CORS_MAX_AGE" env-description:"Time browsers cache preflight answers"`
+	}
+
+	SecurityConfig struct {
+		HSTSMaxAge                time.Duration `yaml:"hsts_max_age" toml:"hsts_max_age" env:"SECURITY_HSTS_MAX_AGE" env-description:"max-age of Strict-Transport-Security"`
+		HSTSPreload               bool          `yaml:"hsts_preload" toml:"hsts_preload" env:"SECURITY_HSTS_PRELOAD" env-description:"Add preload to Strict-Transport-Security"`
+		FrameOptions              string        `yaml:"frame_options" toml:"frame_options" env:"SECURITY_FRAME_OPTIONS" env-description:"X-Frame-Options of the responses"`
+		ContentSecurityPolicy     string        `yaml:"csp" toml:"csp" env:"SECURITY_CSP" env-description:"Content-Security-Policy of the API"`
+		DocsContentSecurityPolicy string        `yaml:"docs_csp" toml:"docs_csp" env:"SECURITY_DOCS_CSP" env-description:"Content-Security-Policy of the Swagger UI"`
+	}
+
+	DocsConfig struct {
+		Enabled bool `yaml:"enabled" toml:"enabled" env:"DOCS_ENABLED" env-description:"Serve the Swagger UI and the API docs"`
+	}
+
+	BoardConfig struct {
+		Enabled bool `yaml:"enabled" toml:"enabled" env:"BOARD_ENABLED" env-description:"Serve the board WebSocket"`
+	}
+
+	MigrationsConfig struct {
+		Path        string        `yaml:"path" toml:"path" env:"MIGRATIONS_PATH" env-description:"Directory of the migrations, empty for the ones built into the binary"`
+		Auto        bool          `yaml:"auto" toml:"auto" env:"MIGRATIONS_AUTO" env-description:"Migrate up when the server starts"`
+		LockTimeout time.Duration `yaml:"lock_timeout" toml:"lock_timeout" env:"MIGRATIONS_LOCK_TIMEOUT" env-description:"Time to wait for another instance to finish migrating"`
+	}
+
+	RateLimitConfig struct {
+		Store  string `yaml:"store" toml:"store" env:"RATE_LIMIT_STORE" env-description:"Store of the buckets: memory, postgres or off"`
+		By     string `yaml:"by" toml:"by" env:"RATE_LIMIT_BY" env-description:"Clients are counted by key, user or ip"`
+		Read   string `yaml:"read" toml:"read" env:"RATE_LIMIT_READ" env-description:"Default read limit, <burst>/<period>"`
+		Write  string `yaml:"write" toml:"write" env:"RATE_LIMIT_WRITE" env-description:"Default write limit, <burst>/<period>"`
+		Groups string `yaml:"groups" toml:"groups" env:"RATE_LIMIT_GROUPS" env-description:"Comma separated <group>.<read|write>=<limit> overrides"`
 	}
 
 	Config struct {
-		HTTP           HTTPConfig
-		Postgres       PostgresConfig
-		MigrationsPath string `env:"MIGRATIONS_PATH"`
+		HTTP        HTTPConfig        `yaml:"http" toml:"http"`
+		GRPC        GRPCConfig        `yaml:"grpc" toml:"grpc"`
+		GraphQL     GraphQLConfig     `yaml:"graphql" toml:"graphql"`
+		Auth        AuthConfig        `yaml:"auth" toml:"auth"`
+		RateLimit   RateLimitConfig   `yaml:"rate_limit" toml:"rate_limit"`
+		CORS        CORSConfig        `yaml:"cors" toml:"cors"`
+		Security    SecurityConfig    `yaml:"security" toml:"security"`
+		Docs        DocsConfig        `yaml:"docs" toml:"docs"`
+		Board       BoardConfig       `yaml:"board" toml:"board"`
+		Postgres    PostgresConfig    `yaml:"postgres" toml:"postgres"`
+		Bulk        BulkConfig        `yaml:"bulk" toml:"bulk"`
+		Idempotency IdempotencyConfig `yaml:"idempotency" toml:"idempotency"`
+		Webhooks    WebhooksConfig    `yaml:"webhooks" toml:"webhooks"`
+		Outbox      OutboxConfig      `yaml:"outbox" toml:"outbox"`
+		NATS        NATSConfig        `yaml:"nats" toml:"nats"`
+		Attachments AttachmentsConfig `yaml:"attachments" toml:"attachments"`
+		S3          S3Config          `yaml:"s3" toml:"s3"`
+		Import      ImportConfig      `yaml:"import" toml:"import"`
+		Migrations  MigrationsConfig  `yaml:"migrations" toml:"migrations"`
 	}
 )
 
-func New() (*Config, error) {
-	var (
-		err error
-		cfg Config
-	)
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+
+	ClientAuthNone    = "none"
+	ClientAuthRequest = "request"
+	ClientAuthRequire = "require"
+
+	RateLimitStoreMemory   = "memory"
+	RateLimitStorePostgres = "postgres"
+	RateLimitStoreOff      = "off"
+
+	PublisherLog     = "log"
+	PublisherWebhook = "webhook"
+	PublisherNATS    = "nats"
+
+	defaultHTTPPort = "8080"
+	defaultGRPCPort = "9090"
+
+	defaultPostgresPort    = 5432
+	defaultPostgresSSLMode = "prefer"
+	// The pool defaults are the ones of pgx.
+	defaultPostgresMaxConnLifetime   = time.Hour
+	defaultPostgresMaxConnIdleTime   = 30 * time.Minute
+	defaultPostgresHealthCheckPeriod = time.Minute
+	defaultPostgresConnectTimeout    = 5 * time.Second
+	defaultPostgresConnectAttempts   = 10
+	defaultPostgresConnectBackoff    = 500 * time.Millisecond
+	defaultPostgresReplicaCheck      = 5 * time.Second
+	defaultPostgresReadYourWrites    = 5 * time.Second
+
+	defaultHTTPMaxJSONBody = 1 << 20
+	defaultHTTPReadTimeout = time.Minute
+	defaultHTTPIdleTimeout = 2 * time.Minute
+	defaultHTTPProxyHeader = "X-Forwarded-For"
+	// multipartFormOverhead is the room left for the other parts and the boundaries of an upload.
+	multipartFormOverhead = 1 << 20
+
+	defaultGraphQLMaxDepth      = 10
+	defaultGraphQLMaxComplexity = 1000
+
+	defaultCORSAllowMethods  = "GET,HEAD,POST,PUT,PATCH,DELETE,OPTIONS"
+	defaultCORSAllowHeaders  = "Content-Type,Authorization,Idempotency-Key,If-None-Match,Last-Event-ID,Range,X-Request-ID,X-Last-Write"
+	defaultCORSExposeHeaders = "X-Request-ID,X-Last-Write,ETag,Content-Disposition,Content-Range,Idempotent-Replayed,Retry-After," +
+		"RateLimit-Policy,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset"
+	defaultCORSMaxAge = 10 * time.Minute
+
+	defaultSecurityHSTSMaxAge   = 180 * 24 * time.Hour
+	defaultSecurityFrameOptions = "DENY"
+	defaultSecurityCSP          = "default-src 'none'; frame-ancestors 'none'"
+	// The Swagger UI starts with an inline script and styles its pages inline.
+	defaultSecurityDocsCSP = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; " +
+		"img-src 'self' data:; frame-ancestors 'none'"
+
+	defaultRateLimitBy    = "key"
+	defaultRateLimitRead  = "300/1m"
+	defaultRateLimitWrite = "60/1m"
 
-	err = cleanenv.ReadEnv(&cfg)
+	defaultBulkMaxOperations = 1000
+	defaultIdempotencyTTL    = 24 * time.Hour
 
-	if cfg == (Config{}) {
-		return nil, ErrEmptyConfig
+	defaultWebhooksMaxAttempts  = 8
+	defaultWebhooksTimeout      = 10 * time.Second
+	defaultWebhooksPollInterval = 5 * time.Second
+
+	defaultOutboxPublishers    = PublisherWebhook
+	defaultOutboxRelayInterval = time.Second
+	defaultNATSSubjectPrefix   = "tasks"
+
+	defaultAttachmentsPath         = "attachments"
+	defaultAttachmentsMaxSize      = 10 << 20
+	defaultAttachmentsAllowedTypes = "image/*,text/plain,application/pdf,application/zip,application/x-gzip"
+
+	defaultImportMaxSize      = 50 << 20
+	defaultImportSyncMaxSize  = 1 << 20
+	defaultImportPollInterval = 5 * time.Second
+
+	defaultMigrationsLockTimeout = 5 * time.Minute
+)
+
+// New loads the config in layers: the defaults, the file when there is one, the environment and the flags
+// registered by RegisterFlags. YAML (.yaml, .yml) and TOML (.toml) files are read, a later layer overrides the
+// settings of the earlier ones.
+func New(file string) (*Config, error) {
+	cfg := defaults()
+
+	if file != "" {
+		if err := readFile(file, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
 	}
 
-	if err != nil {
+	if err := cfg.checkRequired(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	if err := cleanenv.ReadEnv(&cfg); err != nil {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
+	cfg.setDerived()
+
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
 	return &cfg, nil
 }
+
+// NewMigrations loads the migrations settings in the layers of New. It does not check the other settings, so the
+// commands that do not connect to the database work without them.
+func NewMigrations(file string) (*MigrationsConfig, error) {
+	cfg := defaults()
+
+	if file != "" {
+		if err := readFile(file, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+	}
+
+	if err := cleanenv.ReadEnv(&cfg.Migrations); err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	return &cfg.Migrations, nil
+}
+
+func readFile(file string, cfg *Config) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	switch ext := strings.ToLower(filepath.Ext(file)); ext {
+	case ".yaml", ".yml":
+		return cleanenv.ParseYAML(f, cfg)
+	case ".toml":
+		return cleanenv.ParseTOML(f, cfg)
+	default:
+		return fmt.Errorf("unknown format %q, expected .yaml, .yml or .toml", ext)
+	}
+}
+
+// defaults is the config before any layer is loaded. Required settings have no defaults.
+func defaults() Config {
+	return Config{
+		HTTP: HTTPConfig{
+			Port:        defaultHTTPPort,
+			MaxJSONBody: defaultHTTPMaxJSONBody,
+			ReadTimeout: defaultHTTPReadTimeout,
+			IdleTimeout: defaultHTTPIdleTimeout,
+			ProxyHeader: defaultHTTPProxyHeader,
+		},
+		GRPC: GRPCConfig{
+			Port: defaultGRPCPort,
+		},
+		GraphQL: GraphQLConfig{
+			MaxDepth:      defaultGraphQLMaxDepth,
+			MaxComplexity: defaultGraphQLMaxComplexity,
+		},
+		RateLimit: RateLimitConfig{
+			Store: RateLimitStoreMemory,
+			By:    defaultRateLimitBy,
+			Read:  defaultRateLimitRead,
+			Write: defaultRateLimitWrite,
+		},
+		CORS: CORSConfig{
+			AllowMethods:  defaultCORSAllowMethods,
+			AllowHeaders:  defaultCORSAllowHeaders,
+			ExposeHeaders: defaultCORSExposeHeaders,
+			MaxAge:        defaultCORSMaxAge,
+		},
+		Security: SecurityConfig{
+			HSTSMaxAge:                defaultSecurityHSTSMaxAge,
+			FrameOptions:              defaultSecurityFrameOptions,
+			ContentSecurityPolicy:     defaultSecurityCSP,
+			DocsContentSecurityPolicy: defaultSecurityDocsCSP,
+		},
+		Docs: DocsConfig{
+			Enabled: true,
+		},
+		Board: BoardConfig{
+			Enabled: true,
+		},
+		Postgres: PostgresConfig{
+			Port:              defaultPostgresPort,
+			SSLMode:           defaultPostgresSSLMode,
+			MaxConnLifetime:   defaultPostgresMaxConnLifetime,
+			MaxConnIdleTime:   defaultPostgresMaxConnIdleTime,
+			HealthCheckPeriod: defaultPostgresHealthCheckPeriod,
+			ConnectTimeout:    defaultPostgresConnectTimeout,
+			ConnectAttempts:   defaultPostgresConnectAttempts,
+			ConnectBackoff:    defaultPostgresConnectBackoff,
+
+			ReplicaCheckInterval: defaultPostgresReplicaCheck,
+			ReadYourWrites:       defaultPostgresReadYourWrites,
+		},
+		Bulk: BulkConfig{
+			MaxOperations: defaultBulkMaxOperations,
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: defaultIdempotencyTTL,
+		},
+		Webhooks: WebhooksConfig{
+			MaxAttempts:  defaultWebhooksMaxAttempts,
+			Timeout:      defaultWebhooksTimeout,
+			PollInterval: defaultWebhooksPollInterval,
+		},
+		Outbox: OutboxConfig{
+			Publishers:    defaultOutboxPublishers,
+			RelayInterval: defaultOutboxRelayInterval,
+		},
+		NATS: NATSConfig{
+			SubjectPrefix: defaultNATSSubjectPrefix,
+		},
+		Attachments: AttachmentsConfig{
+			Storage:      StorageLocal,
+			LocalPath:    defaultAttachmentsPath,
+			MaxSize:      defaultAttachmentsMaxSize,
+			AllowedTypes: defaultAttachmentsAllowedTypes,
+		},
+		Import: ImportConfig{
+			MaxSize:      defaultImportMaxSize,
+			SyncMaxSize:  defaultImportSyncMaxSize,
+			PollInterval: defaultImportPollInterval,
+		},
+		Migrations: MigrationsConfig{
+			Auto:        true,
+			LockTimeout: defaultMigrationsLockTimeout,
+		},
+	}
+}
+
+// setDerived sets the settings whose defaults depend on other settings.
+func (cfg *Config) setDerived() {
+	if cfg.HTTP.MaxBodySize == 0 {
+		cfg.HTTP.MaxBodySize = max(cfg.Attachments.MaxSize, cfg.Import.MaxSize) + multipartFormOverhead
+	}
+	if cfg.Postgres.MaxConns == 0 {
+		cfg.Postgres.MaxConns = int32(max(4, runtime.NumCPU()))
+	}
+	if cfg.HTTP.TLS.ClientAuth == "" {
+		cfg.HTTP.TLS.ClientAuth = ClientAuthNone
+		if cfg.HTTP.TLS.ClientCAFile != "" {
+			cfg.HTTP.TLS.ClientAuth = ClientAuthRequire
+		}
+	}
+}
+
+// validate reports every invalid setting at once, so they can be fixed in one go.
+func (cfg *Config) validate() error {
+	var errs []error
+
+	checkPort(&errs, "HTTP_PORT", cfg.HTTP.Port)
+	checkPort(&errs, "GRPC_PORT", cfg.GRPC.Port)
+	checkPort(&errs, "POSTGRES_PORT", strconv.Itoa(cfg.Postgres.Port))
+	checkOneOf(&errs, "POSTGRES_SSL", cfg.Postgres.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+
+	pg := cfg.Postgres
+	checkAtLeast(&errs, "POSTGRES_MAX_CONNS", pg.MaxConns, 1)
+	checkAtLeast(&errs, "POSTGRES_MIN_CONNS", pg.MinConns, 0)
+	if pg.MinConns > pg.MaxConns {
+		errs = append(errs, fmt.Errorf("POSTGRES_MIN_CONNS %d is larger than POSTGRES_MAX_CONNS %d", pg.MinConns, pg.MaxConns))
+	}
+	checkAtLeast(&errs, "POSTGRES_MAX_CONN_LIFETIME", pg.MaxConnLifetime, time.Second)
+	checkAtLeast(&errs, "POSTGRES_MAX_CONN_IDLE_TIME", pg.MaxConnIdleTime, time.Second)
+	checkAtLeast(&errs, "POSTGRES_HEALTH_CHECK_PERIOD", pg.HealthCheckPeriod, time.Second)
+	checkAtLeast(&errs, "POSTGRES_CONNECT_TIMEOUT", pg.ConnectTimeout, time.Millisecond)
+	checkAtLeast(&errs, "POSTGRES_CONNECT_ATTEMPTS", pg.ConnectAttempts, 1)
+	checkAtLeast(&errs, "POSTGRES_CONNECT_BACKOFF", pg.ConnectBackoff, 0)
+	if _, err := pg.ReplicaConfigs(); err != nil {
+		errs = append(errs, err)
+	}
+	checkAtLeast(&errs, "POSTGRES_REPLICA_CHECK_INTERVAL", pg.ReplicaCheckInterval, 100*time.Millisecond)
+	checkAtLeast(&errs, "POSTGRES_READ_YOUR_WRITES", pg.ReadYourWrites, 0)
+
+	http := cfg.HTTP
+	checkAtLeast(&errs, "HTTP_MAX_JSON_BODY", http.MaxJSONBody, 1)
+	checkAtLeast(&errs, "HTTP_READ_TIMEOUT", http.ReadTimeout, 0)
+	checkAtLeast(&errs, "HTTP_WRITE_TIMEOUT", http.WriteTimeout, 0)
+	checkAtLeast(&errs, "HTTP_IDLE_TIMEOUT", http.IdleTimeout, 0)
+	if uploads := max(cfg.Attachments.MaxSize, cfg.Import.MaxSize); http.MaxBodySize < uploads {
+		errs = append(errs, fmt.Errorf("HTTP_MAX_BODY_SIZE %d is smaller than the largest upload %d", http.MaxBodySize, uploads))
+	}
+	if http.MaxJSONBody > http.MaxBodySize {
+		errs = append(errs, fmt.Errorf("HTTP_MAX_JSON_BODY %d is larger than HTTP_MAX_BODY_SIZE %d", http.MaxJSONBody, http.MaxBodySize))
+	}
+	for _, proxy := range http.TrustedProxyList() {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				errs = append(errs, fmt.Errorf("HTTP_TRUSTED_PROXIES entry %q is not an IP or a CIDR range", proxy))
+			}
+		}
+	}
+
+	tls := http.TLS
+	if (tls.CertFile == "") != (tls.KeyFile == "") {
+		errs = append(errs, errors.New("HTTP_TLS_CERT_FILE and HTTP_TLS_KEY_FILE must be set together"))
+	}
+	checkOneOf(&errs, "HTTP_TLS_CLIENT_AUTH", tls.ClientAuth, ClientAuthNone, ClientAuthRequest, ClientAuthRequire)
+	if tls.ClientAuth != ClientAuthNone && tls.ClientCAFile == "" {
+		errs = append(errs, fmt.Errorf("HTTP_TLS_CLIENT_AUTH %q needs HTTP_TLS_CLIENT_CA_FILE", tls.ClientAuth))
+	}
+	if tls.ClientCAFile != "" && !http.TLSEnabled() {
+		errs = append(errs, errors.New("HTTP_TLS_CLIENT_CA_FILE needs HTTP_TLS_CERT_FILE and HTTP_TLS_KEY_FILE"))
+	}
+
+	if http.Prefork {
+		if http.TLSEnabled() {
+			errs = append(errs, errors.New("HTTP_PREFORK can not be combined with TLS, the certificate could not be reloaded"))
+		}
+		if cfg.RateLimit.Store == RateLimitStoreMemory {
+			errs = append(errs, errors.New("HTTP_PREFORK needs RATE_LIMIT_STORE postgres or off, the processes do not share memory"))
+		}
+		if cfg.Board.Enabled {
+			errs = append(errs, errors.New("HTTP_PREFORK needs BOARD_ENABLED=false, every process would show its own viewers of a task"))
+		}
+	}
+
+	checkAtLeast(&errs, "GRAPHQL_MAX_DEPTH", cfg.GraphQL.MaxDepth, 1)
+	checkAtLeast(&errs, "GRAPHQL_MAX_COMPLEXITY", cfg.GraphQL.MaxComplexity, 1)
+	checkOneOf(&errs, "RATE_LIMIT_STORE", cfg.RateLimit.Store, RateLimitStoreMemory, RateLimitStorePostgres, RateLimitStoreOff)
+	checkAtLeast(&errs, "CORS_MAX_AGE", cfg.CORS.MaxAge, 0)
+	checkAtLeast(&errs, "SECURITY_HSTS_MAX_AGE", cfg.Security.HSTSMaxAge, 0)
+	checkAtLeast(&errs, "BULK_MAX_OPERATIONS", cfg.Bulk.MaxOperations, 1)
+	checkAtLeast(&errs, "IDEMPOTENCY_TTL", cfg.Idempotency.TTL, time.Second)
+	checkAtLeast(&errs, "WEBHOOKS_MAX_ATTEMPTS", cfg.Webhooks.MaxAttempts, 1)
+	checkAtLeast(&errs, "WEBHOOKS_TIMEOUT", cfg.Webhooks.Timeout, time.Millisecond)
+	checkAtLeast(&errs, "WEBHOOKS_POLL_INTERVAL", cfg.Webhooks.PollInterval, time.Millisecond)
+	checkAtLeast(&errs, "OUTBOX_RELAY_INTERVAL", cfg.Outbox.RelayInterval, time.Millisecond)
+
+	for _, name := range strings.Split(cfg.Outbox.Publishers, ",") {
+		name = strings.TrimSpace(name)
+		checkOneOf(&errs, "OUTBOX_PUBLISHERS entry", name, PublisherLog, PublisherWebhook, PublisherNATS)
+		if name == PublisherNATS && cfg.NATS.URL == "" {
+			errs = append(errs, errors.New("the nats publisher needs NATS_URL"))
+		}
+	}
+
+	checkOneOf(&errs, "ATTACHMENTS_STORAGE", cfg.Attachments.Storage, StorageLocal, StorageS3)
+	if cfg.Attachments.Storage == StorageS3 && cfg.S3.Bucket == "" {
+		errs = append(errs, errors.New("ATTACHMENTS_STORAGE s3 needs S3_BUCKET"))
+	}
+	checkAtLeast(&errs, "ATTACHMENTS_MAX_SIZE", cfg.Attachments.MaxSize, 1)
+	checkAtLeast(&errs, "IMPORT_MAX_SIZE", cfg.Import.MaxSize, 1)
+	checkAtLeast(&errs, "IMPORT_SYNC_MAX_SIZE", cfg.Import.SyncMaxSize, 0)
+	if cfg.Import.SyncMaxSize > cfg.Import.MaxSize {
+		errs = append(errs, fmt.Errorf("IMPORT_SYNC_MAX_SIZE %d is larger than IMPORT_MAX_SIZE %d", cfg.Import.SyncMaxSize, cfg.Import.MaxSize))
+	}
+	checkAtLeast(&errs, "IMPORT_POLL_INTERVAL", cfg.Import.PollInterval, time.Millisecond)
+	checkAtLeast(&errs, "MIGRATIONS_LOCK_TIMEOUT", cfg.Migrations.LockTimeout, time.Second)
+
+	return errors.Join(errs...)
+}
+
+func checkAtLeast[T int | int32 | int64 | time.Duration](errs *[]error, name string, value, least T) {
+	if value < least {
+		*errs = append(*errs, fmt.Errorf("%s %v must be at least %v", name, value, least))
+	}
+}
+
+func checkOneOf(errs *[]error, name, value string, values ...string) {
+	if !slices.Contains(values, value) {
+		*errs = append(*errs, fmt.Errorf("%s %q is not one of %s", name, value, strings.Join(values, ", ")))
+	}
+}
+
+func checkPort(errs *[]error, name, value string) {
+	if port, err := strconv.Atoi(value); err != nil || port < 1 || port > 65535 {
+		*errs = append(*errs, fmt.Errorf("%s %q is not a port between 1 and 65535", name, value))
+	}
+}
+
+// ReplicaConfigs returns the configs of the read replicas, which differ from the primary in the host and the port.
+func (c PostgresConfig) ReplicaConfigs() ([]PostgresConfig, error) {
+	var replicas []PostgresConfig
+	for _, addr := range strings.Split(c.Replicas, ",") {
+		if addr = strings.TrimSpace(addr); addr == "" {
+			continue
+		}
+
+		replica := c
+		replica.Replicas = ""
+
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			// Without a port the replica listens on the port of the primary.
+			host, port = strings.Trim(addr, "[]"), strconv.Itoa(c.Port)
+		}
+
+		replica.Host = host
+		replica.Port, err = strconv.Atoi(port)
+		if err != nil || host == "" || replica.Port < 1 || replica.Port > 65535 {
+			return nil, fmt.Errorf("POSTGRES_REPLICAS entry %q is not a host or a host:port", addr)
+		}
+
+		replicas = append(replicas, replica)
+	}
+
+	return replicas, nil
+}
+
+// TLSEnabled tells whether the HTTP server serves HTTPS.
+func (c HTTPConfig) TLSEnabled() bool {
+	return c.TLS.CertFile != "" && c.TLS.KeyFile != ""
+}
+
+// TrustedProxyList returns the proxies whose ProxyHeader is trusted to carry the IP of the client.
+func (c HTTPConfig) TrustedProxyList() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(c.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
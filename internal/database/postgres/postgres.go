@@ -3,45 +3,225 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"net"
+	"net/url"
 	"skillsrock-test-task/internal/config"
+	"skillsrock-test-task/pkg/logger"
+	"strconv"
+	"sync/atomic"
+	"time"
 
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
 )
 
+// maxConnectBackoff caps the wait between the connection attempts at startup.
+const maxConnectBackoff = 10 * time.Second
+
+type replicaReadsKey struct{}
+
+// WithReplicaReads lets the reads made with the context go to a replica. Replicas may lag behind the primary,
+// so only reads that do not have to see the latest writes opt in.
+func WithReplicaReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaReadsKey{}, true)
+}
+
+// Database is the pool of the primary, which takes the writes and the reads, and the pools of the read replicas.
 type Database struct {
 	Pool *pgxpool.Pool
+
+	replicas       []*replica
+	next           atomic.Uint64
+	connectTimeout time.Duration
 }
 
-func NewDatabase(cfg config.PostgresConfig) (*Database, error) {
-	ctx := context.Background()
+type replica struct {
+	addr    string
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+}
 
-	options := fmt.Sprintf(
-		"postgres://%s:%s@%s:%d/%v?sslmode=%s",
-		cfg.User,
-		cfg.Password,
-		cfg.Host,
-		cfg.Port,
-		cfg.Name,
-		cfg.SSLMode,
-	)
+// NewDatabase opens the pools and waits until the primary answers, so the app does not start against a database
+// it can not reach. The attempts back off exponentially from ConnectBackoff. Replicas that do not answer are
+// left out until a health check reaches them.
+func NewDatabase(ctx context.Context, cfg config.PostgresConfig) (*Database, error) {
+	replicaConfigs, err := cfg.ReplicaConfigs()
+	if err != nil {
+		return nil, err
+	}
 
-	config, err := pgxpool.ParseConfig(options)
+	pool, err := newPool(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err := ping(ctx, pool, cfg); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	db := &Database{
+		Pool:           pool,
+		connectTimeout: cfg.ConnectTimeout,
+	}
+
+	for _, replicaConfig := range replicaConfigs {
+		replicaPool, err := newPool(ctx, replicaConfig)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+
+		r := &replica{
+			addr: net.JoinHostPort(replicaConfig.Host, strconv.Itoa(replicaConfig.Port)),
+			pool: replicaPool,
+		}
+		// A replica is healthy until a check fails, so one that is down at startup is logged.
+		r.healthy.Store(true)
+		db.replicas = append(db.replicas, r)
+	}
+
+	db.checkReplicas(ctx)
+
+	return db, nil
+}
+
+func newPool(ctx context.Context, cfg config.PostgresConfig) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(DSN(cfg))
 	if err != nil {
 		return nil, err
 	}
 
-	return &Database{
-		Pool: pool,
-	}, nil
+	poolConfig.MaxConns = cfg.MaxConns
+	poolConfig.MinConns = cfg.MinConns
+	poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	poolConfig.HealthCheckPeriod = cfg.HealthCheckPeriod
+	poolConfig.ConnConfig.ConnectTimeout = cfg.ConnectTimeout
+
+	return pgxpool.NewWithConfig(ctx, poolConfig)
+}
+
+func ping(ctx context.Context, pool *pgxpool.Pool, cfg config.PostgresConfig) error {
+	log := logger.GetLoggerFromCtx(ctx)
+	backoff := cfg.ConnectBackoff
+
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout)
+		err := pool.Ping(pingCtx)
+		cancel()
+
+		if err == nil {
+			return nil
+		}
+		if attempt == cfg.ConnectAttempts {
+			return fmt.Errorf("database is unreachable after %d attempts: %w", attempt, err)
+		}
+
+		log.Warn(ctx, "Database is unreachable, retrying",
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", backoff),
+			zap.Error(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+}
+
+// DSN is the URL of the database. The user, the password and the name are escaped, they may have any character.
+func DSN(cfg config.PostgresConfig) string {
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Path:     "/" + cfg.Name,
+		RawPath:  "/" + url.PathEscape(cfg.Name),
+		RawQuery: url.Values{"sslmode": {cfg.SSLMode}}.Encode(),
+	}
+
+	return dsn.String()
+}
+
+// Reader is the pool of the reads made with the context. Reads opted in by WithReplicaReads are spread over the
+// healthy replicas, other reads and the reads while no replica is healthy go to the primary.
+func (db *Database) Reader(ctx context.Context) *pgxpool.Pool {
+	if len(db.replicas) == 0 {
+		return db.Pool
+	}
+	if replicaReads, _ := ctx.Value(replicaReadsKey{}).(bool); !replicaReads {
+		return db.Pool
+	}
+
+	start := db.next.Add(1)
+	for i := range uint64(len(db.replicas)) {
+		r := db.replicas[(start+i)%uint64(len(db.replicas))]
+		if r.healthy.Load() {
+			return r.pool
+		}
+	}
+
+	return db.Pool
+}
+
+// RunReplicaChecks periodically pings the replicas, one that fails is left out until it answers again.
+func (db *Database) RunReplicaChecks(ctx context.Context, interval time.Duration) {
+	if len(db.replicas) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		db.checkReplicas(ctx)
+	}
+}
+
+func (db *Database) checkReplicas(ctx context.Context) {
+	log := logger.GetLoggerFromCtx(ctx)
+
+	for _, r := range db.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, db.connectTimeout)
+		err := r.pool.Ping(pingCtx)
+		cancel()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		healthy := err == nil
+		if r.healthy.Swap(healthy) == healthy {
+			continue
+		}
+
+		if healthy {
+			log.Info(ctx, "Read replica is healthy", zap.String("replica", r.addr))
+		} else {
+			log.Warn(ctx, "Read replica is unreachable, reading from the other replicas or the primary",
+				zap.String("replica", r.addr),
+				zap.Error(err),
+			)
+		}
+	}
 }
 
 func (db *Database) Close() {
+	for _, r := range db.replicas {
+		r.pool.Close()
+	}
 	db.Pool.Close()
 }
@@ -0,0 +1,173 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// complexityLimit rejects queries that would load too much data before they are executed. Every field
+// costs 1 and the selections of a field with a first argument count once per requested item, so
+// tasks(first: 10) { nodes { comments(first: 20) { id } } } costs 1 + 10 * (1 + 1 + 20 * 1) = 221.
+// graphql-go does not expose its parsed queries, so the query is parsed again with gqlparser.
+type complexityLimit struct {
+	schema *ast.Schema
+	max    int
+}
+
+func newComplexityLimit(schema string, maxComplexity int) (*complexityLimit, error) {
+	parsed, err := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: schema})
+	if err != nil {
+		return nil, err
+	}
+
+	return &complexityLimit{
+		schema: parsed,
+		max:    maxComplexity,
+	}, nil
+}
+
+// check returns an error when the operation is too complex. Invalid queries pass, graphql-go reports them.
+func (c *complexityLimit) check(query, operationName string, variables map[string]interface{}) error {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return nil
+	}
+
+	op := doc.Operations.ForName(operationName)
+	if op == nil {
+		return nil
+	}
+
+	var root *ast.Definition
+	switch op.Operation {
+	case ast.Query:
+		root = c.schema.Query
+	case ast.Mutation:
+		root = c.schema.Mutation
+	case ast.Subscription:
+		root = c.schema.Subscription
+	}
+	if root == nil {
+		return nil
+	}
+
+	w := &complexityWalker{
+		limit:     c,
+		doc:       doc,
+		variables: variables,
+		visiting:  make(map[string]bool),
+	}
+
+	if w.selectionSet(root.Name, op.SelectionSet) > c.max {
+		return fmt.Errorf("query is too complex, the limit is %d", c.max)
+	}
+
+	return nil
+}
+
+type complexityWalker struct {
+	limit     *complexityLimit
+	doc       *ast.QueryDocument
+	variables map[string]interface{}
+	visiting  map[string]bool
+}
+
+func (w *complexityWalker) selectionSet(typeName string, set ast.SelectionSet) int {
+	cost := 0
+
+	for _, selection := range set {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			cost = w.add(cost, w.field(typeName, selection))
+		case *ast.InlineFragment:
+			fragmentType := typeName
+			if selection.TypeCondition != "" {
+				fragmentType = selection.TypeCondition
+			}
+			cost = w.add(cost, w.selectionSet(fragmentType, selection.SelectionSet))
+		case *ast.FragmentSpread:
+			fragment := w.doc.Fragments.ForName(selection.Name)
+			// Fragment cycles are invalid, graphql-go rejects them.
+			if fragment == nil || w.visiting[fragment.Name] {
+				continue
+			}
+			w.visiting[fragment.Name] = true
+			cost = w.add(cost, w.selectionSet(fragment.TypeCondition, fragment.SelectionSet))
+			delete(w.visiting, fragment.Name)
+		}
+	}
+
+	return cost
+}
+
+func (w *complexityWalker) field(typeName string, field *ast.Field) int {
+	// Introspection is answered from memory.
+	if strings.HasPrefix(field.Name, "__") {
+		return 0
+	}
+
+	parent := w.limit.schema.Types[typeName]
+	if parent == nil {
+		return 1
+	}
+
+	definition := parent.Fields.ForName(field.Name)
+	if definition == nil {
+		return 1
+	}
+
+	children := w.selectionSet(definition.Type.Name(), field.SelectionSet)
+
+	if argument := definition.Arguments.ForName("first"); argument != nil {
+		children = w.multiply(children, w.first(field, argument))
+	}
+
+	return w.add(1, children)
+}
+
+// first returns the number of requested items, from the query, its variables or the default of the schema.
+func (w *complexityWalker) first(field *ast.Field, definition *ast.ArgumentDefinition) int {
+	value := definition.DefaultValue
+	if argument := field.Arguments.ForName("first"); argument != nil {
+		value = argument.Value
+	}
+
+	if value != nil && value.Kind == ast.Variable {
+		// Variables are decoded from JSON, so numbers are float64.
+		if first, ok := w.variables[value.Raw].(float64); ok {
+			return int(max(0, min(first, float64(w.limit.max+1))))
+		}
+		value = definition.DefaultValue
+	}
+
+	if value == nil || value.Kind != ast.IntValue {
+		return 1
+	}
+
+	first, err := strconv.Atoi(value.Raw)
+	if err != nil {
+		return w.limit.max + 1
+	}
+
+	return max(0, min(first, w.limit.max+1))
+}
+
+// add and multiply saturate just above the limit, nested lists could overflow otherwise.
+func (w *complexityWalker) add(a, b int) int {
+	return min(a+b, w.limit.max+1)
+}
+
+func (w *complexityWalker) multiply(a, b int) int {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	if a > (w.limit.max+1)/b {
+		return w.limit.max + 1
+	}
+	return min(a*b, w.limit.max+1)
+}
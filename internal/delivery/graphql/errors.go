@@ -0,0 +1,54 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"skillsrock-test-task/internal/models"
+
+	"go.uber.org/zap"
+)
+
+const (
+	codeNotFound     = "NOT_FOUND"
+	codeBadUserInput = "BAD_USER_INPUT"
+	codeUnavailable  = "UNAVAILABLE"
+	codeTimeout      = "TIMEOUT"
+	codeInternal     = "INTERNAL_SERVER_ERROR"
+)
+
+// queryError adds a machine readable code to the errors of a response.
+type queryError struct {
+	message string
+	code    string
+}
+
+func (e *queryError) Error() string {
+	return e.message
+}
+
+func (e *queryError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+// error maps the errors of the models package to error codes. Unknown errors are logged and
+// reported without details, like the REST API does.
+func (r *Resolver) error(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, models.ErrNotFound):
+		return &queryError{message: err.Error(), code: codeNotFound}
+	case errors.Is(err, models.ErrFailedToParseID),
+		errors.Is(err, models.ErrFailedToParseLimit),
+		errors.Is(err, models.ErrFailedToParseCursor),
+		errors.Is(err, models.ErrInvalidStatus),
+		errors.Is(err, models.ErrEmptyTitle),
+		errors.Is(err, models.ErrValidation):
+		return &queryError{message: err.Error(), code: codeBadUserInput}
+	case errors.Is(err, models.ErrStreamClosed):
+		return &queryError{message: err.Error(), code: codeUnavailable}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &queryError{message: "request timed out", code: codeTimeout}
+	default:
+		r.logger.Error(ctx, "Unknown error occurred while resolving a GraphQL field", zap.Error(err))
+		return &queryError{message: "unknown error occurred", code: codeInternal}
+	}
+}
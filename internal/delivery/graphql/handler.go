@@ -0,0 +1,143 @@
+package graphql
+
+import (
+	"context"
+	_ "embed"
+	"skillsrock-test-task/internal/database/postgres"
+	"skillsrock-test-task/internal/delivery/middleware"
+	"skillsrock-test-task/pkg/logger"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+const (
+	requestTimeout = 1 * time.Second
+
+	codeComplexityLimit = "COMPLEXITY_LIMIT_EXCEEDED"
+)
+
+//go:embed schema.graphql
+var schema string
+
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type Handler struct {
+	schema     *graphql.Schema
+	resolver   *Resolver
+	complexity *complexityLimit
+}
+
+func NewHandler(
+	tasks TaskService,
+	comments CommentService,
+	attachments AttachmentService,
+	stream TaskStreamService,
+	log logger.Logger,
+	maxDepth int,
+	maxComplexity int,
+) (*Handler, error) {
+	resolver := &Resolver{
+		tasks:       tasks,
+		comments:    comments,
+		attachments: attachments,
+		stream:      stream,
+		logger:      log,
+	}
+
+	parsed, err := graphql.ParseSchema(schema, resolver, graphql.MaxDepth(maxDepth))
+	if err != nil {
+		return nil, err
+	}
+
+	complexity, err := newComplexityLimit(schema, maxComplexity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Handler{
+		schema:     parsed,
+		resolver:   resolver,
+		complexity: complexity,
+	}, nil
+}
+
+// Query
+// @Summary      Execute a GraphQL query or mutation
+// @Description  The schema is served by introspection. Queries deeper than GRAPHQL_MAX_DEPTH or more complex than
+// @Description  GRAPHQL_MAX_COMPLEXITY are rejected, every field costs 1 and the selections of a field with a first
+// @Description  argument count once per requested item. Subscriptions are served over WebSocket on the same path.
+// @Tags         graphql
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "GraphQL request"
+// @Success      200  {object}  map[string]interface{}  "GraphQL response with data and errors"
+// @Failure      400  {object}  map[string]interface{}  "Request body is not valid JSON"
+// @Failure      429  {object}  map[string]interface{}  "Too many requests"
+// @Router       /graphql [post]
+func (h *Handler) Query(ctx *fiber.Ctx) error {
+	var req Request
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(&graphql.Response{
+			Errors: []*errors.QueryError{errors.Errorf("request body is not valid JSON")},
+		})
+	}
+
+	if err := h.complexity.check(req.Query, req.OperationName, req.Variables); err != nil {
+		return ctx.JSON(&graphql.Response{Errors: []*errors.QueryError{complexityError(err)}})
+	}
+
+	reqCtx, cancel := context.WithTimeout(operationContext(ctx, &req), requestTimeout)
+	defer cancel()
+
+	res := h.schema.Exec(h.withLoaders(reqCtx), req.Query, req.OperationName, req.Variables)
+
+	return ctx.JSON(res)
+}
+
+// operationContext lets the reads of a query go to a replica, unless the client wrote within the read-your-writes
+// window. Mutations read back the tasks they wrote, so their reads go to the primary.
+func operationContext(ctx *fiber.Ctx, req *Request) context.Context {
+	if !isQuery(req.Query, req.OperationName) {
+		return context.Background()
+	}
+
+	// Queries are sent with POST as well, they are not writes.
+	ctx.Locals(middleware.ReadOnlyKey, true)
+
+	if primary, _ := ctx.Locals(middleware.ReadPrimaryKey).(bool); primary {
+		return context.Background()
+	}
+	return postgres.WithReplicaReads(context.Background())
+}
+
+// isQuery tells whether the operation is a query. Operations that do not parse are not, graphql-go rejects them.
+func isQuery(query, operationName string) bool {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return false
+	}
+
+	op := doc.Operations.ForName(operationName)
+	return op != nil && op.Operation == ast.Query
+}
+
+// withLoaders gives an operation its own loaders.
+func (h *Handler) withLoaders(ctx context.Context) context.Context {
+	return withLoaders(ctx, newLoaders(h.resolver.comments, h.resolver.attachments))
+}
+
+func complexityError(err error) *errors.QueryError {
+	return &errors.QueryError{
+		Message:    err.Error(),
+		Extensions: map[string]interface{}{"code": codeComplexityLimit},
+	}
+}
@@ -0,0 +1,101 @@
+package graphql
+
+import (
+	"context"
+	"skillsrock-test-task/internal/models"
+	"time"
+
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+const (
+	loaderWait     = 2 * time.Millisecond
+	loaderCapacity = 100
+)
+
+type loadersKey struct{}
+
+type commentsKey struct {
+	taskID uint64
+	limit  uint64
+}
+
+// loaders batch the loading of related data of the tasks in a response, so that listing tasks with their
+// comments and attachments takes one query per relation instead of one per task. They cache what they
+// loaded, so they must not outlive a request.
+type loaders struct {
+	comments    *dataloader.Loader[commentsKey, []*models.Comment]
+	attachments *dataloader.Loader[uint64, []*models.Attachment]
+}
+
+func newLoaders(comments CommentService, attachments AttachmentService) *loaders {
+	return &loaders{
+		comments: dataloader.NewBatchedLoader(
+			loadComments(comments),
+			dataloader.WithWait[commentsKey, []*models.Comment](loaderWait),
+			dataloader.WithBatchCapacity[commentsKey, []*models.Comment](loaderCapacity),
+		),
+		attachments: dataloader.NewBatchedLoader(
+			loadAttachments(attachments),
+			dataloader.WithWait[uint64, []*models.Attachment](loaderWait),
+			dataloader.WithBatchCapacity[uint64, []*models.Attachment](loaderCapacity),
+		),
+	}
+}
+
+func withLoaders(ctx context.Context, loaders *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, loaders)
+}
+
+func loadersFromCtx(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// loadComments loads the comments of all tasks requested with the same limit in one query.
+func loadComments(comments CommentService) dataloader.BatchFunc[commentsKey, []*models.Comment] {
+	return func(ctx context.Context, keys []commentsKey) []*dataloader.Result[[]*models.Comment] {
+		taskIDs := make(map[uint64][]uint64)
+		for _, key := range keys {
+			taskIDs[key.limit] = append(taskIDs[key.limit], key.taskID)
+		}
+
+		loaded := make(map[commentsKey][]*models.Comment, len(keys))
+		failed := make(map[uint64]error)
+
+		for limit, ids := range taskIDs {
+			byTask, err := comments.GetFirstComments(ctx, ids, limit)
+			if err != nil {
+				failed[limit] = err
+				continue
+			}
+
+			for _, id := range ids {
+				loaded[commentsKey{taskID: id, limit: limit}] = byTask[id]
+			}
+		}
+
+		results := make([]*dataloader.Result[[]*models.Comment], len(keys))
+		for i, key := range keys {
+			if err, ok := failed[key.limit]; ok {
+				results[i] = &dataloader.Result[[]*models.Comment]{Error: err}
+				continue
+			}
+			results[i] = &dataloader.Result[[]*models.Comment]{Data: loaded[key]}
+		}
+
+		return results
+	}
+}
+
+func loadAttachments(attachments AttachmentService) dataloader.BatchFunc[uint64, []*models.Attachment] {
+	return func(ctx context.Context, taskIDs []uint64) []*dataloader.Result[[]*models.Attachment] {
+		results := make([]*dataloader.Result[[]*models.Attachment], len(taskIDs))
+
+		byTask, err := attachments.GetAttachmentsByTaskIDs(ctx, taskIDs)
+		for i, id := range taskIDs {
+			results[i] = &dataloader.Result[[]*models.Attachment]{Data: byTask[id], Error: err}
+		}
+
+		return results
+	}
+}
@@ -0,0 +1,267 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"skillsrock-test-task/internal/dto"
+	"skillsrock-test-task/internal/models"
+	"skillsrock-test-task/pkg/logger"
+	"strconv"
+
+	"github.com/graph-gophers/graphql-go"
+	"go.uber.org/zap"
+)
+
+const eventReset = "reset"
+
+type TaskService interface {
+	CreateTask(ctx context.Context, task *dto.CreateTaskRequest) (*dto.CreateTaskResponse, error)
+	GetTaskByID(ctx context.Context, id string) (*dto.GetTaskByIDResponse, error)
+	GetTasksPage(ctx context.Context, cursor string, limit uint64, filter *models.TaskFilter) (*dto.GetTasksPageResponse, error)
+	DeleteTask(ctx context.Context, id string) error
+	UpdateTask(ctx context.Context, id string, task *dto.UpdateTaskRequest) error
+	MoveTask(ctx context.Context, id, status string) error
+}
+
+type CommentService interface {
+	GetFirstComments(ctx context.Context, taskIDs []uint64, limit uint64) (map[uint64][]*models.Comment, error)
+}
+
+type AttachmentService interface {
+	GetAttachmentsByTaskIDs(ctx context.Context, taskIDs []uint64) (map[uint64][]*models.Attachment, error)
+}
+
+type TaskStreamService interface {
+	Subscribe(ctx context.Context, req *dto.StreamTasksRequest) (*dto.TaskStream, error)
+}
+
+// Resolver is the root resolver of the schema, its methods are the fields of Query, Mutation and Subscription.
+type Resolver struct {
+	tasks       TaskService
+	comments    CommentService
+	attachments AttachmentService
+	stream      TaskStreamService
+	logger      logger.Logger
+}
+
+type taskFilterInput struct {
+	Status *string
+	Owner  *string
+}
+
+func (f *taskFilterInput) filter() *models.TaskFilter {
+	filter := &models.TaskFilter{}
+	if f == nil {
+		return filter
+	}
+	if f.Status != nil {
+		filter.Status = *f.Status
+	}
+	if f.Owner != nil {
+		filter.Owner = *f.Owner
+	}
+	return filter
+}
+
+func (r *Resolver) Task(ctx context.Context, args struct{ ID graphql.ID }) (*taskResolver, error) {
+	res, err := r.tasks.GetTaskByID(ctx, string(args.ID))
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.error(ctx, err)
+	}
+
+	return newTaskResolver(res.Task, r, loadersFromCtx(ctx)), nil
+}
+
+func (r *Resolver) Tasks(ctx context.Context, args struct {
+	Filter *taskFilterInput
+	First  int32
+	After  *string
+}) (*taskConnectionResolver, error) {
+	if args.First < 0 {
+		return nil, r.error(ctx, models.ErrFailedToParseLimit)
+	}
+
+	var cursor string
+	if args.After != nil {
+		cursor = *args.After
+	}
+
+	// The service applies the default page size to 0, which is not what first: 0 asks for.
+	if args.First == 0 {
+		return &taskConnectionResolver{}, nil
+	}
+
+	res, err := r.tasks.GetTasksPage(ctx, cursor, uint64(args.First), args.Filter.filter())
+	if err != nil {
+		return nil, r.error(ctx, err)
+	}
+
+	loaders := loadersFromCtx(ctx)
+
+	connection := &taskConnectionResolver{
+		nodes:      make([]*taskResolver, 0, len(res.Tasks)),
+		nextCursor: res.NextCursor,
+	}
+	for _, task := range res.Tasks {
+		connection.nodes = append(connection.nodes, newTaskResolver(task, r, loaders))
+	}
+
+	return connection, nil
+}
+
+func (r *Resolver) CreateTask(ctx context.Context, args struct {
+	Input struct {
+		Title       string
+		Description string
+		Owner       string
+		DueAt       *graphql.Time
+	}
+}) (*taskResolver, error) {
+	task := &dto.CreateTaskRequest{
+		Title:       args.Input.Title,
+		Description: args.Input.Description,
+		Owner:       args.Input.Owner,
+		DueAt:       fromGraphQLTime(args.Input.DueAt),
+	}
+	if err := dto.Validate(task); err != nil {
+		return nil, r.error(ctx, err)
+	}
+
+	res, err := r.tasks.CreateTask(ctx, task)
+	if err != nil {
+		return nil, r.error(ctx, err)
+	}
+
+	return r.loadTask(ctx, strconv.FormatUint(res.ID, 10))
+}
+
+func (r *Resolver) UpdateTask(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input struct {
+		Title       string
+		Description string
+		Status      string
+		DueAt       *graphql.Time
+	}
+}) (*taskResolver, error) {
+	task := &dto.UpdateTaskRequest{
+		Title:       args.Input.Title,
+		Description: args.Input.Description,
+		Status:      args.Input.Status,
+		DueAt:       fromGraphQLTime(args.Input.DueAt),
+	}
+	if err := dto.Validate(task); err != nil {
+		return nil, r.error(ctx, err)
+	}
+
+	if err := r.tasks.UpdateTask(ctx, string(args.ID), task); err != nil {
+		return nil, r.error(ctx, err)
+	}
+
+	return r.loadTask(ctx, string(args.ID))
+}
+
+func (r *Resolver) MoveTask(ctx context.Context, args struct {
+	ID     graphql.ID
+	Status string
+}) (*taskResolver, error) {
+	if err := r.tasks.MoveTask(ctx, string(args.ID), args.Status); err != nil {
+		return nil, r.error(ctx, err)
+	}
+
+	return r.loadTask(ctx, string(args.ID))
+}
+
+func (r *Resolver) DeleteTask(ctx context.Context, args struct{ ID graphql.ID }) (graphql.ID, error) {
+	if err := r.tasks.DeleteTask(ctx, string(args.ID)); err != nil {
+		return "", r.error(ctx, err)
+	}
+
+	return args.ID, nil
+}
+
+// loadTask returns the task as stored after a mutation, the services only report what they changed.
+func (r *Resolver) loadTask(ctx context.Context, id string) (*taskResolver, error) {
+	res, err := r.tasks.GetTaskByID(ctx, id)
+	if err != nil {
+		return nil, r.error(ctx, err)
+	}
+
+	return newTaskResolver(res.Task, r, loadersFromCtx(ctx)), nil
+}
+
+func (r *Resolver) TaskEvents(ctx context.Context, args struct {
+	Filter      *taskFilterInput
+	LastEventID *graphql.ID
+}) (<-chan *taskEventResolver, error) {
+	filter := args.Filter.filter()
+
+	var lastEventID string
+	if args.LastEventID != nil {
+		lastEventID = string(*args.LastEventID)
+	}
+
+	stream, err := r.stream.Subscribe(ctx, &dto.StreamTasksRequest{
+		Status:      filter.Status,
+		Owner:       filter.Owner,
+		LastEventID: lastEventID,
+	})
+	if err != nil {
+		return nil, r.error(ctx, err)
+	}
+
+	events := make(chan *taskEventResolver)
+
+	go func() {
+		defer close(events)
+
+		send := func(event *taskEventResolver) bool {
+			select {
+			case events <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		if stream.Reset && !send(&taskEventResolver{event: &models.TaskEvent{Type: eventReset}}) {
+			return
+		}
+
+		for _, outbox := range stream.Replay {
+			if event := r.newTaskEventResolver(ctx, outbox); event != nil && !send(event) {
+				return
+			}
+		}
+
+		// The events are closed when the subscription is cancelled, falls behind or the server shuts down.
+		for outbox := range stream.Events {
+			if event := r.newTaskEventResolver(ctx, outbox); event != nil && !send(event) {
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// newTaskEventResolver gives every event its own loaders, a cache shared by the whole subscription
+// would return comments as they were when the first event was resolved.
+func (r *Resolver) newTaskEventResolver(ctx context.Context, outbox *models.OutboxEvent) *taskEventResolver {
+	var event models.TaskEvent
+	if err := json.Unmarshal(outbox.Payload, &event); err != nil {
+		r.logger.Error(ctx, "Failed to decode the task event", zap.Uint64("id", outbox.ID), zap.Error(err))
+		return nil
+	}
+
+	return &taskEventResolver{
+		id:      outbox.ID,
+		event:   &event,
+		root:    r,
+		loaders: newLoaders(r.comments, r.attachments),
+	}
+}
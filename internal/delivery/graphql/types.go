@@ -0,0 +1,260 @@
+package graphql
+
+import (
+	"context"
+	"skillsrock-test-task/internal/models"
+	"strconv"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+func toID(id uint64) graphql.ID {
+	return graphql.ID(strconv.FormatUint(id, 10))
+}
+
+func fromGraphQLTime(t *graphql.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	return &t.Time
+}
+
+type taskResolver struct {
+	task    *models.Task
+	root    *Resolver
+	loaders *loaders
+}
+
+func newTaskResolver(task *models.Task, root *Resolver, loaders *loaders) *taskResolver {
+	return &taskResolver{
+		task:    task,
+		root:    root,
+		loaders: loaders,
+	}
+}
+
+func (r *taskResolver) ID() graphql.ID {
+	return toID(r.task.ID)
+}
+
+func (r *taskResolver) Title() string {
+	return r.task.Title
+}
+
+func (r *taskResolver) Description() string {
+	return r.task.Description
+}
+
+func (r *taskResolver) Status() string {
+	return r.task.Status
+}
+
+func (r *taskResolver) Owner() string {
+	return r.task.Owner
+}
+
+func (r *taskResolver) DueAt() *graphql.Time {
+	if r.task.DueAt == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *r.task.DueAt}
+}
+
+func (r *taskResolver) CommentsCount() int32 {
+	return int32(r.task.CommentsCount)
+}
+
+func (r *taskResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.task.CreatedAt}
+}
+
+func (r *taskResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: r.task.UpdatedAt}
+}
+
+func (r *taskResolver) Comments(ctx context.Context, args struct{ First int32 }) ([]*commentResolver, error) {
+	if args.First < 0 {
+		return nil, r.root.error(ctx, models.ErrFailedToParseLimit)
+	}
+	if args.First == 0 {
+		return []*commentResolver{}, nil
+	}
+
+	comments, err := r.loaders.comments.Load(ctx, commentsKey{taskID: r.task.ID, limit: uint64(args.First)})()
+	if err != nil {
+		return nil, r.root.error(ctx, err)
+	}
+
+	resolvers := make([]*commentResolver, 0, len(comments))
+	for _, comment := range comments {
+		resolvers = append(resolvers, &commentResolver{comment: comment})
+	}
+
+	return resolvers, nil
+}
+
+func (r *taskResolver) Attachments(ctx context.Context) ([]*attachmentResolver, error) {
+	attachments, err := r.loaders.attachments.Load(ctx, r.task.ID)()
+	if err != nil {
+		return nil, r.root.error(ctx, err)
+	}
+
+	resolvers := make([]*attachmentResolver, 0, len(attachments))
+	for _, attachment := range attachments {
+		resolvers = append(resolvers, &attachmentResolver{attachment: attachment})
+	}
+
+	return resolvers, nil
+}
+
+type taskConnectionResolver struct {
+	nodes      []*taskResolver
+	nextCursor string
+}
+
+func (r *taskConnectionResolver) Nodes() []*taskResolver {
+	if r.nodes == nil {
+		return []*taskResolver{}
+	}
+	return r.nodes
+}
+
+func (r *taskConnectionResolver) PageInfo() *pageInfoResolver {
+	return &pageInfoResolver{nextCursor: r.nextCursor}
+}
+
+type pageInfoResolver struct {
+	nextCursor string
+}
+
+func (r *pageInfoResolver) HasNextPage() bool {
+	return r.nextCursor != ""
+}
+
+func (r *pageInfoResolver) EndCursor() *string {
+	if r.nextCursor == "" {
+		return nil
+	}
+	return &r.nextCursor
+}
+
+type commentResolver struct {
+	comment *models.Comment
+}
+
+func (r *commentResolver) ID() graphql.ID {
+	return toID(r.comment.ID)
+}
+
+func (r *commentResolver) ReplyTo() *graphql.ID {
+	if r.comment.ReplyTo == nil {
+		return nil
+	}
+	id := toID(*r.comment.ReplyTo)
+	return &id
+}
+
+func (r *commentResolver) Author() string {
+	return r.comment.Author
+}
+
+func (r *commentResolver) Body() string {
+	return r.comment.Body
+}
+
+func (r *commentResolver) Edited() bool {
+	return r.comment.Edited
+}
+
+func (r *commentResolver) Deleted() bool {
+	return r.comment.Deleted
+}
+
+func (r *commentResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.comment.CreatedAt}
+}
+
+func (r *commentResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: r.comment.UpdatedAt}
+}
+
+type attachmentResolver struct {
+	attachment *models.Attachment
+}
+
+func (r *attachmentResolver) ID() graphql.ID {
+	return toID(r.attachment.ID)
+}
+
+func (r *attachmentResolver) Filename() string {
+	return r.attachment.Filename
+}
+
+func (r *attachmentResolver) ContentType() string {
+	return r.attachment.ContentType
+}
+
+func (r *attachmentResolver) Size() int32 {
+	return int32(r.attachment.Size)
+}
+
+func (r *attachmentResolver) Checksum() string {
+	return r.attachment.Checksum
+}
+
+func (r *attachmentResolver) Uploader() string {
+	return r.attachment.Uploader
+}
+
+func (r *attachmentResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.attachment.CreatedAt}
+}
+
+type taskEventResolver struct {
+	id      uint64
+	event   *models.TaskEvent
+	root    *Resolver
+	loaders *loaders
+}
+
+func (r *taskEventResolver) ID() *graphql.ID {
+	if r.event.Type == eventReset {
+		return nil
+	}
+	id := toID(r.id)
+	return &id
+}
+
+func (r *taskEventResolver) Type() string {
+	return r.event.Type
+}
+
+func (r *taskEventResolver) TaskID() *graphql.ID {
+	if r.event.Type == eventReset {
+		return nil
+	}
+	id := toID(r.event.TaskID)
+	return &id
+}
+
+func (r *taskEventResolver) PreviousStatus() *string {
+	if r.event.PreviousStatus == "" {
+		return nil
+	}
+	return &r.event.PreviousStatus
+}
+
+func (r *taskEventResolver) OccurredAt() *graphql.Time {
+	if r.event.Type == eventReset {
+		return nil
+	}
+	return &graphql.Time{Time: r.event.OccurredAt}
+}
+
+func (r *taskEventResolver) Task() *taskResolver {
+	if r.event.Task == nil {
+		return nil
+	}
+	return newTaskResolver(r.event.Task, r.root, r.loaders)
+}
@@ -0,0 +1,329 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+	"go.uber.org/zap"
+)
+
+// The graphql-transport-ws protocol, https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md.
+const (
+	wsProtocol = "graphql-transport-ws"
+
+	wsConnectionInit = "connection_init"
+	wsConnectionAck  = "connection_ack"
+	wsPing           = "ping"
+	wsPong           = "pong"
+	wsSubscribe      = "subscribe"
+	wsNext           = "next"
+	wsError          = "error"
+	wsComplete       = "complete"
+
+	wsCloseBadRequest       = 4400
+	wsCloseUnauthorized     = 4401
+	wsCloseInitTimeout      = 4408
+	wsCloseSubscriberExists = 4409
+	wsCloseTooManyInits     = 4429
+
+	wsInitTimeout    = 10 * time.Second
+	wsMaxMessageSize = 64 << 10
+	wsOutboxSize     = 256
+	wsMaxOperations  = 20
+	wsWriteTimeout   = 10 * time.Second
+	wsPongTimeout    = 60 * time.Second
+	wsPingInterval   = wsPongTimeout * 9 / 10
+)
+
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// UpgradeSubscriptions lets only WebSocket handshakes through to Subscriptions.
+func (h *Handler) UpgradeSubscriptions(ctx *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(ctx) {
+		return fiber.NewError(fiber.StatusUpgradeRequired, "WebSocket upgrade required")
+	}
+	return ctx.Next()
+}
+
+// Subscriptions
+// @Summary      GraphQL subscriptions over WebSocket
+// @Description  Speaks the graphql-transport-ws protocol, queries and mutations can be sent over it as well.
+// @Description  The handshake needs a token from AUTH_TOKENS, as Authorization: Bearer <token> or ?access_token=<token>.
+// @Tags         graphql
+// @Param        access_token  query  string  false  "Access token, when the client can not set the Authorization header"
+// @Success      101  {string}  string  "Switching protocols"
+// @Failure      401  {object}  map[string]interface{}  "Missing or invalid access token"
+// @Failure      426  {object}  map[string]interface{}  "WebSocket upgrade required"
+// @Failure      429  {object}  map[string]interface{}  "Too many requests"
+// @Router       /graphql [get]
+func (h *Handler) Subscriptions() fiber.Handler {
+	return websocket.New(func(conn *websocket.Conn) {
+		newWSConn(h, conn).serve()
+	}, websocket.Config{
+		Subprotocols: []string{wsProtocol},
+	})
+}
+
+type wsConn struct {
+	h    *Handler
+	conn *websocket.Conn
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	out       chan *wsMessage
+	done      chan struct{}
+	closeOnce sync.Once
+	closeCode int
+	closeText string
+
+	initialized bool
+
+	mu         sync.Mutex
+	operations map[string]context.CancelFunc
+}
+
+func newWSConn(h *Handler, conn *websocket.Conn) *wsConn {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &wsConn{
+		h:          h,
+		conn:       conn,
+		ctx:        ctx,
+		cancel:     cancel,
+		out:        make(chan *wsMessage, wsOutboxSize),
+		done:       make(chan struct{}),
+		operations: make(map[string]context.CancelFunc),
+	}
+}
+
+// serve reads client messages until the connection is closed by either side. All writes happen
+// in writeLoop, the connection must not be used after serve returns.
+func (c *wsConn) serve() {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.writeLoop()
+	}()
+
+	initTimeout := time.AfterFunc(wsInitTimeout, func() {
+		c.close(wsCloseInitTimeout, "Connection initialisation timeout")
+	})
+
+	c.readLoop(initTimeout)
+
+	initTimeout.Stop()
+	c.close(websocket.CloseNormalClosure, "")
+	c.cancel()
+	wg.Wait()
+}
+
+func (c *wsConn) readLoop(initTimeout *time.Timer) {
+	c.conn.SetReadLimit(wsMaxMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		_ = c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+
+		var msg wsMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.close(wsCloseBadRequest, "Invalid message received")
+			return
+		}
+
+		switch msg.Type {
+		case wsConnectionInit:
+			if c.initialized {
+				c.close(wsCloseTooManyInits, "Too many initialisation requests")
+				return
+			}
+			initTimeout.Stop()
+			c.initialized = true
+			c.send(&wsMessage{Type: wsConnectionAck})
+		case wsPing:
+			c.send(&wsMessage{Type: wsPong})
+		case wsPong:
+		case wsSubscribe:
+			if !c.initialized {
+				c.close(wsCloseUnauthorized, "Unauthorized")
+				return
+			}
+			if !c.subscribe(&msg) {
+				return
+			}
+		case wsComplete:
+			c.mu.Lock()
+			if cancel, ok := c.operations[msg.ID]; ok {
+				cancel()
+				delete(c.operations, msg.ID)
+			}
+			c.mu.Unlock()
+		default:
+			c.close(wsCloseBadRequest, "Invalid message received")
+			return
+		}
+	}
+}
+
+func (c *wsConn) writeLoop() {
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case msg := <-c.out:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := c.conn.WriteJSON(msg); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+			}
+		case <-ping.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+			}
+		case <-c.done:
+			if c.closeCode != websocket.CloseAbnormalClosure {
+				_ = c.conn.WriteControl(
+					websocket.CloseMessage,
+					websocket.FormatCloseMessage(c.closeCode, c.closeText),
+					time.Now().Add(wsWriteTimeout),
+				)
+			}
+			// Closing the connection unblocks the read loop.
+			_ = c.conn.Close()
+			return
+		}
+	}
+}
+
+// close stops the connection with the code sent to the client, only the first call has an effect.
+func (c *wsConn) close(code int, text string) {
+	c.closeOnce.Do(func() {
+		c.closeCode = code
+		c.closeText = text
+		close(c.done)
+	})
+}
+
+// send queues the message without blocking, a client that does not read its messages is disconnected.
+func (c *wsConn) send(msg *wsMessage) {
+	select {
+	case c.out <- msg:
+	default:
+		c.close(websocket.ClosePolicyViolation, "client is too slow")
+	}
+}
+
+func (c *wsConn) sendPayload(id, typ string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		c.h.resolver.logger.Error(c.ctx, "Failed to encode a GraphQL response", zap.Error(err))
+		return
+	}
+
+	c.send(&wsMessage{ID: id, Type: typ, Payload: data})
+}
+
+// subscribe starts an operation, it returns false when the connection has been closed.
+func (c *wsConn) subscribe(msg *wsMessage) bool {
+	var req Request
+	if msg.ID == "" || json.Unmarshal(msg.Payload, &req) != nil {
+		c.close(wsCloseBadRequest, "Invalid message received")
+		return false
+	}
+
+	c.mu.Lock()
+	if _, ok := c.operations[msg.ID]; ok {
+		c.mu.Unlock()
+		c.close(wsCloseSubscriberExists, "Subscriber for "+msg.ID+" already exists")
+		return false
+	}
+	if len(c.operations) >= wsMaxOperations {
+		c.mu.Unlock()
+		c.sendPayload(msg.ID, wsError, []*errors.QueryError{errors.Errorf("too many operations")})
+		return true
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.operations[msg.ID] = cancel
+	c.mu.Unlock()
+
+	if err := c.h.complexity.check(req.Query, req.OperationName, req.Variables); err != nil {
+		if c.finish(ctx, msg.ID) {
+			c.sendPayload(msg.ID, wsError, []*errors.QueryError{complexityError(err)})
+		}
+		return true
+	}
+
+	// Queries and mutations are executed right away, their single result is forwarded like an event.
+	responses, err := c.h.schema.Subscribe(c.h.withLoaders(ctx), req.Query, req.OperationName, req.Variables)
+	if err != nil {
+		if c.finish(ctx, msg.ID) {
+			c.sendPayload(msg.ID, wsError, []*errors.QueryError{errors.Errorf("%s", err)})
+		}
+		return true
+	}
+
+	go c.forward(ctx, msg.ID, responses)
+
+	return true
+}
+
+// forward sends the results of an operation. An operation that fails before it produced data gets
+// an error message, any other operation is completed unless the client completed it.
+func (c *wsConn) forward(ctx context.Context, id string, responses <-chan interface{}) {
+	first := true
+	for response := range responses {
+		res, ok := response.(*graphql.Response)
+		if !ok {
+			continue
+		}
+
+		if first && res.Data == nil && len(res.Errors) > 0 {
+			if c.finish(ctx, id) {
+				c.sendPayload(id, wsError, res.Errors)
+			}
+			return
+		}
+		first = false
+
+		c.sendPayload(id, wsNext, res)
+	}
+
+	if c.finish(ctx, id) {
+		c.send(&wsMessage{ID: id, Type: wsComplete})
+	}
+}
+
+// finish removes an operation that ended on the server side before the client is told about it, so that
+// the client can reuse its ID right away. It returns false when the client completed the operation.
+func (c *wsConn) finish(ctx context.Context, id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ctx.Err() != nil {
+		return false
+	}
+
+	c.operations[id]()
+	delete(c.operations, id)
+
+	return true
+}
@@ -0,0 +1,264 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"skillsrock-test-task/internal/models"
+	tasksv1 "skillsrock-test-task/pkg/api/tasks/v1"
+	"skillsrock-test-task/pkg/logger"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	metadataRequestID     = "x-request-id"
+	metadataAuthorization = "authorization"
+	// metadataLastWrite carries the time of the last write of a client in Unix milliseconds, like the X-Last-Write
+	// header of the REST API.
+	metadataLastWrite = "x-last-write"
+)
+
+type (
+	userKey        struct{}
+	readPrimaryKey struct{}
+)
+
+// writeMethods are the calls that write, their responses carry the time of the write.
+var writeMethods = map[string]bool{
+	tasksv1.TaskService_CreateTask_FullMethodName: true,
+	tasksv1.TaskService_UpdateTask_FullMethodName: true,
+	tasksv1.TaskService_DeleteTask_FullMethodName: true,
+}
+
+// serverStream replaces the context of a stream, interceptors can not change it otherwise.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func requestIDUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(withRequestID(ctx), req)
+	}
+}
+
+func requestIDStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{ServerStream: ss, ctx: withRequestID(ss.Context())})
+	}
+}
+
+// withRequestID takes the request ID sent by the client or generates one, and returns it in the response headers.
+func withRequestID(ctx context.Context) context.Context {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(metadataRequestID); len(values) > 0 {
+			requestID = values[0]
+		}
+	}
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(metadataRequestID, requestID))
+
+	return context.WithValue(ctx, logger.RequestIDKey{}, requestID)
+}
+
+func loggingUnaryInterceptor(log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		res, err := handler(ctx, req)
+		logCall(ctx, log, info.FullMethod, start, err)
+		return res, err
+	}
+}
+
+func loggingStreamInterceptor(log logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(ss.Context(), log, info.FullMethod, start, err)
+		return err
+	}
+}
+
+// logCall logs the outcome of a call, the cause of internal errors is logged by the errors interceptor.
+func logCall(ctx context.Context, log logger.Logger, method string, start time.Time, err error) {
+	log.Info(ctx, "gRPC call",
+		zap.String("method", method),
+		zap.String("code", status.Code(err).String()),
+		zap.Duration("duration", time.Since(start)),
+	)
+}
+
+func errorsUnaryInterceptor(log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		res, err := handler(ctx, req)
+		return res, toStatusError(ctx, log, err)
+	}
+}
+
+func errorsStreamInterceptor(log logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return toStatusError(ss.Context(), log, handler(srv, ss))
+	}
+}
+
+// kindCodes are the gRPC codes of the kinds of errors, like the statuses of the REST API.
+var kindCodes = map[models.ErrorKind]codes.Code{
+	models.KindInvalid:             codes.InvalidArgument,
+	models.KindNotFound:            codes.NotFound,
+	models.KindConflict:            codes.Aborted,
+	models.KindUnprocessable:       codes.FailedPrecondition,
+	models.KindTooLarge:            codes.ResourceExhausted,
+	models.KindUnsupported:         codes.InvalidArgument,
+	models.KindRangeNotSatisfiable: codes.OutOfRange,
+	models.KindUnauthorized:        codes.Unauthenticated,
+	models.KindUnavailable:         codes.Unavailable,
+	models.KindTooManyRequests:     codes.ResourceExhausted,
+}
+
+// toStatusError maps the errors of the models package to gRPC codes by their kind, the invalid fields of a
+// validation error are sent as BadRequest details. Unknown errors are logged and reported without details,
+// like the REST API does.
+func toStatusError(ctx context.Context, log logger.Logger, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var (
+		validationErr *models.ValidationError
+		modelErr      *models.Error
+	)
+
+	switch {
+	case errors.As(err, &validationErr):
+		return validationStatus(validationErr, err.Error()).Err()
+	case errors.As(err, &modelErr):
+		if code, ok := kindCodes[modelErr.Kind]; ok {
+			return status.Error(code, err.Error())
+		}
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	}
+
+	log.Error(ctx, "Unknown error occurred while handling the gRPC call", zap.Error(err))
+	return status.Error(codes.Internal, "unknown error occurred")
+}
+
+func validationStatus(validationErr *models.ValidationError, message string) *status.Status {
+	st := status.New(codes.InvalidArgument, message)
+
+	details := &errdetails.BadRequest{}
+	for _, field := range validationErr.Fields {
+		details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       field.Field,
+			Description: field.Err.Error(),
+		})
+	}
+
+	if withDetails, err := st.WithDetails(details); err == nil {
+		return withDetails
+	}
+	return st
+}
+
+func authUnaryInterceptor(auth Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, auth)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func authStreamInterceptor(auth Authenticator) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), auth)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticate expects the same bearer tokens as the REST API in the authorization metadata.
+func authenticate(ctx context.Context, auth Authenticator) (context.Context, error) {
+	var token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(metadataAuthorization); len(values) > 0 {
+			token, _ = strings.CutPrefix(values[0], "Bearer ")
+		}
+	}
+
+	user, err := auth.Authenticate(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return context.WithValue(ctx, userKey{}, user), nil
+}
+
+// readYourWritesUnaryInterceptor sends the reads of a client that wrote within the window to the primary, until the
+// replicas have caught up with the write. Clients echo the x-last-write header of the response of a write in the
+// metadata of their calls. A window of 0 turns it off.
+func readYourWritesUnaryInterceptor(window time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if window <= 0 {
+			return handler(ctx, req)
+		}
+
+		now := time.Now()
+		if lastWrite, ok := clientLastWrite(ctx); ok && now.Sub(lastWrite) < window {
+			ctx = context.WithValue(ctx, readPrimaryKey{}, true)
+		}
+
+		res, err := handler(ctx, req)
+		if err != nil || !writeMethods[info.FullMethod] {
+			return res, err
+		}
+
+		_ = grpc.SetHeader(ctx, metadata.Pairs(metadataLastWrite, strconv.FormatInt(now.UnixMilli(), 10)))
+
+		return res, nil
+	}
+}
+
+func clientLastWrite(ctx context.Context) (time.Time, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	values := md.Get(metadataLastWrite)
+	if len(values) == 0 {
+		return time.Time{}, false
+	}
+
+	millis, err := strconv.ParseInt(values[0], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.UnixMilli(millis), true
+}
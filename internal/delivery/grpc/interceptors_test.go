@@ -0,0 +1,91 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"skillsrock-test-task/internal/models"
+	"skillsrock-test-task/pkg/logger"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestToStatusError(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewNop()
+
+	tests := []struct {
+		name string
+		err  error
+		want codes.Code
+	}{
+		{name: "invalid", err: models.ErrFailedToParseCursor, want: codes.InvalidArgument},
+		{name: "not found", err: models.ErrUnknownImportSource, want: codes.NotFound},
+		{name: "conflict", err: models.ErrIdempotencyKeyInFlight, want: codes.Aborted},
+		{name: "unprocessable", err: models.ErrBulkRolledBack, want: codes.FailedPrecondition},
+		{name: "too large", err: models.ErrFileTooLarge, want: codes.ResourceExhausted},
+		{name: "unsupported", err: models.ErrUnsupportedFileType, want: codes.InvalidArgument},
+		{name: "range not satisfiable", err: models.ErrInvalidRange, want: codes.OutOfRange},
+		{name: "unauthorized", err: models.ErrUnauthorized, want: codes.Unauthenticated},
+		{name: "unavailable", err: models.ErrStreamClosed, want: codes.Unavailable},
+		{name: "too many requests", err: models.ErrRateLimited, want: codes.ResourceExhausted},
+		{name: "wrapped", err: fmt.Errorf("get task: %w", models.ErrNotFound), want: codes.NotFound},
+		{name: "message", err: models.ErrInvalidDueRange.WithMessage("from must be before to"), want: codes.InvalidArgument},
+		{name: "deadline", err: context.DeadlineExceeded, want: codes.DeadlineExceeded},
+		{name: "canceled", err: context.Canceled, want: codes.Canceled},
+		{name: "status", err: status.Error(codes.PermissionDenied, "denied"), want: codes.PermissionDenied},
+		{name: "unknown", err: errors.New("connection reset"), want: codes.Internal},
+		{name: "unknown kind", err: &models.Error{Kind: -1, Code: "odd", Message: "odd"}, want: codes.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(toStatusError(ctx, log, tt.err))
+			if st.Code() != tt.want {
+				t.Fatalf("code = %s, want %s", st.Code(), tt.want)
+			}
+			if tt.want == codes.Internal && st.Message() != "unknown error occurred" {
+				t.Fatalf("message = %q, want the cause left out", st.Message())
+			}
+		})
+	}
+
+	if err := toStatusError(ctx, log, nil); err != nil {
+		t.Fatalf("toStatusError(nil) = %v", err)
+	}
+}
+
+func TestToStatusErrorCoversEveryKind(t *testing.T) {
+	for kind := models.KindInvalid; kind <= models.KindTooManyRequests; kind++ {
+		if _, ok := kindCodes[kind]; !ok {
+			t.Errorf("kind %d has no gRPC code", kind)
+		}
+	}
+}
+
+func TestToStatusErrorValidation(t *testing.T) {
+	err := &models.ValidationError{Fields: []models.FieldError{
+		{Field: "title", Err: models.ErrRequired},
+		{Field: "owner", Err: models.ErrTooLong},
+	}}
+
+	st := status.Convert(toStatusError(context.Background(), logger.NewNop(), err))
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("code = %s, want %s", st.Code(), codes.InvalidArgument)
+	}
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			violations = append(violations, badRequest.FieldViolations...)
+		}
+	}
+	if len(violations) != 2 || violations[0].Field != "title" || violations[1].Field != "owner" {
+		t.Fatalf("field violations = %v, want title and owner", violations)
+	}
+	if violations[0].Description != models.ErrRequired.Error() {
+		t.Fatalf("description = %q, want %q", violations[0].Description, models.ErrRequired.Error())
+	}
+}
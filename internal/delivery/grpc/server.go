@@ -0,0 +1,272 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"skillsrock-test-task/internal/database/postgres"
+	"skillsrock-test-task/internal/dto"
+	"skillsrock-test-task/internal/models"
+	tasksv1 "skillsrock-test-task/pkg/api/tasks/v1"
+	"skillsrock-test-task/pkg/logger"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	requestTimeout = 1 * time.Second
+	eventReset     = "reset"
+)
+
+type TaskService interface {
+	CreateTask(ctx context.Context, task *dto.CreateTaskRequest) (*dto.CreateTaskResponse, error)
+	GetTaskByID(ctx context.Context, id string) (*dto.GetTaskByIDResponse, error)
+	GetTasks(ctx context.Context, page, limit string, filter *models.TaskFilter) (*dto.GetTasksResponse, error)
+	DeleteTask(ctx context.Context, id string) error
+	UpdateTask(ctx context.Context, id string, task *dto.UpdateTaskRequest) error
+}
+
+type TaskStreamService interface {
+	Subscribe(ctx context.Context, req *dto.StreamTasksRequest) (*dto.TaskStream, error)
+}
+
+type Authenticator interface {
+	Authenticate(token string) (string, error)
+}
+
+type Server struct {
+	tasksv1.UnimplementedTaskServiceServer
+
+	service TaskService
+	stream  TaskStreamService
+}
+
+// NewServer returns a gRPC server exposing the task service, every call is authenticated,
+// gets a request ID and is logged. Reads go to the replicas, except for clients that wrote within readYourWrites.
+func NewServer(service TaskService, stream TaskStreamService, auth Authenticator, log logger.Logger, readYourWrites time.Duration) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			requestIDUnaryInterceptor(),
+			loggingUnaryInterceptor(log),
+			errorsUnaryInterceptor(log),
+			authUnaryInterceptor(auth),
+			readYourWritesUnaryInterceptor(readYourWrites),
+		),
+		grpc.ChainStreamInterceptor(
+			requestIDStreamInterceptor(),
+			loggingStreamInterceptor(log),
+			errorsStreamInterceptor(log),
+			authStreamInterceptor(auth),
+		),
+	)
+
+	tasksv1.RegisterTaskServiceServer(server, &Server{
+		service: service,
+		stream:  stream,
+	})
+
+	return server
+}
+
+func (s *Server) CreateTask(ctx context.Context, req *tasksv1.CreateTaskRequest) (*tasksv1.CreateTaskResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	task := &dto.CreateTaskRequest{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		Owner:       req.GetOwner(),
+		DueAt:       fromProtoTime(req.GetDueAt()),
+	}
+	if err := dto.Validate(task); err != nil {
+		return nil, err
+	}
+
+	res, err := s.service.CreateTask(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tasksv1.CreateTaskResponse{
+		Id:        res.ID,
+		Status:    res.Status,
+		CreatedAt: timestamppb.New(res.CreatedAt),
+	}, nil
+}
+
+func (s *Server) GetTask(ctx context.Context, req *tasksv1.GetTaskRequest) (*tasksv1.Task, error) {
+	ctx, cancel := context.WithTimeout(readContext(ctx), requestTimeout)
+	defer cancel()
+
+	res, err := s.service.GetTaskByID(ctx, strconv.FormatUint(req.GetId(), 10))
+	if err != nil {
+		return nil, err
+	}
+
+	return toProtoTask(res.Task), nil
+}
+
+func (s *Server) ListTasks(ctx context.Context, req *tasksv1.ListTasksRequest) (*tasksv1.ListTasksResponse, error) {
+	ctx, cancel := context.WithTimeout(readContext(ctx), requestTimeout)
+	defer cancel()
+
+	res, err := s.service.GetTasks(
+		ctx,
+		strconv.FormatUint(req.GetPage(), 10),
+		strconv.FormatUint(req.GetLimit(), 10),
+		&models.TaskFilter{
+			Status: req.GetStatus(),
+			Owner:  req.GetOwner(),
+		},
+	)
+	// An empty page is a valid result of a list call.
+	if errors.Is(err, models.ErrNotFound) {
+		return &tasksv1.ListTasksResponse{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]*tasksv1.Task, 0, len(res.Tasks))
+	for _, task := range res.Tasks {
+		tasks = append(tasks, toProtoTask(task))
+	}
+
+	return &tasksv1.ListTasksResponse{
+		Tasks: tasks,
+	}, nil
+}
+
+func (s *Server) UpdateTask(ctx context.Context, req *tasksv1.UpdateTaskRequest) (*emptypb.Empty, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	task := &dto.UpdateTaskRequest{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		Status:      req.GetStatus(),
+		DueAt:       fromProtoTime(req.GetDueAt()),
+	}
+	if err := dto.Validate(task); err != nil {
+		return nil, err
+	}
+
+	if err := s.service.UpdateTask(ctx, strconv.FormatUint(req.GetId(), 10), task); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) DeleteTask(ctx context.Context, req *tasksv1.DeleteTaskRequest) (*emptypb.Empty, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	if err := s.service.DeleteTask(ctx, strconv.FormatUint(req.GetId(), 10)); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) Watch(req *tasksv1.WatchRequest, stream tasksv1.TaskService_WatchServer) error {
+	var lastEventID string
+	if req.GetLastEventId() != 0 {
+		lastEventID = strconv.FormatUint(req.GetLastEventId(), 10)
+	}
+
+	sub, err := s.stream.Subscribe(stream.Context(), &dto.StreamTasksRequest{
+		Status:      req.GetStatus(),
+		Owner:       req.GetOwner(),
+		LastEventID: lastEventID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if sub.Reset {
+		if err := stream.Send(&tasksv1.TaskEvent{Type: eventReset}); err != nil {
+			return err
+		}
+	}
+
+	for _, event := range sub.Replay {
+		if err := sendEvent(stream, event); err != nil {
+			return err
+		}
+	}
+
+	for event := range sub.Events {
+		if err := sendEvent(stream, event); err != nil {
+			return err
+		}
+	}
+
+	// The events are closed when the call is cancelled, the client falls behind or the server shuts down.
+	if stream.Context().Err() == nil {
+		return models.ErrStreamClosed
+	}
+
+	return nil
+}
+
+func sendEvent(stream tasksv1.TaskService_WatchServer, outbox *models.OutboxEvent) error {
+	var event models.TaskEvent
+	if err := json.Unmarshal(outbox.Payload, &event); err != nil {
+		return err
+	}
+
+	res := &tasksv1.TaskEvent{
+		Id:             outbox.ID,
+		Type:           event.Type,
+		TaskId:         event.TaskID,
+		PreviousStatus: event.PreviousStatus,
+		OccurredAt:     timestamppb.New(event.OccurredAt),
+	}
+	if event.Task != nil {
+		res.Task = toProtoTask(event.Task)
+	}
+
+	return stream.Send(res)
+}
+
+// readContext lets the reads of a call go to a replica, unless the client wrote within the read-your-writes window.
+func readContext(ctx context.Context) context.Context {
+	if primary, _ := ctx.Value(readPrimaryKey{}).(bool); primary {
+		return ctx
+	}
+	return postgres.WithReplicaReads(ctx)
+}
+
+func toProtoTask(task *models.Task) *tasksv1.Task {
+	return &tasksv1.Task{
+		Id:            task.ID,
+		Title:         task.Title,
+		Description:   task.Description,
+		Status:        task.Status,
+		Owner:         task.Owner,
+		CommentsCount: task.CommentsCount,
+		CreatedAt:     timestamppb.New(task.CreatedAt),
+		UpdatedAt:     timestamppb.New(task.UpdatedAt),
+		DueAt:         toProtoTime(task.DueAt),
+	}
+}
+
+func toProtoTime(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func fromProtoTime(t *timestamppb.Timestamp) *time.Time {
+	if t == nil {
+		return nil
+	}
+	value := t.AsTime()
+	return &value
+}
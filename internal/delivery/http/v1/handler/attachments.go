@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"skillsrock-test-task/internal/dto"
+	"skillsrock-test-task/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+const uploadTimeout = 5 * time.Minute
+
+// UploadAttachment
+// @Summary      Attach a file to a task
+// @Description  Uploads a file as multipart/form-data. The size and the sniffed MIME type are checked against the configured limits
+// @Tags         attachments
+// @Accept       mpfd
+// @Produce      json
+// @Param        id        path      string  true  "Task ID"
+// @Param        file      formData  file    true  "File"
+// @Param        uploader  formData  string  true  "Uploader"
+// @Success      201  {object}  dto.UploadAttachmentResponse
+// @Failure      400  {object}  Problem  "Invalid input or task ID"
+// @Failure      404  {object}  Problem  "Task not found"
+// @Failure      413  {object}  Problem  "File is too large"
+// @Failure      415  {object}  Problem  "File type is not allowed"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      500  {object}  Problem  "Unknown error occurred"
+// @Router       /tasks/{id}/attachments [post]
+func (h *Handler) UploadAttachment(ctx *fiber.Ctx) error {
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), uploadTimeout)
+	defer cancel()
+
+	taskID := ctx.Params("id")
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		return invalidForm(err)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return invalidForm(err)
+	}
+	defer file.Close()
+
+	res, err := h.attachments.UploadAttachment(ctxWithTimeout, taskID, &dto.UploadAttachmentRequest{
+		Filename: fileHeader.Filename,
+		Uploader: ctx.FormValue("uploader"),
+		Size:     fileHeader.Size,
+		File:     file,
+	})
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info(ctx.Context(), "Attachment uploaded", zap.String("task_id", taskID), zap.Uint64("id", res.Attachment.ID))
+
+	return ctx.Status(fiber.StatusCreated).JSON(res)
+}
+
+// GetAttachments
+// @Summary      Get task attachments
+// @Description  Retrieves metadata of all files attached to a task
+// @Tags         attachments
+// @Produce      json
+// @Param        id   path      string  true  "Task ID"
+// @Success      200  {object}  dto.GetAttachmentsResponse  "List of attachments"
+// @Failure      400  {object}  Problem  "Invalid task ID"
+// @Failure      404  {object}  Problem  "Task not found"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      500  {object}  Problem  "Unknown error occurred"
+// @Router       /tasks/{id}/attachments [get]
+func (h *Handler) GetAttachments(ctx *fiber.Ctx) error {
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	taskID := ctx.Params("id")
+
+	res, err := h.attachments.GetAttachments(ctxWithTimeout, taskID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+// DownloadAttachment
+// @Summary      Download an attachment
+// @Description  Streams the file content. A single byte range can be requested with the Range header
+// @Tags         attachments
+// @Produce      octet-stream
+// @Param        id            path    string  true   "Task ID"
+// @Param        attachmentID  path    string  true   "Attachment ID"
+// @Param        Range         header  string  false  "Byte range, e.g. bytes=0-1023"
+// @Success      200  {file}    file           "File content"
+// @Success      206  {file}    file           "Requested range of the file content"
+// @Failure      400  {object}  Problem  "Invalid IDs"
+// @Failure      404  {object}  Problem  "Attachment not found"
+// @Failure      416  {object}  Problem  "Range not satisfiable"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      500  {object}  Problem  "Unknown error occurred"
+// @Router       /tasks/{id}/attachments/{attachmentID} [get]
+func (h *Handler) DownloadAttachment(ctx *fiber.Ctx) error {
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	taskID := ctx.Params("id")
+	attachmentID := ctx.Params("attachmentID")
+
+	attachment, err := h.attachments.GetAttachment(ctxWithTimeout, taskID, attachmentID)
+	if err != nil {
+		return err
+	}
+
+	offset, length, partial, err := parseRange(ctx.Get(fiber.HeaderRange), attachment.Size)
+
+	var reader io.ReadCloser
+	if err == nil {
+		// The content is streamed after the handler returns, so the read must outlive the request timeout.
+		reader, err = h.attachments.OpenAttachment(context.Background(), attachment, offset, length)
+	}
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidRange) {
+			ctx.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes */%d", attachment.Size))
+		}
+		return err
+	}
+
+	ctx.Set(fiber.HeaderContentType, attachment.ContentType)
+	ctx.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	ctx.Set(fiber.HeaderAcceptRanges, "bytes")
+	ctx.Set(fiber.HeaderETag, strconv.Quote(attachment.Checksum))
+
+	status := fiber.StatusOK
+	if partial {
+		status = fiber.StatusPartialContent
+		ctx.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", offset, offset+length-1, attachment.Size))
+	}
+
+	return ctx.Status(status).SendStream(reader, int(length))
+}
+
+// DeleteAttachment
+// @Summary      Delete an attachment
+// @Description  Deletes the attachment metadata, the stored file is removed in the background
+// @Tags         attachments
+// @Produce      json
+// @Param        id            path  string  true  "Task ID"
+// @Param        attachmentID  path  string  true  "Attachment ID"
+// @Success      200  {string}  string  "Deleted successfully"
+// @Failure      400  {object}  Problem  "Invalid IDs"
+// @Failure      404  {object}  Problem  "Attachment not found"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      500  {object}  Problem  "Unknown error occurred"
+// @Router       /tasks/{id}/attachments/{attachmentID} [delete]
+func (h *Handler) DeleteAttachment(ctx *fiber.Ctx) error {
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	taskID := ctx.Params("id")
+	attachmentID := ctx.Params("attachmentID")
+
+	if err := h.attachments.DeleteAttachment(ctxWithTimeout, taskID, attachmentID); err != nil {
+		return err
+	}
+
+	h.logger.Info(ctx.Context(), "Attachment deleted", zap.String("task_id", taskID), zap.String("id", attachmentID))
+
+	return ctx.SendStatus(fiber.StatusOK)
+}
+
+// parseRange resolves a single "bytes=" range against the blob size.
+// Syntactically invalid or multi-range headers are ignored and the whole content is served, as RFC 9110 allows.
+func parseRange(header string, size int64) (offset, length int64, partial bool, err error) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return 0, size, false, nil
+	}
+
+	startStr, endStr, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return 0, size, false, nil
+	}
+
+	var start, end int64
+
+	if startStr == "" {
+		suffix, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil {
+			return 0, size, false, nil
+		}
+		if suffix <= 0 {
+			return 0, 0, false, models.ErrInvalidRange
+		}
+
+		start, end = max(size-suffix, 0), size-1
+	} else {
+		start, err = strconv.ParseInt(startStr, 10, 64)
+		if err != nil || start < 0 {
+			return 0, size, false, nil
+		}
+
+		end = size - 1
+		if endStr != "" {
+			end, err = strconv.ParseInt(endStr, 10, 64)
+			if err != nil || end < start {
+				return 0, size, false, nil
+			}
+			end = min(end, size-1)
+		}
+	}
+
+	if start >= size {
+		return 0, 0, false, models.ErrInvalidRange
+	}
+
+	return start, end - start + 1, true, nil
+}
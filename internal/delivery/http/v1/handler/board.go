@@ -0,0 +1,380 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"skillsrock-test-task/internal/delivery/middleware"
+	"skillsrock-test-task/internal/dto"
+	"skillsrock-test-task/internal/models"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+const (
+	boardMessageSubscribe   = "subscribe"
+	boardMessageUnsubscribe = "unsubscribe"
+	boardMessageMove        = "move"
+	boardMessageUpdate      = "update"
+	boardMessageView        = "view"
+	boardMessageLeave       = "leave"
+
+	boardMessageAck      = "ack"
+	boardMessageError    = "error"
+	boardMessageEvent    = "event"
+	boardMessageReset    = "reset"
+	boardMessagePresence = "presence"
+
+	boardMaxMessageSize   = 64 << 10
+	boardOutboxSize       = 256
+	boardMaxSubscriptions = 20
+	boardMaxViews         = 50
+	boardRateLimit        = 10
+	boardRateBurst        = 20
+	boardWriteTimeout     = 10 * time.Second
+	boardPongTimeout      = 60 * time.Second
+	boardPingInterval     = boardPongTimeout * 9 / 10
+)
+
+var (
+	errBoardRateLimited       = errors.New("rate limit exceeded")
+	errBoardUnknownMessage    = errors.New("message type is unknown")
+	errBoardInvalidMessage    = errors.New("message is not valid JSON")
+	errBoardSubscriptionID    = errors.New("subscription id is empty or already used")
+	errBoardTooManySubscribes = errors.New("too many subscriptions")
+	errBoardTooManyViews      = errors.New("too many viewed tasks")
+)
+
+type PresenceService interface {
+	View(ctx context.Context, taskID uint64, user string) <-chan []string
+}
+
+// UpgradeBoard lets only WebSocket handshakes through to Board.
+func (h *Handler) UpgradeBoard(ctx *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(ctx) {
+		return fiber.NewError(fiber.StatusUpgradeRequired, "WebSocket upgrade required")
+	}
+	return ctx.Next()
+}
+
+// Board
+// @Summary      Collaborative board over WebSocket
+// @Description  Bidirectional JSON messages. Clients send "subscribe" (subscription, status, owner, last_event_id),
+// @Description  "unsubscribe" (subscription), "move" (task_id, status), "update" (task_id, title, description, status, due_at),
+// @Description  "view" and "leave" (task_id), each with an optional "ref" echoed in the "ack" or "error" reply.
+// @Description  The server pushes "event", "reset" and "presence" messages. Clients may send up to 10 messages per second,
+// @Description  connections that do not read their messages fast enough are closed.
+// @Tags         tasks
+// @Param        access_token  query  string  false  "Access token, when it is not sent in the Authorization header"
+// @Success      101  {string}  string  "Switching protocols"
+// @Failure      401  {object}  Problem  "Missing or invalid access token"
+// @Failure      426  {object}  Problem  "WebSocket upgrade required"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Router       /board [get]
+func (h *Handler) Board() fiber.Handler {
+	return websocket.New(func(conn *websocket.Conn) {
+		user, _ := conn.Locals(middleware.UserKey).(string)
+		newBoardConn(h, conn, user).serve()
+	})
+}
+
+type boardConn struct {
+	h       *Handler
+	conn    *websocket.Conn
+	user    string
+	limiter *rate.Limiter
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	out       chan *dto.BoardMessage
+	done      chan struct{}
+	closeOnce sync.Once
+	closeCode int
+	closeText string
+
+	mu            sync.Mutex
+	subscriptions map[string]context.CancelFunc
+	views         map[uint64]context.CancelFunc
+}
+
+func newBoardConn(h *Handler, conn *websocket.Conn, user string) *boardConn {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &boardConn{
+		h:             h,
+		conn:          conn,
+		user:          user,
+		limiter:       rate.NewLimiter(boardRateLimit, boardRateBurst),
+		ctx:           ctx,
+		cancel:        cancel,
+		out:           make(chan *dto.BoardMessage, boardOutboxSize),
+		done:          make(chan struct{}),
+		subscriptions: make(map[string]context.CancelFunc),
+		views:         make(map[uint64]context.CancelFunc),
+	}
+}
+
+// serve reads client messages until the connection is closed by either side. All writes happen
+// in writeLoop, the connection must not be used after serve returns.
+func (c *boardConn) serve() {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.writeLoop()
+	}()
+
+	c.readLoop()
+
+	c.close(websocket.CloseNormalClosure, "")
+	c.cancel()
+	wg.Wait()
+}
+
+func (c *boardConn) readLoop() {
+	c.conn.SetReadLimit(boardMaxMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(boardPongTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(boardPongTimeout))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		// Any message proves that the client is alive, not only pongs.
+		_ = c.conn.SetReadDeadline(time.Now().Add(boardPongTimeout))
+
+		var req dto.BoardRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			c.replyError("", errBoardInvalidMessage)
+			continue
+		}
+
+		if !c.limiter.Allow() {
+			c.replyError(req.Ref, errBoardRateLimited)
+			continue
+		}
+
+		if err := c.handle(&req); err != nil {
+			c.replyError(req.Ref, err)
+			continue
+		}
+
+		c.send(&dto.BoardMessage{Type: boardMessageAck, Ref: req.Ref})
+	}
+}
+
+func (c *boardConn) writeLoop() {
+	ping := time.NewTicker(boardPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case msg := <-c.out:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(boardWriteTimeout))
+			if err := c.conn.WriteJSON(msg); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+			}
+		case <-ping.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(boardWriteTimeout)); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+			}
+		case <-c.done:
+			if c.closeCode != websocket.CloseAbnormalClosure {
+				_ = c.conn.WriteControl(
+					websocket.CloseMessage,
+					websocket.FormatCloseMessage(c.closeCode, c.closeText),
+					time.Now().Add(boardWriteTimeout),
+				)
+			}
+			// Closing the connection unblocks the read loop.
+			_ = c.conn.Close()
+			return
+		}
+	}
+}
+
+// close stops the connection with the code sent to the client, only the first call has an effect.
+func (c *boardConn) close(code int, text string) {
+	c.closeOnce.Do(func() {
+		c.closeCode = code
+		c.closeText = text
+		close(c.done)
+	})
+}
+
+// send queues the message without blocking, a client that does not read its messages is disconnected.
+func (c *boardConn) send(msg *dto.BoardMessage) {
+	select {
+	case c.out <- msg:
+	default:
+		c.close(websocket.ClosePolicyViolation, "client is too slow")
+	}
+}
+
+func (c *boardConn) replyError(ref string, err error) {
+	c.send(&dto.BoardMessage{Type: boardMessageError, Ref: ref, Error: err.Error()})
+}
+
+func (c *boardConn) handle(req *dto.BoardRequest) error {
+	switch req.Type {
+	case boardMessageSubscribe:
+		return c.subscribe(req)
+	case boardMessageUnsubscribe:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if cancel, ok := c.subscriptions[req.Subscription]; ok {
+			cancel()
+			delete(c.subscriptions, req.Subscription)
+		}
+		return nil
+	case boardMessageMove:
+		return c.mutate(func(ctx context.Context) error {
+			return c.h.service.MoveTask(ctx, strconv.FormatUint(req.TaskID, 10), req.Status)
+		})
+	case boardMessageUpdate:
+		task := &dto.UpdateTaskRequest{
+			Title:       req.Title,
+			Description: req.Description,
+			Status:      req.Status,
+			DueAt:       req.DueAt,
+		}
+		if err := dto.Validate(task); err != nil {
+			return err
+		}
+
+		return c.mutate(func(ctx context.Context) error {
+			return c.h.service.UpdateTask(ctx, strconv.FormatUint(req.TaskID, 10), task)
+		})
+	case boardMessageView:
+		return c.view(req.TaskID)
+	case boardMessageLeave:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if cancel, ok := c.views[req.TaskID]; ok {
+			cancel()
+			delete(c.views, req.TaskID)
+		}
+		return nil
+	default:
+		return errBoardUnknownMessage
+	}
+}
+
+// mutate runs a change through TaskService, so the same validation applies as for the REST API.
+// Subscribers, including this connection, learn about the change from the resulting events.
+func (c *boardConn) mutate(apply func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(c.ctx, requestTimeout)
+	defer cancel()
+
+	err := apply(ctx)
+	switch {
+	case err == nil,
+		errors.Is(err, models.ErrFailedToParseID),
+		errors.Is(err, models.ErrInvalidStatus),
+		errors.Is(err, models.ErrNotFound):
+		return err
+	default:
+		c.h.logger.Error(c.ctx, "Unknown error occurred while changing the task from the board", zap.Error(err))
+		return errors.New("unknown error occurred while changing the task")
+	}
+}
+
+func (c *boardConn) subscribe(req *dto.BoardRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.subscriptions[req.Subscription]; ok || req.Subscription == "" {
+		return errBoardSubscriptionID
+	}
+	if len(c.subscriptions) >= boardMaxSubscriptions {
+		return errBoardTooManySubscribes
+	}
+
+	ctx, cancel := context.WithCancel(c.ctx)
+
+	stream, err := c.h.stream.Subscribe(ctx, &dto.StreamTasksRequest{
+		Status:      req.Status,
+		Owner:       req.Owner,
+		LastEventID: req.LastEventID,
+	})
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	c.subscriptions[req.Subscription] = cancel
+
+	if stream.Reset {
+		c.send(&dto.BoardMessage{Type: boardMessageReset, Subscription: req.Subscription})
+	}
+	for _, event := range stream.Replay {
+		c.sendEvent(req.Subscription, event)
+	}
+
+	go func() {
+		for event := range stream.Events {
+			c.sendEvent(req.Subscription, event)
+		}
+
+		// The stream is closed when the subscription falls behind or the server shuts down.
+		if ctx.Err() == nil {
+			c.close(websocket.CloseGoingAway, "subscription closed, resubscribe with last_event_id")
+		}
+	}()
+
+	return nil
+}
+
+func (c *boardConn) sendEvent(subscription string, event *models.OutboxEvent) {
+	c.send(&dto.BoardMessage{
+		Type:         boardMessageEvent,
+		Subscription: subscription,
+		ID:           event.ID,
+		Event:        event.Payload,
+	})
+}
+
+func (c *boardConn) view(taskID uint64) error {
+	if taskID == 0 {
+		return models.ErrFailedToParseID
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.views[taskID]; ok {
+		return nil
+	}
+	if len(c.views) >= boardMaxViews {
+		return errBoardTooManyViews
+	}
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.views[taskID] = cancel
+
+	updates := c.h.presence.View(ctx, taskID, c.user)
+
+	go func() {
+		for viewers := range updates {
+			if ctx.Err() != nil {
+				continue
+			}
+			c.send(&dto.BoardMessage{Type: boardMessagePresence, TaskID: taskID, Viewers: viewers})
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"skillsrock-test-task/internal/dto"
+	"skillsrock-test-task/internal/models"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseJSON decodes a JSON body into the request and validates it. Decoding is strict: bodies over the limit,
+// unknown fields and data after the JSON value are rejected, so a misspelled field is not silently ignored.
+func (h *Handler) parseJSON(ctx *fiber.Ctx, req any) error {
+	if !ctx.Is("json") {
+		return models.ErrUnsupportedContentType
+	}
+
+	body := ctx.Body()
+	if int64(len(body)) > h.maxJSONBody {
+		return models.ErrBodyTooLarge
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(req); err != nil {
+		return jsonError(err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return invalidBody(errors.New("unexpected data after the JSON value"))
+	}
+
+	return dto.Validate(req)
+}
+
+// jsonError reports the fields of a wrong type or unknown to the request as invalid fields.
+func jsonError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return models.NewFieldError(typeErr.Field, models.ErrInvalidType.WithMessage(
+			fmt.Sprintf("%s must be %s", typeErr.Field, jsonType(typeErr.Type)),
+		))
+	}
+
+	// encoding/json has no type for this error, the message is all it tells.
+	if quoted, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		if field, unquoteErr := strconv.Unquote(quoted); unquoteErr == nil {
+			return models.NewFieldError(field, models.ErrUnknownField.WithMessage(field+" is not a known field"))
+		}
+	}
+
+	return invalidBody(err)
+}
+
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Pointer:
+		return jsonType(t.Elem())
+	default:
+		return "an object"
+	}
+}
@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"skillsrock-test-task/internal/delivery/middleware"
+	"skillsrock-test-task/internal/dto"
+	"skillsrock-test-task/internal/models"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	calendarComponentTodo  = "vtodo"
+	calendarComponentEvent = "vevent"
+
+	calendarProductID = "-//skillsrock-test-task//Tasks//EN"
+	calendarUIDDomain = "skillsrock-test-task"
+	// calendarRefresh is the poll interval suggested to clients, a poll of an unchanged calendar is cheap.
+	calendarRefresh = "PT15M"
+
+	icsLineLength = 75
+	icsTimeLayout = "20060102T150405Z"
+	icsDateLayout = "20060102"
+)
+
+// calendarStatuses are the VTODO statuses of the task statuses.
+var calendarStatuses = map[string]string{
+	"new":         "NEEDS-ACTION",
+	"in_progress": "IN-PROCESS",
+	"done":        "COMPLETED",
+}
+
+// Calendar
+// @Summary      Calendar feed of tasks
+// @Description  Renders the tasks with a due date of the authenticated user as an iCalendar feed, to be subscribed to by
+// @Description  calendar clients. Tasks are to-dos (VTODO) whose status follows the task, or all-day and timed events
+// @Description  (VEVENT). The feed has a strong ETag, a request whose If-None-Match has it is answered with 304 without
+// @Description  reading the tasks. Calendar clients usually can not set headers, so the token is sent as access_token.
+// @Tags         tasks
+// @Produce      text/calendar
+// @Param        access_token   query   string  false  "Access token, when it is not sent in the Authorization header"
+// @Param        status         query   string  false  "Only tasks with these comma separated statuses"
+// @Param        from           query   string  false  "Only tasks due at or after this RFC 3339 time or date"
+// @Param        to             query   string  false  "Only tasks due before this RFC 3339 time or date"
+// @Param        component      query   string  false  "vtodo (default) or vevent"
+// @Param        If-None-Match  header  string  false  "ETag of the feed the client has"
+// @Success      200  {string}  string  "iCalendar feed"
+// @Success      304  {string}  string  "Feed did not change"
+// @Failure      400  {object}  Problem  "Invalid status, range or component"
+// @Failure      401  {object}  Problem  "Missing or invalid access token"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      500  {object}  Problem  "Unknown error occurred"
+// @Router       /calendar.ics [get]
+func (h *Handler) Calendar(ctx *fiber.Ctx) error {
+	ctxWithTimeout, cancel := context.WithTimeout(readContext(ctx), requestTimeout)
+	defer cancel()
+
+	component := ctx.Query("component", calendarComponentTodo)
+	if component != calendarComponentTodo && component != calendarComponentEvent {
+		return models.ErrInvalidCalendarComponent
+	}
+
+	user, _ := ctx.Locals(middleware.UserKey).(string)
+
+	res, err := h.service.GetCalendar(ctxWithTimeout, &dto.GetCalendarRequest{
+		Owner:    user,
+		Status:   ctx.Query("status"),
+		From:     ctx.Query("from"),
+		To:       ctx.Query("to"),
+		Versions: calendarVersions(ctx.Get(fiber.HeaderIfNoneMatch), component),
+	})
+	if err != nil {
+		return err
+	}
+
+	// The representation is part of the ETag, the same tasks render differently as to-dos and as events.
+	ctx.Set(fiber.HeaderETag, strconv.Quote(component+"-"+res.Version))
+	ctx.Set(fiber.HeaderCacheControl, "private, no-cache")
+
+	if res.NotModified {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+
+	ctx.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	ctx.Set(fiber.HeaderContentDisposition, `inline; filename="tasks.ics"`)
+
+	return ctx.Status(fiber.StatusOK).SendString(renderCalendar(user, component, res.Tasks))
+}
+
+// calendarVersions returns the versions of the ETags in an If-None-Match header that have the component.
+func calendarVersions(ifNoneMatch, component string) []string {
+	var versions []string
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		tag, err := strconv.Unquote(tag)
+		if err != nil {
+			continue
+		}
+		if version, ok := strings.CutPrefix(tag, component+"-"); ok {
+			versions = append(versions, version)
+		}
+	}
+
+	return versions
+}
+
+func renderCalendar(user, component string, tasks []*models.Task) string {
+	var w icsWriter
+
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", calendarProductID)
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	w.text("X-WR-CALNAME", "Tasks of "+user)
+	w.line("REFRESH-INTERVAL;VALUE=DURATION", calendarRefresh)
+	w.line("X-PUBLISHED-TTL", calendarRefresh)
+
+	for _, task := range tasks {
+		if component == calendarComponentEvent {
+			renderEvent(&w, task)
+		} else {
+			renderTodo(&w, task)
+		}
+	}
+
+	w.line("END", "VCALENDAR")
+
+	return w.String()
+}
+
+func renderTodo(w *icsWriter, task *models.Task) {
+	w.line("BEGIN", "VTODO")
+	renderTaskProperties(w, task)
+	w.line("STATUS", calendarStatuses[task.Status])
+	w.date("DUE", *task.DueAt)
+	w.line("END", "VTODO")
+}
+
+// renderEvent renders a task due at midnight UTC as an all-day event and other tasks as events without a
+// duration. Events have no task statuses, the status is a category.
+func renderEvent(w *icsWriter, task *models.Task) {
+	w.line("BEGIN", "VEVENT")
+	renderTaskProperties(w, task)
+	w.text("CATEGORIES", task.Status)
+	w.date("DTSTART", *task.DueAt)
+	if isAllDay(*task.DueAt) {
+		w.date("DTEND", task.DueAt.AddDate(0, 0, 1))
+	}
+	w.line("TRANSP", "TRANSPARENT")
+	w.line("END", "VEVENT")
+}
+
+func renderTaskProperties(w *icsWriter, task *models.Task) {
+	w.line("UID", fmt.Sprintf("task-%d@%s", task.ID, calendarUIDDomain))
+	w.line("DTSTAMP", task.UpdatedAt.UTC().Format(icsTimeLayout))
+	w.line("CREATED", task.CreatedAt.UTC().Format(icsTimeLayout))
+	w.line("LAST-MODIFIED", task.UpdatedAt.UTC().Format(icsTimeLayout))
+	w.text("SUMMARY", task.Title)
+	if task.Description != "" {
+		w.text("DESCRIPTION", task.Description)
+	}
+}
+
+// isAllDay tells a due date set without a time, which is due at the start of the day in UTC.
+func isAllDay(t time.Time) bool {
+	t = t.UTC()
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
+
+// icsWriter writes content lines as RFC 5545 wants them: ended by CRLF and folded after 75 octets.
+type icsWriter struct {
+	strings.Builder
+}
+
+func (w *icsWriter) line(name, value string) {
+	line := name + ":" + value
+
+	// Continuation lines start with a space, which counts towards their length. UTF-8 sequences are not split.
+	limit := icsLineLength
+	for len(line) > limit {
+		cut := limit
+		for !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		limit = icsLineLength - 1
+	}
+
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+func (w *icsWriter) text(name, value string) {
+	w.line(name, icsText.Replace(value))
+}
+
+func (w *icsWriter) date(name string, t time.Time) {
+	if isAllDay(t) {
+		w.line(name+";VALUE=DATE", t.UTC().Format(icsDateLayout))
+		return
+	}
+	w.line(name, t.UTC().Format(icsTimeLayout))
+}
+
+// icsText escapes TEXT values. Control characters other than tabs are not allowed in them and are dropped.
+var icsText = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+	"\x00", "", "\x01", "", "\x02", "", "\x03", "", "\x04", "", "\x05", "", "\x06", "", "\x07", "",
+	"\x08", "", "\x0b", "", "\x0c", "", "\x0e", "", "\x0f", "", "\x10", "", "\x11", "", "\x12", "",
+	"\x13", "", "\x14", "", "\x15", "", "\x16", "", "\x17", "", "\x18", "", "\x19", "", "\x1a", "",
+	"\x1b", "", "\x1c", "", "\x1d", "", "\x1e", "", "\x1f", "", "\x7f", "",
+)
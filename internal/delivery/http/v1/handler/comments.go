@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"context"
+	"skillsrock-test-task/internal/dto"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// CreateComment
+// @Summary      Comment on a task
+// @Description  Adds a comment to the task, optionally as a reply to another comment of the same task
+// @Tags         comments
+// @Accept       json
+// @Produce      json
+// @Param        id       path  string                    true  "Task ID"
+// @Param        comment  body  dto.CreateCommentRequest  true  "Comment"
+// @Success      201  {object}  dto.CreateCommentResponse
+// @Failure      400  {object}  Problem  "Invalid input or task ID"
+// @Failure      404  {object}  Problem  "Task not found"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      500  {object}  Problem  "Unknown error occurred"
+// @Router       /tasks/{id}/comments [post]
+func (h *Handler) CreateComment(ctx *fiber.Ctx) error {
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	taskID := ctx.Params("id")
+
+	var comment dto.CreateCommentRequest
+	if err := h.parseJSON(ctx, &comment); err != nil {
+		return err
+	}
+
+	res, err := h.comments.CreateComment(ctxWithTimeout, taskID, &comment)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info(ctx.Context(), "Comment created", zap.String("task_id", taskID), zap.Uint64("id", res.ID))
+
+	return ctx.Status(fiber.StatusCreated).JSON(res)
+}
+
+// GetComments
+// @Summary      Get task comments
+// @Description  Retrieves the comments of a task in chronological order using cursor pagination
+// @Tags         comments
+// @Produce      json
+// @Param        id      path   string  true   "Task ID"
+// @Param        cursor  query  string  false  "Cursor returned as next_cursor by the previous page"
+// @Param        limit   query  string  false  "Items per page"
+// @Success      200  {object}  dto.GetCommentsResponse  "List of comments"
+// @Failure      400  {object}  Problem  "Invalid task ID or pagination parameters"
+// @Failure      404  {object}  Problem  "Task not found"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      500  {object}  Problem  "Unknown error occurred"
+// @Router       /tasks/{id}/comments [get]
+func (h *Handler) GetComments(ctx *fiber.Ctx) error {
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	taskID := ctx.Params("id")
+	cursor := ctx.Query("cursor")
+	limit := ctx.Query("limit")
+
+	res, err := h.comments.GetComments(ctxWithTimeout, taskID, cursor, limit)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+// UpdateComment
+// @Summary      Edit a comment
+// @Description  Replaces the body of a comment and marks it as edited
+// @Tags         comments
+// @Accept       json
+// @Produce      json
+// @Param        id         path  string                    true  "Task ID"
+// @Param        commentID  path  string                    true  "Comment ID"
+// @Param        comment    body  dto.UpdateCommentRequest  true  "Comment payload"
+// @Success      200  {string}  string  "Updated successfully"
+// @Failure      400  {object}  Problem  "Invalid input or IDs"
+// @Failure      404  {object}  Problem  "Comment not found"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      500  {object}  Problem  "Unknown error occurred"
+// @Router       /tasks/{id}/comments/{commentID} [put]
+func (h *Handler) UpdateComment(ctx *fiber.Ctx) error {
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	taskID := ctx.Params("id")
+	commentID := ctx.Params("commentID")
+
+	var comment dto.UpdateCommentRequest
+	if err := h.parseJSON(ctx, &comment); err != nil {
+		return err
+	}
+
+	if err := h.comments.UpdateComment(ctxWithTimeout, taskID, commentID, &comment); err != nil {
+		return err
+	}
+
+	h.logger.Info(ctx.Context(), "Comment updated", zap.String("task_id", taskID), zap.String("id", commentID))
+
+	return ctx.SendStatus(fiber.StatusOK)
+}
+
+// DeleteComment
+// @Summary      Delete a comment
+// @Description  Soft-deletes a comment, replies to it are kept
+// @Tags         comments
+// @Produce      json
+// @Param        id         path  string  true  "Task ID"
+// @Param        commentID  path  string  true  "Comment ID"
+// @Success      200  {string}  string  "Deleted successfully"
+// @Failure      400  {object}  Problem  "Invalid IDs"
+// @Failure      404  {object}  Problem  "Comment not found"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      500  {object}  Problem  "Unknown error occurred"
+// @Router       /tasks/{id}/comments/{commentID} [delete]
+func (h *Handler) DeleteComment(ctx *fiber.Ctx) error {
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	taskID := ctx.Params("id")
+	commentID := ctx.Params("commentID")
+
+	if err := h.comments.DeleteComment(ctxWithTimeout, taskID, commentID); err != nil {
+		return err
+	}
+
+	h.logger.Info(ctx.Context(), "Comment deleted", zap.String("task_id", taskID), zap.String("id", commentID))
+
+	return ctx.SendStatus(fiber.StatusOK)
+}
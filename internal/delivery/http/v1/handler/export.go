@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"skillsrock-test-task/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	exportFormatCSV    = "csv"
+	exportFormatJSON   = "json"
+	exportFormatNDJSON = "ndjson"
+
+	// exportTimeout bounds an export instead of requestTimeout, large exports take minutes.
+	exportTimeout = 1 * time.Hour
+)
+
+var exportContentTypes = map[string]string{
+	exportFormatCSV:    "text/csv; charset=utf-8",
+	exportFormatJSON:   fiber.MIMEApplicationJSONCharsetUTF8,
+	exportFormatNDJSON: "application/x-ndjson",
+}
+
+type exportColumn struct {
+	name  string
+	value func(task *models.Task) interface{}
+}
+
+var exportColumns = []exportColumn{
+	{"id", func(task *models.Task) interface{} { return task.ID }},
+	{"title", func(task *models.Task) interface{} { return task.Title }},
+	{"description", func(task *models.Task) interface{} { return task.Description }},
+	{"status", func(task *models.Task) interface{} { return task.Status }},
+	{"owner", func(task *models.Task) interface{} { return task.Owner }},
+	{"due_at", func(task *models.Task) interface{} { return task.DueAt }},
+	{"comments_count", func(task *models.Task) interface{} { return task.CommentsCount }},
+	{"created_at", func(task *models.Task) interface{} { return task.CreatedAt }},
+	{"updated_at", func(task *models.Task) interface{} { return task.UpdatedAt }},
+}
+
+// ExportTasks
+// @Summary      Export tasks
+// @Description  Streams all tasks matching the filters, oldest first, as a file download. The export is a consistent
+// @Description  snapshot and is not limited by the timeout of the other endpoints. An error after the download
+// @Description  started cuts the file short, so a JSON export is not valid JSON then.
+// @Tags         tasks
+// @Produce      text/csv
+// @Produce      json
+// @Produce      application/x-ndjson
+// @Param        format   query  string  false  "csv (default), json or ndjson"
+// @Param        columns  query  string  false  "Comma separated columns, all by default: id,title,description,status,owner,due_at,comments_count,created_at,updated_at"
+// @Param        status   query  string  false  "Only tasks with this status"
+// @Param        owner    query  string  false  "Only tasks of this owner"
+// @Param        gzip     query  bool    false  "Compress the file with gzip"
+// @Success      200  {file}    file           "Exported tasks"
+// @Failure      400  {object}  Problem  "Invalid format, columns or status"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      500  {object}  Problem  "Unknown error occurred"
+// @Router       /tasks/export [get]
+func (h *Handler) ExportTasks(ctx *fiber.Ctx) error {
+	format := ctx.Query("format", exportFormatCSV)
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		return models.ErrInvalidExportFormat
+	}
+
+	columns, err := parseExportColumns(ctx.Query("columns"))
+	if err != nil {
+		return err
+	}
+
+	export, err := h.service.ExportTasks(&models.TaskFilter{
+		Status: ctx.Query("status"),
+		Owner:  ctx.Query("owner"),
+	})
+	if err != nil {
+		return err
+	}
+
+	compress := ctx.QueryBool("gzip")
+
+	filename := "tasks-" + time.Now().UTC().Format("20060102-150405") + "." + format
+	if compress {
+		filename += ".gz"
+		contentType = "application/gzip"
+	}
+
+	ctx.Set(fiber.HeaderContentType, contentType)
+	ctx.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+
+	// The stream writer runs after the handler returned, it must not touch the fiber context.
+	readCtx := readContext(ctx)
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		exportCtx, cancel := context.WithTimeout(readCtx, exportTimeout)
+		defer cancel()
+
+		var out io.Writer = w
+		var zw *gzip.Writer
+		if compress {
+			zw = gzip.NewWriter(w)
+			out = zw
+		}
+
+		enc := newTaskEncoder(format, out, columns)
+
+		// A write fails when the client has gone away, which stops the export.
+		err := export(exportCtx, enc.encode)
+		if err == nil {
+			err = enc.close()
+		}
+		if err == nil && zw != nil {
+			err = zw.Close()
+		}
+		if err != nil {
+			h.logger.Error(exportCtx, "Export of the tasks failed", zap.Error(err))
+			return
+		}
+
+		_ = w.Flush()
+	})
+
+	return nil
+}
+
+func parseExportColumns(query string) ([]exportColumn, error) {
+	if query == "" {
+		return exportColumns, nil
+	}
+
+	var columns []exportColumn
+	for _, name := range strings.Split(query, ",") {
+		name = strings.TrimSpace(name)
+
+		found := false
+		for _, column := range exportColumns {
+			if column.name == name {
+				columns = append(columns, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: unknown column %q", models.ErrInvalidExportColumns, name)
+		}
+	}
+
+	return columns, nil
+}
+
+// taskEncoder writes the selected columns of the tasks in one of the export formats.
+type taskEncoder struct {
+	format  string
+	w       io.Writer
+	csv     *csv.Writer
+	columns []exportColumn
+	count   int
+	row     []string
+}
+
+func newTaskEncoder(format string, w io.Writer, columns []exportColumn) *taskEncoder {
+	enc := &taskEncoder{
+		format:  format,
+		w:       w,
+		columns: columns,
+	}
+	if format == exportFormatCSV {
+		enc.csv = csv.NewWriter(w)
+		enc.row = make([]string, len(columns))
+	}
+
+	return enc
+}
+
+func (e *taskEncoder) encode(task *models.Task) error {
+	defer func() { e.count++ }()
+
+	switch e.format {
+	case exportFormatCSV:
+		if e.count == 0 {
+			if err := e.writeHeader(); err != nil {
+				return err
+			}
+		}
+		for i, column := range e.columns {
+			e.row[i] = csvValue(column.value(task))
+		}
+		if err := e.csv.Write(e.row); err != nil {
+			return err
+		}
+		// csv.Writer buffers on its own, flushing it lets a failed write stop the export right away.
+		e.csv.Flush()
+		return e.csv.Error()
+	case exportFormatNDJSON:
+		return e.writeObject(task, "", "\n")
+	default:
+		prefix := ",\n"
+		if e.count == 0 {
+			prefix = "[\n"
+		}
+		return e.writeObject(task, prefix, "")
+	}
+}
+
+// close finishes the file, an export without tasks is a valid empty file.
+func (e *taskEncoder) close() error {
+	switch e.format {
+	case exportFormatCSV:
+		if e.count == 0 {
+			if err := e.writeHeader(); err != nil {
+				return err
+			}
+		}
+		e.csv.Flush()
+		return e.csv.Error()
+	case exportFormatNDJSON:
+		return nil
+	default:
+		end := "\n]\n"
+		if e.count == 0 {
+			end = "[]\n"
+		}
+		_, err := io.WriteString(e.w, end)
+		return err
+	}
+}
+
+func (e *taskEncoder) writeHeader() error {
+	for i, column := range e.columns {
+		e.row[i] = column.name
+	}
+	return e.csv.Write(e.row)
+}
+
+// writeObject writes the columns in their requested order, which a map would not keep.
+func (e *taskEncoder) writeObject(task *models.Task, prefix, suffix string) error {
+	var b strings.Builder
+	b.WriteString(prefix)
+	b.WriteByte('{')
+	for i, column := range e.columns {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		value, err := json.Marshal(column.value(task))
+		if err != nil {
+			return err
+		}
+		b.WriteString(strconv.Quote(column.name))
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	b.WriteString(suffix)
+
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+func csvValue(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case uint64:
+		return strconv.FormatUint(value, 10)
+	case time.Time:
+		return value.Format(time.RFC3339Nano)
+	case *time.Time:
+		if value == nil {
+			return ""
+		}
+		return value.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(value)
+	}
+}
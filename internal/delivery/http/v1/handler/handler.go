@@ -3,6 +3,9 @@ package handler
 import (
 	"context"
 	"errors"
+	"io"
+	"skillsrock-test-task/internal/database/postgres"
+	"skillsrock-test-task/internal/delivery/middleware"
 	"skillsrock-test-task/internal/dto"
 	"skillsrock-test-task/internal/models"
 	"skillsrock-test-task/pkg/logger"
@@ -12,29 +15,79 @@ import (
 	"go.uber.org/zap"
 )
 
-const requestTimeout = 1 * time.Second
-
-type ErrorResponse struct {
-	Error string `json:"error"`
-}
+const (
+	requestTimeout = 1 * time.Second
+	bulkTimeout    = 30 * time.Second
+)
 
 type TaskService interface {
 	CreateTask(ctx context.Context, task *dto.CreateTaskRequest) (*dto.CreateTaskResponse, error)
 	GetTaskByID(ctx context.Context, id string) (*dto.GetTaskByIDResponse, error)
-	GetTasks(ctx context.Context, page, limit string) (*dto.GetTasksResponse, error)
+	GetTasks(ctx context.Context, page, limit string, filter *models.TaskFilter) (*dto.GetTasksResponse, error)
+	ExportTasks(filter *models.TaskFilter) (dto.TaskExport, error)
+	GetCalendar(ctx context.Context, req *dto.GetCalendarRequest) (*dto.GetCalendarResponse, error)
 	DeleteTask(ctx context.Context, id string) error
 	UpdateTask(ctx context.Context, id string, task *dto.UpdateTaskRequest) error
+	MoveTask(ctx context.Context, id, status string) error
+	BulkTasks(ctx context.Context, req *dto.BulkTasksRequest) (*dto.BulkTasksResponse, error)
+}
+
+type CommentService interface {
+	CreateComment(ctx context.Context, taskID string, comment *dto.CreateCommentRequest) (*dto.CreateCommentResponse, error)
+	GetComments(ctx context.Context, taskID, cursor, limit string) (*dto.GetCommentsResponse, error)
+	UpdateComment(ctx context.Context, taskID, commentID string, comment *dto.UpdateCommentRequest) error
+	DeleteComment(ctx context.Context, taskID, commentID string) error
+}
+
+type AttachmentService interface {
+	UploadAttachment(ctx context.Context, taskID string, upload *dto.UploadAttachmentRequest) (*dto.UploadAttachmentResponse, error)
+	GetAttachments(ctx context.Context, taskID string) (*dto.GetAttachmentsResponse, error)
+	GetAttachment(ctx context.Context, taskID, attachmentID string) (*models.Attachment, error)
+	OpenAttachment(ctx context.Context, attachment *models.Attachment, offset, length int64) (io.ReadCloser, error)
+	DeleteAttachment(ctx context.Context, taskID, attachmentID string) error
+}
+
+type ImportService interface {
+	ImportTasks(ctx context.Context, req *dto.ImportTasksRequest) (*dto.ImportTasksResponse, error)
+	GetImportJob(ctx context.Context, id string) (*dto.GetImportJobResponse, error)
+	ImportFromTracker(ctx context.Context, req *dto.ImportFromTrackerRequest) (*dto.ImportFromTrackerResponse, error)
+}
+
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, owner string, webhook *dto.CreateWebhookRequest) (*dto.CreateWebhookResponse, error)
+	GetWebhooks(ctx context.Context, owner string) (*dto.GetWebhooksResponse, error)
+	DeleteWebhook(ctx context.Context, owner, id string) error
+	GetDeliveries(ctx context.Context, owner, webhookID, cursor, limit string) (*dto.GetDeliveriesResponse, error)
+	Redeliver(ctx context.Context, owner, webhookID, deliveryID string) (*dto.RedeliverResponse, error)
+}
+
+type TaskStreamService interface {
+	Subscribe(ctx context.Context, req *dto.StreamTasksRequest) (*dto.TaskStream, error)
 }
 
 type Handler struct {
-	service TaskService
-	logger  logger.Logger
+	service     TaskService
+	comments    CommentService
+	attachments AttachmentService
+	imports     ImportService
+	webhooks    WebhookService
+	stream      TaskStreamService
+	presence    PresenceService
+	logger      logger.Logger
+	maxJSONBody int64
 }
 
-func NewHandler(serv TaskService, log logger.Logger) *Handler {
+func NewHandler(serv TaskService, comments CommentService, attachments AttachmentService, imports ImportService, webhooks WebhookService, stream TaskStreamService, presence PresenceService, log logger.Logger, maxJSONBody int64) *Handler {
 	return &Handler{
-		service: serv,
-		logger:  log,
+		service:     serv,
+		comments:    comments,
+		attachments: attachments,
+		imports:     imports,
+		webhooks:    webhooks,
+		stream:      stream,
+		presence:    presence,
+		logger:      log,
+		maxJSONBody: maxJSONBody,
 	}
 }
 
@@ -44,9 +97,13 @@ func NewHandler(serv TaskService, log logger.Logger) *Handler {
 // @Tags         tasks
 // @Produce      json
 // @Param book body dto.CreateTaskRequest true "Task"
+// @Param        Idempotency-Key  header  string  false  "Repeating a request with the same key replays the original response"
 // @Success      201  {object}  dto.CreateTaskResponse
-// @Failure      400  {object}  ErrorResponse  "Invalid request body"
-// @Failure      500  {object}  ErrorResponse  "Uknown error occured while creating the task"
+// @Failure      400  {object}  Problem  "Invalid request body or empty title"
+// @Failure      409  {object}  Problem  "Request with the same idempotency key is still in progress"
+// @Failure      422  {object}  Problem  "Idempotency key was used with a different request"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      500  {object}  Problem  "Unknown error occurred while creating the task"
 // @Router /tasks [post]
 func (h *Handler) CreateTask(ctx *fiber.Ctx) error {
 	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), requestTimeout)
@@ -54,16 +111,13 @@ func (h *Handler) CreateTask(ctx *fiber.Ctx) error {
 
 	var task dto.CreateTaskRequest
 
-	if err := ctx.BodyParser(&task); err != nil {
-		h.logger.Error(ctx.Context(), "Failed to parse request body", zap.Error(err))
-		return ctx.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid request body"})
+	if err := h.parseJSON(ctx, &task); err != nil {
+		return err
 	}
 
 	res, err := h.service.CreateTask(ctxWithTimeout, &task)
 	if err != nil {
-
-		h.logger.Error(ctx.Context(), "Unknown error occured while creating the task", zap.Error(err))
-		return ctx.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Uknown error occured while creating the task"})
+		return err
 	}
 
 	h.logger.Info(ctx.Context(), "Task created", zap.Uint64("id", res.ID))
@@ -78,27 +132,20 @@ func (h *Handler) CreateTask(ctx *fiber.Ctx) error {
 // @Produce      json
 // @Param        id   path      string  true  "Task ID"
 // @Success      200  {object}  dto.GetTaskByIDResponse  "Task details"
-// @Failure      400  {object}  ErrorResponse  "Invalid task ID"
-// @Failure      404  {object}  ErrorResponse  "Task not found"
-// @Failure      500  {object}  ErrorResponse  "Unknown error occurred"
+// @Failure      400  {object}  Problem  "Invalid task ID"
+// @Failure      404  {object}  Problem  "Task not found"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      500  {object}  Problem  "Unknown error occurred"
 // @Router       /tasks/{id} [get]
 func (h *Handler) GetTaskByID(ctx *fiber.Ctx) error {
-	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	ctxWithTimeout, cancel := context.WithTimeout(readContext(ctx), requestTimeout)
 	defer cancel()
 
 	taskID := ctx.Params("id")
 
 	res, err := h.service.GetTaskByID(ctxWithTimeout, taskID)
 	if err != nil {
-		switch {
-		case errors.Is(err, models.ErrFailedToParseID):
-			return ctx.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: err.Error()})
-		case errors.Is(err, models.ErrNotFound):
-			return ctx.Status(fiber.StatusNotFound).JSON(ErrorResponse{Error: err.Error()})
-		default:
-			h.logger.Error(ctx.Context(), "Unknown error occurred while getting the task", zap.Error(err))
-			return ctx.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Unknown error occurred while getting the task"})
-		}
+		return err
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(res)
@@ -113,9 +160,10 @@ func (h *Handler) GetTaskByID(ctx *fiber.Ctx) error {
 // @Param        id    path  string     true  "Task ID"
 // @Param        task  body  dto.UpdateTaskRequest   true  "Task payload"
 // @Success      200   {string}  string  "Updated successfully"
-// @Failure      400   {object}  ErrorResponse  "Invalid input or task ID"
-// @Failure      404   {object}  ErrorResponse  "Task not found"
-// @Failure      500   {object}  ErrorResponse  "Unknown error occurred"
+// @Failure      400   {object}  Problem  "Invalid input or task ID"
+// @Failure      404   {object}  Problem  "Task not found"
+// @Failure      429   {object}  Problem  "Too many requests"
+// @Failure      500   {object}  Problem  "Unknown error occurred"
 // @Router       /tasks/{id} [put]
 func (h *Handler) UpdateTask(ctx *fiber.Ctx) error {
 	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), requestTimeout)
@@ -124,21 +172,12 @@ func (h *Handler) UpdateTask(ctx *fiber.Ctx) error {
 	taskID := ctx.Params("id")
 
 	var task dto.UpdateTaskRequest
-	if err := ctx.BodyParser(&task); err != nil {
-		h.logger.Error(ctx.Context(), "Invalid request body", zap.Error(err))
-		return ctx.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid request body"})
+	if err := h.parseJSON(ctx, &task); err != nil {
+		return err
 	}
 
 	if err := h.service.UpdateTask(ctxWithTimeout, taskID, &task); err != nil {
-		switch {
-		case errors.Is(err, models.ErrFailedToParseID) || errors.Is(err, models.ErrInvalidStatus):
-			return ctx.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: err.Error()})
-		case errors.Is(err, models.ErrNotFound):
-			return ctx.Status(fiber.StatusNotFound).JSON(ErrorResponse{Error: err.Error()})
-		default:
-			h.logger.Error(ctx.Context(), "Unknown error occurred while updating the task", zap.Error(err))
-			return ctx.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Unknown error occurred while updating the task"})
-		}
+		return err
 	}
 
 	h.logger.Info(ctx.Context(), "Task updated", zap.String("id", taskID))
@@ -153,9 +192,10 @@ func (h *Handler) UpdateTask(ctx *fiber.Ctx) error {
 // @Produce      json
 // @Param        id   path  string  true  "Task ID"
 // @Success      200  {string}  string  "Deleted successfully"
-// @Failure      400  {object}  ErrorResponse  "Invalid task ID"
-// @Failure      404  {object}  ErrorResponse  "Task not found"
-// @Failure      500  {object}  ErrorResponse  "Unknown error occurred"
+// @Failure      400  {object}  Problem  "Invalid task ID"
+// @Failure      404  {object}  Problem  "Task not found"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      500  {object}  Problem  "Unknown error occurred"
 // @Router       /tasks/{id} [delete]
 func (h *Handler) DeleteTask(ctx *fiber.Ctx) error {
 	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), requestTimeout)
@@ -164,15 +204,7 @@ func (h *Handler) DeleteTask(ctx *fiber.Ctx) error {
 	taskID := ctx.Params("id")
 
 	if err := h.service.DeleteTask(ctxWithTimeout, taskID); err != nil {
-		switch {
-		case errors.Is(err, models.ErrFailedToParseID):
-			return ctx.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: err.Error()})
-		case errors.Is(err, models.ErrNotFound):
-			return ctx.Status(fiber.StatusNotFound).JSON(ErrorResponse{Error: err.Error()})
-		default:
-			h.logger.Error(ctx.Context(), "Unknown error occurred while deleting the task", zap.Error(err))
-			return ctx.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Unknown error occurred while deleting the task"})
-		}
+		return err
 	}
 
 	h.logger.Info(ctx.Context(), "Task deleted", zap.String("id", taskID))
@@ -182,35 +214,81 @@ func (h *Handler) DeleteTask(ctx *fiber.Ctx) error {
 
 // GetTasks
 // @Summary      Get tasks
-// @Description  Retrieves a paginated list of tasks
+// @Description  Retrieves a paginated list of tasks, optionally filtered by status and owner
 // @Tags         tasks
 // @Produce      json
-// @Param        page   query     string  false  "Page number"
-// @Param        limit  query     string  false  "Items per page"
-// @Success      200    {object}  dto.GetTasksResponse  "List of tasks"
-// @Failure      400    {object}  ErrorResponse  "Invalid pagination parameters"
-// @Failure      404    {object}  ErrorResponse  "No tasks found"
-// @Failure      500    {object}  ErrorResponse  "Unknown error occurred"
+// @Param        page    query     string  false  "Page number"
+// @Param        limit   query     string  false  "Items per page"
+// @Param        status  query     string  false  "Only tasks with this status"
+// @Param        owner   query     string  false  "Only tasks of this owner"
+// @Success      200     {object}  dto.GetTasksResponse  "List of tasks"
+// @Failure      400     {object}  Problem  "Invalid pagination parameters or status"
+// @Failure      404    {object}  Problem  "No tasks found"
+// @Failure      429    {object}  Problem  "Too many requests"
+// @Failure      500    {object}  Problem  "Unknown error occurred"
 // @Router       /tasks [get]
 func (h *Handler) GetTasks(ctx *fiber.Ctx) error {
-	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	ctxWithTimeout, cancel := context.WithTimeout(readContext(ctx), requestTimeout)
 	defer cancel()
 
 	page := ctx.Query("page")
 	limit := ctx.Query("limit")
+	filter := &models.TaskFilter{
+		Status: ctx.Query("status"),
+		Owner:  ctx.Query("owner"),
+	}
+
+	res, err := h.service.GetTasks(ctxWithTimeout, page, limit, filter)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+// BulkTasks
+// @Summary      Create, update and delete tasks in bulk
+// @Description  Applies a list of operations in one request. In "atomic" mode (default) all operations are committed or none;
+// @Description  a rolled back request is answered with 422 and per-item results. In "best_effort" mode every operation is applied independently.
+// @Tags         tasks
+// @Accept       json
+// @Produce      json
+// @Param        request  body  dto.BulkTasksRequest  true  "Operations"
+// @Success      200  {object}  dto.BulkTasksResponse  "Per-item results"
+// @Failure      400  {object}  Problem  "Invalid request body or mode"
+// @Failure      413  {object}  Problem  "Too many operations"
+// @Failure      422  {object}  dto.BulkTasksResponse  "Atomic request was rolled back"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      500  {object}  Problem  "Unknown error occurred"
+// @Router       /tasks/bulk [post]
+func (h *Handler) BulkTasks(ctx *fiber.Ctx) error {
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), bulkTimeout)
+	defer cancel()
+
+	var req dto.BulkTasksRequest
+	if err := h.parseJSON(ctx, &req); err != nil {
+		return err
+	}
 
-	res, err := h.service.GetTasks(ctxWithTimeout, page, limit)
+	res, err := h.service.BulkTasks(ctxWithTimeout, &req)
 	if err != nil {
-		switch {
-		case errors.Is(err, models.ErrFailedToParsePage), errors.Is(err, models.ErrFailedToParseLimit):
-			return ctx.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: err.Error()})
-		case errors.Is(err, models.ErrNotFound):
-			return ctx.Status(fiber.StatusNotFound).JSON(ErrorResponse{Error: err.Error()})
-		default:
-			h.logger.Error(ctx.Context(), "Unknown error occurred while listing the tasks", zap.Error(err))
-			return ctx.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Unknown error occurred while listing the tasks"})
+		// A rolled back request is answered with the results, which tell the operation that failed.
+		if errors.Is(err, models.ErrBulkRolledBack) {
+			return ctx.Status(fiber.StatusUnprocessableEntity).JSON(res)
 		}
+		return err
 	}
 
+	h.logger.Info(ctx.Context(), "Bulk operations applied", zap.String("mode", res.Mode), zap.Int("operations", len(res.Results)))
+
 	return ctx.Status(fiber.StatusOK).JSON(res)
 }
+
+// readContext is the base context of the reads of a request. They may go to a read replica, unless the client
+// wrote within the read-your-writes window and the replicas may not have the write yet.
+func readContext(ctx *fiber.Ctx) context.Context {
+	if primary, _ := ctx.Locals(middleware.ReadPrimaryKey).(bool); primary {
+		return context.Background()
+	}
+	return postgres.WithReplicaReads(context.Background())
+}
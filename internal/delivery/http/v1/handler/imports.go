@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"context"
+	"skillsrock-test-task/internal/dto"
+	"skillsrock-test-task/internal/models"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// importTimeout bounds the imports that are run during the request, larger files are imported by a job.
+const importTimeout = 5 * time.Minute
+
+// ImportTasks
+// @Summary      Import tasks
+// @Description  Creates tasks from a CSV or NDJSON file uploaded as multipart/form-data. Every row is validated like a
+// @Description  created task and may set the status, rows that are not valid are skipped and reported. The valid rows
+// @Description  are imported together or not at all. Small files are imported during the request, larger ones are
+// @Description  imported by a job whose status is polled at the Location of the response.
+// @Tags         tasks
+// @Accept       mpfd
+// @Produce      json
+// @Param        file     formData  file    true   "CSV file with a header line or NDJSON file"
+// @Param        format   formData  string  false  "csv or ndjson, taken from the file extension by default"
+// @Param        mapping  formData  string  false  "JSON object mapping the fields title, description, owner, status and due_at to columns, e.g. {\"title\":\"Summary\"}"
+// @Param        dry_run  formData  bool    false  "Only validate the rows"
+// @Success      200  {object}  dto.ImportTasksResponse  "Report of the import"
+// @Success      202  {object}  dto.ImportTasksResponse  "Job importing the file"
+// @Failure      400  {object}  Problem  "Invalid form, format, mapping or file"
+// @Failure      413  {object}  Problem  "File is too large"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      500  {object}  Problem  "Unknown error occurred"
+// @Router       /tasks/import [post]
+func (h *Handler) ImportTasks(ctx *fiber.Ctx) error {
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), importTimeout)
+	defer cancel()
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		return invalidForm(err)
+	}
+
+	dryRun := false
+	if value := ctx.FormValue("dry_run"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			return models.NewFieldError("dry_run", models.ErrInvalidBoolean)
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return invalidForm(err)
+	}
+	defer file.Close()
+
+	res, err := h.imports.ImportTasks(ctxWithTimeout, &dto.ImportTasksRequest{
+		Filename: fileHeader.Filename,
+		Format:   ctx.FormValue("format"),
+		Mapping:  ctx.FormValue("mapping"),
+		DryRun:   dryRun,
+		Size:     fileHeader.Size,
+		File:     file,
+	})
+	if err != nil {
+		return err
+	}
+
+	if res.Job != nil {
+		h.logger.Info(ctx.Context(), "Import job created", zap.Uint64("id", res.Job.ID))
+
+		ctx.Location("/api/v1/tasks/import/" + strconv.FormatUint(res.Job.ID, 10))
+		return ctx.Status(fiber.StatusAccepted).JSON(res)
+	}
+
+	h.logger.Info(ctx.Context(), "Tasks imported",
+		zap.Uint64("imported", res.Report.ImportedRows),
+		zap.Uint64("failed", res.Report.FailedRows),
+		zap.Bool("dry_run", res.Report.DryRun),
+	)
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+// GetImportJob
+// @Summary      Get an import job
+// @Description  Retrieves the status and the report of a job importing a large file. The report is updated while the
+// @Description  job runs, its imported rows are committed when the job succeeds
+// @Tags         tasks
+// @Produce      json
+// @Param        id   path      string  true  "Import job ID"
+// @Success      200  {object}  dto.GetImportJobResponse  "Import job"
+// @Failure      400  {object}  Problem  "Invalid import job ID"
+// @Failure      404  {object}  Problem  "Import job not found"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      500  {object}  Problem  "Unknown error occurred"
+// @Router       /tasks/import/{id} [get]
+func (h *Handler) GetImportJob(ctx *fiber.Ctx) error {
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	jobID := ctx.Params("id")
+
+	res, err := h.imports.GetImportJob(ctxWithTimeout, jobID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+// ImportFromTracker
+// @Summary      Import tasks from another tracker
+// @Description  Creates tasks from the JSON export of a Trello board, Jira issues (a search response or an array) or
+// @Description  GitHub issues (an array from the REST API or gh issue list --json). Titles, descriptions, states,
+// @Description  creation and due dates are imported. The tracker IDs are kept, so importing an export again updates the
+// @Description  tasks imported before. The report tells which status every state got and which task every item became
+// @Tags         tasks
+// @Accept       mpfd
+// @Produce      json
+// @Param        source   path      string  true   "trello, jira or github"
+// @Param        file     formData  file    true   "JSON export"
+// @Param        mapping  formData  string  false  "JSON object mapping states of the tracker to statuses, e.g. {\"In Review\":\"in_progress\"}"
+// @Param        dry_run  formData  bool    false  "Only report what the import would change"
+// @Success      200  {object}  dto.ImportFromTrackerResponse  "Report of the import"
+// @Failure      400  {object}  Problem  "Invalid form, mapping or file"
+// @Failure      404  {object}  Problem  "Unknown source"
+// @Failure      413  {object}  Problem  "File is too large"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      500  {object}  Problem  "Unknown error occurred"
+// @Router       /tasks/import/{source} [post]
+func (h *Handler) ImportFromTracker(ctx *fiber.Ctx) error {
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), importTimeout)
+	defer cancel()
+
+	source := ctx.Params("source")
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		return invalidForm(err)
+	}
+
+	dryRun := false
+	if value := ctx.FormValue("dry_run"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			return models.NewFieldError("dry_run", models.ErrInvalidBoolean)
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return invalidForm(err)
+	}
+	defer file.Close()
+
+	res, err := h.imports.ImportFromTracker(ctxWithTimeout, &dto.ImportFromTrackerRequest{
+		Source:  source,
+		Mapping: ctx.FormValue("mapping"),
+		DryRun:  dryRun,
+		Size:    fileHeader.Size,
+		File:    file,
+	})
+	if err != nil {
+		return err
+	}
+
+	report := res.Report
+	h.logger.Info(ctx.Context(), "Tasks imported from a tracker",
+		zap.String("source", report.Source),
+		zap.Uint64("created", report.Created),
+		zap.Uint64("updated", report.Updated),
+		zap.Uint64("unchanged", report.Unchanged),
+		zap.Uint64("skipped", report.Skipped),
+		zap.Bool("dry_run", report.DryRun),
+	)
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"skillsrock-test-task/internal/models"
+	"skillsrock-test-task/pkg/logger"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	MIMEApplicationProblemJSON = "application/problem+json"
+
+	// problemTypeBase prefixes the codes to form the type URIs, they identify problems and are not links.
+	problemTypeBase = "tag:skillsrock-test-task,2025:problems/"
+
+	codeInternal = "internal_error"
+	codeTimeout  = "timeout"
+)
+
+// Problem is an RFC 7807 problem details object, the body of every error response. Code is the stable,
+// machine-readable part of Type, Instance is the ID of the request.
+type Problem struct {
+	Type     string         `json:"type"`
+	Title    string         `json:"title"`
+	Status   int            `json:"status"`
+	Detail   string         `json:"detail,omitempty"`
+	Instance string         `json:"instance,omitempty"`
+	Code     string         `json:"code"`
+	Errors   []ProblemField `json:"errors,omitempty"`
+}
+
+// ProblemField tells why a field of the request is invalid.
+type ProblemField struct {
+	Field  string `json:"field"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+var kindStatuses = map[models.ErrorKind]int{
+	models.KindInvalid:             fiber.StatusBadRequest,
+	models.KindNotFound:            fiber.StatusNotFound,
+	models.KindConflict:            fiber.StatusConflict,
+	models.KindUnprocessable:       fiber.StatusUnprocessableEntity,
+	models.KindTooLarge:            fiber.StatusRequestEntityTooLarge,
+	models.KindUnsupported:         fiber.StatusUnsupportedMediaType,
+	models.KindRangeNotSatisfiable: fiber.StatusRequestedRangeNotSatisfiable,
+	models.KindUnauthorized:        fiber.StatusUnauthorized,
+	models.KindUnavailable:         fiber.StatusServiceUnavailable,
+	models.KindTooManyRequests:     fiber.StatusTooManyRequests,
+}
+
+// ErrorHandler renders the errors returned by handlers and middleware as problem details. Errors of the
+// models package keep their message, unknown errors are logged and reported without details.
+func ErrorHandler(log logger.Logger) fiber.ErrorHandler {
+	return func(ctx *fiber.Ctx, err error) error {
+		problem := newProblem(err)
+		if problem.Code == codeInternal {
+			log.Error(ctx.Context(), "Unknown error occurred while handling the request",
+				zap.String("method", ctx.Method()),
+				zap.String("route", ctx.Route().Path),
+				zap.Error(err),
+			)
+		}
+
+		if requestID, ok := ctx.Locals(logger.RequestIDKey{}).(string); ok {
+			problem.Instance = requestID
+		}
+
+		return ctx.Status(problem.Status).JSON(problem, MIMEApplicationProblemJSON)
+	}
+}
+
+func newProblem(err error) *Problem {
+	var (
+		validationErr *models.ValidationError
+		modelErr      *models.Error
+		fiberErr      *fiber.Error
+	)
+
+	switch {
+	case errors.As(err, &validationErr):
+		problem := problemOf(models.ErrValidation, fiber.StatusBadRequest, err.Error())
+		for _, field := range validationErr.Fields {
+			problem.Errors = append(problem.Errors, ProblemField{
+				Field:  field.Field,
+				Code:   field.Err.Code,
+				Detail: field.Err.Error(),
+			})
+		}
+		return problem
+	case errors.As(err, &modelErr):
+		status, ok := kindStatuses[modelErr.Kind]
+		if !ok {
+			status = fiber.StatusInternalServerError
+		}
+		return problemOf(modelErr, status, err.Error())
+	case errors.As(err, &fiberErr):
+		// Errors of fiber itself, e.g. a route that does not exist or a body over the limit.
+		return &Problem{
+			Type:   problemTypeBase + statusCode(fiberErr.Code),
+			Title:  http.StatusText(fiberErr.Code),
+			Status: fiberErr.Code,
+			Detail: fiberErr.Message,
+			Code:   statusCode(fiberErr.Code),
+		}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &Problem{
+			Type:   problemTypeBase + codeTimeout,
+			Title:  http.StatusText(fiber.StatusGatewayTimeout),
+			Status: fiber.StatusGatewayTimeout,
+			Detail: "request timed out",
+			Code:   codeTimeout,
+		}
+	default:
+		return &Problem{
+			Type:   problemTypeBase + codeInternal,
+			Title:  http.StatusText(fiber.StatusInternalServerError),
+			Status: fiber.StatusInternalServerError,
+			Detail: "unknown error occurred",
+			Code:   codeInternal,
+		}
+	}
+}
+
+// problemOf describes an error of the models package. The title is the message of the error without the
+// details a wrapped error adds to the detail.
+func problemOf(err *models.Error, status int, detail string) *Problem {
+	return &Problem{
+		Type:   problemTypeBase + err.Code,
+		Title:  strings.ToUpper(err.Message[:1]) + err.Message[1:],
+		Status: status,
+		Detail: detail,
+		Code:   err.Code,
+	}
+}
+
+// statusCode turns a status into a code, e.g. 405 into "method_not_allowed".
+func statusCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
+
+// invalidBody keeps the reason a body could not be parsed, e.g. a JSON syntax error, in the detail.
+func invalidBody(err error) error {
+	return fmt.Errorf("%w: %v", models.ErrInvalidBody, err)
+}
+
+func invalidForm(err error) error {
+	return fmt.Errorf("%w: %v", models.ErrInvalidForm, err)
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"skillsrock-test-task/internal/dto"
+	"skillsrock-test-task/internal/models"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	HeaderLastEventID = "Last-Event-ID"
+
+	streamHeartbeatInterval = 15 * time.Second
+	streamRetry             = 3 * time.Second
+)
+
+// StreamTasks
+// @Summary      Stream task events
+// @Description  Pushes task.created, task.updated, task.status_changed and task.deleted events as Server-Sent Events.
+// @Description  The id of every event can be sent back in the Last-Event-ID header to resume after a reconnect,
+// @Description  a "reset" event tells that the missed events are not available anymore and the tasks should be reloaded.
+// @Tags         tasks
+// @Produce      text/event-stream
+// @Param        status         query   string  false  "Only events of tasks with this status, status changes match the previous status too"
+// @Param        owner          query   string  false  "Only events of tasks of this owner"
+// @Param        Last-Event-ID  header  string  false  "ID of the last received event"
+// @Success      200  {string}  string  "Event stream"
+// @Failure      400  {object}  Problem  "Invalid status"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      503  {object}  Problem  "Server is shutting down"
+// @Router       /tasks/stream [get]
+func (h *Handler) StreamTasks(ctx *fiber.Ctx) error {
+	streamCtx, cancel := context.WithCancel(context.Background())
+
+	stream, err := h.stream.Subscribe(streamCtx, &dto.StreamTasksRequest{
+		Status:      ctx.Query("status"),
+		Owner:       ctx.Query("owner"),
+		LastEventID: ctx.Get(HeaderLastEventID),
+	})
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	ctx.Set(fiber.HeaderContentType, "text/event-stream")
+	ctx.Set(fiber.HeaderCacheControl, "no-cache")
+	ctx.Set(fiber.HeaderConnection, "keep-alive")
+	ctx.Set("X-Accel-Buffering", "no")
+
+	// The stream writer runs after the handler returned, it must not touch the fiber context.
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+
+		if stream.Reset {
+			w.WriteString("event: reset\ndata: {}\n\n")
+		}
+		for _, event := range stream.Replay {
+			writeStreamEvent(w, event)
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(streamHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case event, ok := <-stream.Events:
+				if !ok {
+					return
+				}
+				writeStreamEvent(w, event)
+			case <-heartbeat.C:
+				w.WriteString(": heartbeat\n\n")
+			}
+
+			// A failed flush means that the client has gone away.
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+func writeStreamEvent(w *bufio.Writer, event *models.OutboxEvent) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\n", event.ID, event.Type)
+	for _, line := range bytes.Split(event.Payload, []byte("\n")) {
+		w.WriteString("data: ")
+		w.Write(line)
+		w.WriteString("\n")
+	}
+	w.WriteString("\n")
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"context"
+	"skillsrock-test-task/internal/delivery/middleware"
+	"skillsrock-test-task/internal/dto"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// CreateWebhook
+// @Summary      Subscribe to task events
+// @Description  Registers a webhook for the given events: task.created, task.updated, task.status_changed, task.deleted.
+// @Description  Deliveries are signed with HMAC-SHA256 over "<X-Webhook-Timestamp>.<body>" and sent in X-Webhook-Signature.
+// @Description  The secret is generated when omitted and is only returned in this response.
+// @Description  The url must resolve to public addresses, loopback, link-local and private networks are rejected.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        webhook  body  dto.CreateWebhookRequest  true  "Webhook"
+// @Success      201  {object}  dto.CreateWebhookResponse
+// @Failure      400  {object}  Problem  "Invalid url or events"
+// @Failure      401  {object}  Problem  "Missing or invalid access token"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      500  {object}  Problem  "Unknown error occurred"
+// @Router       /webhooks [post]
+func (h *Handler) CreateWebhook(ctx *fiber.Ctx) error {
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	var webhook dto.CreateWebhookRequest
+	if err := h.parseJSON(ctx, &webhook); err != nil {
+		return err
+	}
+
+	user, _ := ctx.Locals(middleware.UserKey).(string)
+
+	res, err := h.webhooks.CreateWebhook(ctxWithTimeout, user, &webhook)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info(ctx.Context(), "Webhook created", zap.Uint64("id", res.ID))
+
+	return ctx.Status(fiber.StatusCreated).JSON(res)
+}
+
+// GetWebhooks
+// @Summary      Get webhooks
+// @Description  Retrieves the webhook subscriptions of the user
+// @Tags         webhooks
+// @Produce      json
+// @Success      200  {object}  dto.GetWebhooksResponse  "List of webhooks"
+// @Failure      401  {object}  Problem  "Missing or invalid access token"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      500  {object}  Problem  "Unknown error occurred"
+// @Router       /webhooks [get]
+func (h *Handler) GetWebhooks(ctx *fiber.Ctx) error {
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	user, _ := ctx.Locals(middleware.UserKey).(string)
+
+	res, err := h.webhooks.GetWebhooks(ctxWithTimeout, user)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+// DeleteWebhook
+// @Summary      Delete a webhook
+// @Description  Deletes the subscription together with its delivery log
+// @Tags         webhooks
+// @Produce      json
+// @Param        id   path  string  true  "Webhook ID"
+// @Success      200  {string}  string  "Deleted successfully"
+// @Failure      400  {object}  Problem  "Invalid webhook ID"
+// @Failure      404  {object}  Problem  "Webhook not found"
+// @Failure      401  {object}  Problem  "Missing or invalid access token"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      500  {object}  Problem  "Unknown error occurred"
+// @Router       /webhooks/{id} [delete]
+func (h *Handler) DeleteWebhook(ctx *fiber.Ctx) error {
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	user, _ := ctx.Locals(middleware.UserKey).(string)
+	webhookID := ctx.Params("id")
+
+	if err := h.webhooks.DeleteWebhook(ctxWithTimeout, user, webhookID); err != nil {
+		return err
+	}
+
+	h.logger.Info(ctx.Context(), "Webhook deleted", zap.String("id", webhookID))
+
+	return ctx.SendStatus(fiber.StatusOK)
+}
+
+// GetDeliveries
+// @Summary      Get webhook deliveries
+// @Description  Retrieves the delivery log of a webhook, newest first, using cursor pagination
+// @Tags         webhooks
+// @Produce      json
+// @Param        id      path   string  true   "Webhook ID"
+// @Param        cursor  query  string  false  "Cursor returned as next_cursor by the previous page"
+// @Param        limit   query  string  false  "Items per page"
+// @Success      200  {object}  dto.GetDeliveriesResponse  "List of deliveries"
+// @Failure      400  {object}  Problem  "Invalid webhook ID or pagination parameters"
+// @Failure      404  {object}  Problem  "Webhook not found"
+// @Failure      401  {object}  Problem  "Missing or invalid access token"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      500  {object}  Problem  "Unknown error occurred"
+// @Router       /webhooks/{id}/deliveries [get]
+func (h *Handler) GetDeliveries(ctx *fiber.Ctx) error {
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	user, _ := ctx.Locals(middleware.UserKey).(string)
+	webhookID := ctx.Params("id")
+	cursor := ctx.Query("cursor")
+	limit := ctx.Query("limit")
+
+	res, err := h.webhooks.GetDeliveries(ctxWithTimeout, user, webhookID, cursor, limit)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
+}
+
+// Redeliver
+// @Summary      Redeliver a webhook event
+// @Description  Queues a new delivery with the payload of an existing one, e.g. after it ended up dead
+// @Tags         webhooks
+// @Produce      json
+// @Param        id          path  string  true  "Webhook ID"
+// @Param        deliveryID  path  string  true  "Delivery ID"
+// @Success      202  {object}  dto.RedeliverResponse
+// @Failure      400  {object}  Problem  "Invalid IDs"
+// @Failure      404  {object}  Problem  "Delivery not found"
+// @Failure      401  {object}  Problem  "Missing or invalid access token"
+// @Failure      429  {object}  Problem  "Too many requests"
+// @Failure      500  {object}  Problem  "Unknown error occurred"
+// @Router       /webhooks/{id}/deliveries/{deliveryID}/redeliver [post]
+func (h *Handler) Redeliver(ctx *fiber.Ctx) error {
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	user, _ := ctx.Locals(middleware.UserKey).(string)
+	webhookID := ctx.Params("id")
+	deliveryID := ctx.Params("deliveryID")
+
+	res, err := h.webhooks.Redeliver(ctxWithTimeout, user, webhookID, deliveryID)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info(ctx.Context(), "Webhook delivery queued", zap.String("webhook_id", webhookID), zap.Uint64("id", res.ID))
+
+	return ctx.Status(fiber.StatusAccepted).JSON(res)
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"skillsrock-test-task/internal/models"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// UserKey is the fiber.Ctx local holding the name of the authenticated user.
+const UserKey = "user"
+
+type Authenticator interface {
+	Authenticate(token string) (string, error)
+}
+
+// Auth requires a bearer token in the Authorization header. Browsers can not set headers on
+// WebSocket and EventSource requests, so the token is also accepted in the access_token query parameter.
+func Auth(auth Authenticator) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		token, ok := strings.CutPrefix(ctx.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !ok {
+			token = ctx.Query("access_token")
+		}
+
+		user, err := auth.Authenticate(token)
+		if err != nil {
+			ctx.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+			return models.ErrUnauthorized
+		}
+
+		ctx.Locals(UserKey, user)
+
+		return ctx.Next()
+	}
+}
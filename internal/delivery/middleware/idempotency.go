@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"skillsrock-test-task/internal/models"
+	"skillsrock-test-task/pkg/logger"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	idempotencyStoreTimeout  = 1 * time.Second
+)
+
+type IdempotencyService interface {
+	Begin(ctx context.Context, scope, key, fingerprint string) (*models.IdempotencyRecord, error)
+	Complete(ctx context.Context, scope, key string, statusCode int, contentType string, body []byte) error
+	Abort(ctx context.Context, scope, key string) error
+}
+
+// Idempotency replays the stored response for requests repeated by a client with the same Idempotency-Key header.
+// Keys are scoped to the client, so clients that pick the same key do not get each other's responses.
+// Requests without the header pass through unchanged.
+func Idempotency(service IdempotencyService, client func(ctx *fiber.Ctx) string, logger logger.Logger) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		key := ctx.Get(HeaderIdempotencyKey)
+		if key == "" {
+			return ctx.Next()
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			return models.ErrInvalidIdempotencyKey
+		}
+
+		scope := client(ctx) + " " + ctx.Method() + " " + ctx.Route().Path
+		fingerprint := requestFingerprint(ctx)
+
+		storeCtx, cancel := context.WithTimeout(context.Background(), idempotencyStoreTimeout)
+		defer cancel()
+
+		record, err := service.Begin(storeCtx, scope, key, fingerprint)
+		if err != nil {
+			return err
+		}
+
+		if record != nil {
+			ctx.Set(HeaderIdempotentReplayed, "true")
+			ctx.Set(fiber.HeaderContentType, record.ContentType)
+			return ctx.Status(record.StatusCode).Send(record.Body)
+		}
+
+		err = ctx.Next()
+
+		storeCtx, cancel = context.WithTimeout(context.Background(), idempotencyStoreTimeout)
+		defer cancel()
+
+		// Only successful responses are stored, any failure frees the key so that the client can retry.
+		status := ctx.Response().StatusCode()
+		if err != nil || status < fiber.StatusOK || status >= fiber.StatusMultipleChoices {
+			if abortErr := service.Abort(storeCtx, scope, key); abortErr != nil {
+				logger.Error(ctx.Context(), "Failed to release the idempotency key", zap.Error(abortErr))
+			}
+			return err
+		}
+
+		body := append([]byte(nil), ctx.Response().Body()...)
+		contentType := string(ctx.Response().Header.ContentType())
+
+		if err := service.Complete(storeCtx, scope, key, status, contentType, body); err != nil {
+			logger.Error(ctx.Context(), "Failed to store the idempotent response", zap.Error(err))
+		}
+
+		return nil
+	}
+}
+
+func requestFingerprint(ctx *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(ctx.Method()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(ctx.OriginalURL()))
+	hash.Write([]byte{0})
+	hash.Write(ctx.Body())
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"skillsrock-test-task/internal/models"
+	"skillsrock-test-task/pkg/logger"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	RateLimitByKey  = "key"
+	RateLimitByUser = "user"
+	RateLimitByIP   = "ip"
+)
+
+type RateLimiter interface {
+	Take(ctx context.Context, group string, write bool, client string) (*models.RateLimitResult, error)
+}
+
+// RateLimitClient names the client of a request by its API key or its user when it sends a valid token,
+// and by its IP otherwise. Invalid tokens are not trusted, a client could make up a new one for every request.
+func RateLimitClient(auth Authenticator, by string) func(ctx *fiber.Ctx) string {
+	return func(ctx *fiber.Ctx) string {
+		token, ok := strings.CutPrefix(ctx.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !ok {
+			token = ctx.Query("access_token")
+		}
+
+		if by != RateLimitByIP && token != "" {
+			if user, err := auth.Authenticate(token); err == nil {
+				if by == RateLimitByUser {
+					return "user:" + user
+				}
+				sum := sha256.Sum256([]byte(token))
+				return "key:" + hex.EncodeToString(sum[:])
+			}
+		}
+
+		return "ip:" + ctx.IP()
+	}
+}
+
+// RateLimit takes a token from the bucket of the client for the group, from its read budget for GET and HEAD
+// requests and from its write budget otherwise. The budget is reported in the RateLimit headers, a request over
+// it is rejected with Retry-After. Requests are let through when the limiter fails, it should not take the API down.
+func RateLimit(limiter RateLimiter, client func(ctx *fiber.Ctx) string, group string, logger logger.Logger) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		write := ctx.Method() != fiber.MethodGet && ctx.Method() != fiber.MethodHead
+
+		res, err := limiter.Take(ctx.Context(), group, write, client(ctx))
+		if err != nil {
+			logger.Error(ctx.Context(), "Failed to check the rate limit", zap.String("group", group), zap.Error(err))
+			return ctx.Next()
+		}
+
+		ctx.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", res.Limit.Burst, seconds(res.Limit.Period)))
+		ctx.Set("RateLimit-Limit", strconv.Itoa(res.Limit.Burst))
+		ctx.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		ctx.Set("RateLimit-Reset", strconv.Itoa(seconds(res.Reset)))
+
+		if !res.Allowed {
+			ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(max(seconds(res.RetryAfter), 1)))
+			return models.ErrRateLimited
+		}
+
+		return ctx.Next()
+	}
+}
+
+// seconds rounds up, a client waiting for the rounded time is sure to find the tokens.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,144 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"skillsrock-test-task/internal/delivery/http/v1/handler"
+	"skillsrock-test-task/internal/delivery/middleware"
+	"skillsrock-test-task/internal/models"
+	"skillsrock-test-task/internal/repository"
+	"skillsrock-test-task/internal/service"
+	"skillsrock-test-task/pkg/logger"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const testToken = "test-token"
+
+type fakeAuthenticator struct{}
+
+func (fakeAuthenticator) Authenticate(token string) (string, error) {
+	if token != testToken {
+		return "", models.ErrUnauthorized
+	}
+	return "tester", nil
+}
+
+type failingRateLimiter struct{}
+
+func (failingRateLimiter) Take(context.Context, string, bool, string) (*models.RateLimitResult, error) {
+	return nil, errors.New("rate limits are unavailable")
+}
+
+func newRateLimitedApp(t *testing.T, limiter middleware.RateLimiter) *fiber.App {
+	t.Helper()
+
+	log := logger.NewNop()
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler(log)})
+
+	limit := middleware.RateLimit(limiter, middleware.RateLimitClient(fakeAuthenticator{}, middleware.RateLimitByKey), "tasks", log)
+	ok := func(ctx *fiber.Ctx) error { return ctx.SendStatus(fiber.StatusOK) }
+	app.Get("/tasks", limit, ok)
+	app.Post("/tasks", limit, ok)
+
+	return app
+}
+
+func send(t *testing.T, app *fiber.App, method, token string) *http.Response {
+	t.Helper()
+
+	req := httptest.NewRequest(method, "/tasks", nil)
+	if token != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	}
+
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("%s /tasks: %v", method, err)
+	}
+	res.Body.Close()
+
+	return res
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	limiter, err := service.NewRateLimitService(repository.NewMemoryRateLimitRepository(), "2/1m", "1/1m", "")
+	if err != nil {
+		t.Fatalf("NewRateLimitService: %v", err)
+	}
+	app := newRateLimitedApp(t, limiter)
+
+	steps := []struct {
+		name       string
+		method     string
+		token      string
+		status     int
+		limit      string
+		remaining  string
+		retryAfter string
+	}{
+		{name: "first read", method: http.MethodGet, status: http.StatusOK, limit: "2", remaining: "1"},
+		{name: "last read", method: http.MethodGet, status: http.StatusOK, limit: "2", remaining: "0"},
+		// A token comes back every 30 seconds.
+		{name: "read over the limit", method: http.MethodGet, status: http.StatusTooManyRequests, limit: "2", remaining: "0", retryAfter: "30"},
+		{name: "write has its own budget", method: http.MethodPost, status: http.StatusOK, limit: "1", remaining: "0"},
+		{name: "write over the limit", method: http.MethodPost, status: http.StatusTooManyRequests, limit: "1", remaining: "0", retryAfter: "60"},
+		{name: "API key has its own budget", method: http.MethodGet, token: testToken, status: http.StatusOK, limit: "2", remaining: "1"},
+		// An invalid token is not trusted, the client is named by its IP.
+		{name: "invalid token counts as the IP", method: http.MethodGet, token: "made-up", status: http.StatusTooManyRequests, limit: "2", remaining: "0", retryAfter: "30"},
+	}
+	for _, step := range steps {
+		res := send(t, app, step.method, step.token)
+
+		if res.StatusCode != step.status {
+			t.Fatalf("%s: status = %d, want %d", step.name, res.StatusCode, step.status)
+		}
+		headers := map[string]string{
+			"RateLimit-Policy":    step.limit + ";w=60",
+			"RateLimit-Limit":     step.limit,
+			"RateLimit-Remaining": step.remaining,
+			"Retry-After":         step.retryAfter,
+		}
+		for name, want := range headers {
+			if got := res.Header.Get(name); got != want {
+				t.Errorf("%s: %s = %q, want %q", step.name, name, got, want)
+			}
+		}
+		if res.Header.Get("RateLimit-Reset") == "" {
+			t.Errorf("%s: RateLimit-Reset is missing", step.name)
+		}
+	}
+}
+
+func TestRateLimitRetryAfterRoundsUp(t *testing.T) {
+	limiter, err := service.NewRateLimitService(repository.NewMemoryRateLimitRepository(), "10/1s", "1/1m", "")
+	if err != nil {
+		t.Fatalf("NewRateLimitService: %v", err)
+	}
+	app := newRateLimitedApp(t, limiter)
+
+	for range 10 {
+		send(t, app, http.MethodGet, "")
+	}
+
+	// The next token is 100ms away, a client is never told to retry right away.
+	res := send(t, app, http.MethodGet, "")
+	if res.StatusCode != http.StatusTooManyRequests || res.Header.Get("Retry-After") != "1" {
+		t.Fatalf("status = %d with Retry-After %q, want 429 with 1", res.StatusCode, res.Header.Get("Retry-After"))
+	}
+}
+
+func TestRateLimitFailingLimiter(t *testing.T) {
+	app := newRateLimitedApp(t, failingRateLimiter{})
+
+	res := send(t, app, http.MethodGet, "")
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want the request let through", res.StatusCode)
+	}
+	if res.Header.Get("RateLimit-Limit") != "" {
+		t.Fatalf("RateLimit-Limit = %q, want no budget reported", res.Header.Get("RateLimit-Limit"))
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// ReadPrimaryKey is the fiber.Ctx local set when the client wrote within the read-your-writes window.
+	ReadPrimaryKey = "readPrimary"
+	// ReadOnlyKey is the fiber.Ctx local a handler sets when a request it serves is not a write by its method,
+	// e.g. a GraphQL query sent with POST.
+	ReadOnlyKey = "readOnly"
+
+	// LastWriteCookie and HeaderLastWrite carry the time of the last write of a client in Unix milliseconds.
+	// Browsers send the cookie back, other clients echo the header of the response of the write.
+	LastWriteCookie = "last_write"
+	HeaderLastWrite = "X-Last-Write"
+)
+
+// ReadYourWrites tells the handlers which clients wrote within the window, their reads go to the primary until
+// the replicas have caught up with the write. A successful request with a method other than GET, HEAD and
+// OPTIONS is a write, unless its handler set ReadOnlyKey.
+func ReadYourWrites(window time.Duration) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		now := time.Now()
+
+		if lastWrite, ok := clientLastWrite(ctx); ok && now.Sub(lastWrite) < window {
+			ctx.Locals(ReadPrimaryKey, true)
+		}
+
+		err := ctx.Next()
+		if err != nil || ctx.Response().StatusCode() >= fiber.StatusBadRequest {
+			return err
+		}
+
+		switch ctx.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return nil
+		}
+		if readOnly, _ := ctx.Locals(ReadOnlyKey).(bool); readOnly {
+			return nil
+		}
+
+		value := strconv.FormatInt(now.UnixMilli(), 10)
+		ctx.Set(HeaderLastWrite, value)
+		ctx.Cookie(&fiber.Cookie{
+			Name:     LastWriteCookie,
+			Value:    value,
+			Path:     "/",
+			Expires:  now.Add(window),
+			Secure:   ctx.Protocol() == "https",
+			HTTPOnly: true,
+			SameSite: fiber.CookieSameSiteLaxMode,
+		})
+
+		return nil
+	}
+}
+
+// clientLastWrite reads the time of the last write from the header, or from the cookie without one.
+func clientLastWrite(ctx *fiber.Ctx) (time.Time, bool) {
+	value := ctx.Get(HeaderLastWrite)
+	if value == "" {
+		value = ctx.Cookies(LastWriteCookie)
+	}
+
+	millis, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.UnixMilli(millis), true
+}
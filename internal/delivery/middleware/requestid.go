@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"skillsrock-test-task/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLength bounds the IDs taken from clients, they end up in every log line of the request.
+const maxRequestIDLength = 128
+
+// RequestID takes the request ID sent by the client or generates one, and returns it in the response headers.
+// The logger adds it to the entries logged with the context of the request.
+func RequestID() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		requestID := ctx.Get(HeaderRequestID)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+		}
+
+		ctx.Locals(logger.RequestIDKey{}, requestID)
+		ctx.Set(HeaderRequestID, requestID)
+
+		return ctx.Next()
+	}
+}
@@ -1,32 +1,103 @@
 package routes
 
 import (
+	"skillsrock-test-task/internal/delivery/graphql"
 	"skillsrock-test-task/internal/delivery/http/v1/handler"
 	"skillsrock-test-task/internal/delivery/middleware"
 	"skillsrock-test-task/pkg/logger"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/helmet"
 	"github.com/gofiber/swagger"
 )
 
-func RegistrateRoutes(app *fiber.App, logger logger.Logger, h *handler.Handler) {
-	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowMethods: "GET,POST,PUT,DELETE",
-		AllowHeaders: "Content-Type,Authorization",
-	}))
+// Options configures the middleware shared by the routes.
+type Options struct {
+	// CORS is nil when cross-origin requests are not allowed.
+	CORS     *cors.Config
+	Security helmet.Config
+	// DocsContentSecurityPolicy replaces the policy of the API for the Swagger UI, which runs inline scripts.
+	DocsContentSecurityPolicy string
+	// Docs serves the Swagger UI and the OpenAPI document.
+	Docs bool
+	// Board serves the board WebSocket.
+	Board bool
+	// RateLimiter is nil when requests are not rate limited.
+	RateLimiter middleware.RateLimiter
+	RateLimitBy string
+	// ReadYourWrites is the time a client reads from the primary after a write, 0 when there are no replicas.
+	ReadYourWrites time.Duration
+}
+
+func RegistrateRoutes(app *fiber.App, logger logger.Logger, h *handler.Handler, gql *graphql.Handler, idempotency middleware.IdempotencyService, auth middleware.Authenticator, opts Options) {
+	app.Use(helmet.New(opts.Security))
+	if opts.CORS != nil {
+		app.Use(cors.New(*opts.CORS))
+	}
+	app.Use(middleware.RequestID())
+
+	// limit applies the budgets of a route group, every route is in one.
+	limit := func(group string) fiber.Handler {
+		if opts.RateLimiter == nil {
+			return func(ctx *fiber.Ctx) error { return ctx.Next() }
+		}
+		return middleware.RateLimit(opts.RateLimiter, middleware.RateLimitClient(auth, opts.RateLimitBy), group, logger)
+	}
 
 	api := app.Group("/api")
 	v1 := api.Group("/v1")
+	if opts.ReadYourWrites > 0 {
+		v1.Use(middleware.ReadYourWrites(opts.ReadYourWrites))
+	}
+
+	v1.Get("/tasks", middleware.LoggingMiddleware(logger), limit("tasks"), h.GetTasks)
+	v1.Get("/tasks/stream", middleware.LoggingMiddleware(logger), limit("tasks"), h.StreamTasks)
+	v1.Get("/tasks/export", middleware.LoggingMiddleware(logger), limit("tasks"), h.ExportTasks)
+	v1.Get("/tasks/import/:id", middleware.LoggingMiddleware(logger), limit("imports"), h.GetImportJob)
+	v1.Get("/tasks/:id", middleware.LoggingMiddleware(logger), limit("tasks"), h.GetTaskByID)
+	v1.Post("/tasks", middleware.LoggingMiddleware(logger), limit("tasks"), middleware.Idempotency(idempotency, middleware.RateLimitClient(auth, middleware.RateLimitByKey), logger), h.CreateTask)
+	v1.Post("/tasks/bulk", middleware.LoggingMiddleware(logger), limit("tasks"), h.BulkTasks)
+	v1.Post("/tasks/import", middleware.LoggingMiddleware(logger), limit("imports"), h.ImportTasks)
+	v1.Post("/tasks/import/:source", middleware.LoggingMiddleware(logger), limit("imports"), h.ImportFromTracker)
+	v1.Put("/tasks/:id", middleware.LoggingMiddleware(logger), limit("tasks"), h.UpdateTask)
+	v1.Delete("/tasks/:id", middleware.LoggingMiddleware(logger), limit("tasks"), h.DeleteTask)
+
+	v1.Get("/tasks/:id/comments", middleware.LoggingMiddleware(logger), limit("comments"), h.GetComments)
+	v1.Post("/tasks/:id/comments", middleware.LoggingMiddleware(logger), limit("comments"), h.CreateComment)
+	v1.Put("/tasks/:id/comments/:commentID", middleware.LoggingMiddleware(logger), limit("comments"), h.UpdateComment)
+	v1.Delete("/tasks/:id/comments/:commentID", middleware.LoggingMiddleware(logger), limit("comments"), h.DeleteComment)
+
+	v1.Get("/tasks/:id/attachments", middleware.LoggingMiddleware(logger), limit("attachments"), h.GetAttachments)
+	v1.Post("/tasks/:id/attachments", middleware.LoggingMiddleware(logger), limit("attachments"), h.UploadAttachment)
+	v1.Get("/tasks/:id/attachments/:attachmentID", middleware.LoggingMiddleware(logger), limit("attachments"), h.DownloadAttachment)
+	v1.Delete("/tasks/:id/attachments/:attachmentID", middleware.LoggingMiddleware(logger), limit("attachments"), h.DeleteAttachment)
+
+	if opts.Board {
+		v1.Get("/board", middleware.LoggingMiddleware(logger), limit("board"), h.UpgradeBoard, middleware.Auth(auth), h.Board())
+	}
+	v1.Get("/calendar.ics", middleware.LoggingMiddleware(logger), limit("calendar"), middleware.Auth(auth), h.Calendar)
+
+	v1.Post("/graphql", middleware.LoggingMiddleware(logger), limit("graphql"), gql.Query)
+	v1.Get("/graphql", middleware.LoggingMiddleware(logger), limit("graphql"), gql.UpgradeSubscriptions, middleware.Auth(auth), gql.Subscriptions())
+
+	v1.Get("/webhooks", middleware.LoggingMiddleware(logger), limit("webhooks"), middleware.Auth(auth), h.GetWebhooks)
+	v1.Post("/webhooks", middleware.LoggingMiddleware(logger), limit("webhooks"), middleware.Auth(auth), h.CreateWebhook)
+	v1.Delete("/webhooks/:id", middleware.LoggingMiddleware(logger), limit("webhooks"), middleware.Auth(auth), h.DeleteWebhook)
+	v1.Get("/webhooks/:id/deliveries", middleware.LoggingMiddleware(logger), limit("webhooks"), middleware.Auth(auth), h.GetDeliveries)
+	v1.Post("/webhooks/:id/deliveries/:deliveryID/redeliver", middleware.LoggingMiddleware(logger), limit("webhooks"), middleware.Auth(auth), h.Redeliver)
+
+	if !opts.Docs {
+		return
+	}
 
-	v1.Get("/tasks", middleware.LoggingMiddleware(logger), h.GetTasks)
-	v1.Get("/tasks/:id", middleware.LoggingMiddleware(logger), h.GetTaskByID)
-	v1.Post("/tasks", middleware.LoggingMiddleware(logger), h.CreateTask)
-	v1.Put("/tasks/:id", middleware.LoggingMiddleware(logger), h.UpdateTask)
-	v1.Delete("/tasks/:id", middleware.LoggingMiddleware(logger), h.DeleteTask)
+	docsSecurity := func(ctx *fiber.Ctx) error {
+		ctx.Set(fiber.HeaderContentSecurityPolicy, opts.DocsContentSecurityPolicy)
+		return ctx.Next()
+	}
 
-	app.Get("/swagger/*", swagger.New(swagger.Config{
+	app.Get("/swagger/*", docsSecurity, swagger.New(swagger.Config{
 		URL: "/docs/swagger.json",
 	}))
 
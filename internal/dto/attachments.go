@@ -0,0 +1,21 @@
+package dto
+
+import (
+	"io"
+	"skillsrock-test-task/internal/models"
+)
+
+type UploadAttachmentRequest struct {
+	Filename string
+	Uploader string
+	Size     int64
+	File     io.Reader
+}
+
+type UploadAttachmentResponse struct {
+	Attachment *models.Attachment `json:"attachment"`
+}
+
+type GetAttachmentsResponse struct {
+	Attachments []*models.Attachment `json:"attachments"`
+}
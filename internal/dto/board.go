@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// BoardRequest is a message sent by a board client over the WebSocket, Ref is echoed in the reply.
+type BoardRequest struct {
+	Type         string     `json:"type"`
+	Ref          string     `json:"ref,omitempty"`
+	Subscription string     `json:"subscription,omitempty"`
+	Status       string     `json:"status,omitempty"`
+	Owner        string     `json:"owner,omitempty"`
+	LastEventID  string     `json:"last_event_id,omitempty"`
+	TaskID       uint64     `json:"task_id,omitempty"`
+	Title        string     `json:"title,omitempty"`
+	Description  string     `json:"description,omitempty"`
+	DueAt        *time.Time `json:"due_at,omitempty"`
+}
+
+// BoardMessage is a message sent by the server to a board client.
+type BoardMessage struct {
+	Type         string          `json:"type"`
+	Ref          string          `json:"ref,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	Subscription string          `json:"subscription,omitempty"`
+	ID           uint64          `json:"id,omitempty"`
+	Event        json.RawMessage `json:"event,omitempty"`
+	TaskID       uint64          `json:"task_id,omitempty"`
+	Viewers      []string        `json:"viewers,omitempty"`
+}
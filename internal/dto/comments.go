@@ -0,0 +1,26 @@
+package dto
+
+import (
+	"skillsrock-test-task/internal/models"
+	"time"
+)
+
+type CreateCommentRequest struct {
+	Author  string  `json:"author" validate:"trim,required,max=100"`
+	Body    string  `json:"body" validate:"required,max=10000"`
+	ReplyTo *uint64 `json:"reply_to,omitempty"`
+}
+
+type CreateCommentResponse struct {
+	ID        uint64    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type UpdateCommentRequest struct {
+	Body string `json:"body" validate:"required,max=10000"`
+}
+
+type GetCommentsResponse struct {
+	Comments   []*models.Comment `json:"comments"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
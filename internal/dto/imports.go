@@ -0,0 +1,40 @@
+package dto
+
+import (
+	"io"
+	"skillsrock-test-task/internal/models"
+)
+
+type ImportTasksRequest struct {
+	Filename string
+	Format   string
+	// Mapping is a JSON object from task fields to the columns of the file, e.g. {"title": "Summary"}.
+	Mapping string
+	DryRun  bool
+	Size    int64
+	File    io.Reader
+}
+
+// ImportTasksResponse has the report of a file imported right away or the job importing a large file.
+type ImportTasksResponse struct {
+	Report *models.ImportReport `json:"report,omitempty"`
+	Job    *models.ImportJob    `json:"job,omitempty"`
+}
+
+type GetImportJobResponse struct {
+	Job *models.ImportJob `json:"job"`
+}
+
+type ImportFromTrackerRequest struct {
+	// Source is trello, jira or github.
+	Source string
+	// Mapping is a JSON object from states of the tracker to statuses, e.g. {"In Review": "in_progress"}.
+	Mapping string
+	DryRun  bool
+	Size    int64
+	File    io.Reader
+}
+
+type ImportFromTrackerResponse struct {
+	Report *models.ExternalImportReport `json:"report"`
+}
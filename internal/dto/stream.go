@@ -0,0 +1,15 @@
+package dto
+
+import "skillsrock-test-task/internal/models"
+
+type StreamTasksRequest struct {
+	Status      string
+	Owner       string
+	LastEventID string
+}
+
+type TaskStream struct {
+	Replay []*models.OutboxEvent
+	Reset  bool
+	Events <-chan *models.OutboxEvent
+}
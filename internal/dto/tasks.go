@@ -1,13 +1,16 @@
 package dto
 
 import (
+	"context"
 	"skillsrock-test-task/internal/models"
 	"time"
 )
 
 type CreateTaskRequest struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
+	Title       string     `json:"title" validate:"trim,required,max=200"`
+	Description string     `json:"description" validate:"max=10000"`
+	Owner       string     `json:"owner" validate:"trim,max=100"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
 }
 
 type CreateTaskResponse struct {
@@ -16,10 +19,12 @@ type CreateTaskResponse struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// UpdateTaskRequest replaces the fields of a task, a task updated without a due date has none.
 type UpdateTaskRequest struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Status      string `json:"status"`
+	Title       string     `json:"title" validate:"trim,required,max=200"`
+	Description string     `json:"description" validate:"max=10000"`
+	Status      string     `json:"status" validate:"trim,required,oneof=new in_progress done"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
 }
 
 type GetTaskByIDResponse struct {
@@ -29,3 +34,57 @@ type GetTaskByIDResponse struct {
 type GetTasksResponse struct {
 	Tasks []*models.Task `json:"tasks"`
 }
+
+type GetTasksPageResponse struct {
+	Tasks      []*models.Task `json:"tasks"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// TaskExport passes the exported tasks to fn one at a time, it stops at the first error of fn.
+type TaskExport func(ctx context.Context, fn func(task *models.Task) error) error
+
+// BulkOperation is validated for every operation type, the fields an operation type needs are checked when it is applied.
+type BulkOperation struct {
+	Op          string     `json:"op" validate:"trim,required,oneof=create update delete"`
+	ID          uint64     `json:"id,omitempty"`
+	Title       string     `json:"title,omitempty" validate:"trim,max=200"`
+	Description string     `json:"description,omitempty" validate:"max=10000"`
+	Status      string     `json:"status,omitempty" validate:"trim,oneof=new in_progress done"`
+	Owner       string     `json:"owner,omitempty" validate:"trim,max=100"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
+}
+
+type BulkTasksRequest struct {
+	Mode       string          `json:"mode" validate:"trim,oneof=atomic best_effort"`
+	Operations []BulkOperation `json:"operations"`
+}
+
+type BulkOperationResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	ID     uint64 `json:"id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type BulkTasksResponse struct {
+	Mode      string                `json:"mode"`
+	Committed bool                  `json:"committed"`
+	Results   []BulkOperationResult `json:"results"`
+}
+
+// GetCalendarRequest selects the tasks of a calendar feed, Versions are the versions the client already has.
+type GetCalendarRequest struct {
+	Owner    string
+	Status   string
+	From     string
+	To       string
+	Versions []string
+}
+
+// GetCalendarResponse has no tasks when the client already has the Version.
+type GetCalendarResponse struct {
+	Version     string
+	NotModified bool
+	Tasks       []*models.Task
+}
@@ -0,0 +1,116 @@
+package dto
+
+import (
+	"fmt"
+	"reflect"
+	"skillsrock-test-task/internal/models"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validate checks a request, a pointer to a struct, against the validate tags of its fields. Structs and slices
+// of structs in the request are checked too, their fields are named by their path, e.g. operations[1].title.
+// The rules of a tag are applied in order and the first one that fails is reported:
+//   - trim removes the leading and trailing whitespace of a string;
+//   - required wants a string that is not blank, a slice that is not empty or a pointer that is not nil;
+//   - max=N wants a string of at most N characters or a slice of at most N items;
+//   - oneof=a b c wants a string that is one of the values, an empty string is left to required.
+func Validate(req any) error {
+	var fields []models.FieldError
+	validateStruct(reflect.ValueOf(req).Elem(), "", &fields)
+
+	if len(fields) > 0 {
+		return &models.ValidationError{Fields: fields}
+	}
+
+	return nil
+}
+
+func validateStruct(v reflect.Value, prefix string, fields *[]models.FieldError) {
+	for i := range v.NumField() {
+		sf := v.Type().Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name := prefix + jsonName(sf)
+		fv := v.Field(i)
+
+		if tag := sf.Tag.Get("validate"); tag != "" {
+			if err := validateField(fv, name, tag); err != nil {
+				*fields = append(*fields, models.FieldError{Field: name, Err: err})
+				continue
+			}
+		}
+
+		switch {
+		case fv.Kind() == reflect.Struct && fv.Type().PkgPath() == v.Type().PkgPath():
+			validateStruct(fv, name+".", fields)
+		case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Struct:
+			for j := range fv.Len() {
+				validateStruct(fv.Index(j), name+"["+strconv.Itoa(j)+"].", fields)
+			}
+		}
+	}
+}
+
+func validateField(v reflect.Value, name, tag string) *models.Error {
+	for _, rule := range strings.Split(tag, ",") {
+		rule, arg, _ := strings.Cut(rule, "=")
+
+		switch rule {
+		case "trim":
+			v.SetString(strings.TrimSpace(v.String()))
+		case "required":
+			if isEmpty(v) {
+				return models.ErrRequired.WithMessage(name + " is required")
+			}
+		case "max":
+			limit, err := strconv.Atoi(arg)
+			if err != nil {
+				panic(fmt.Sprintf("dto: invalid max rule of %s: %q", name, arg))
+			}
+
+			if v.Kind() == reflect.Slice {
+				if v.Len() > limit {
+					return models.ErrTooLong.WithMessage(fmt.Sprintf("%s must have at most %d items", name, limit))
+				}
+			} else if utf8.RuneCountInString(v.String()) > limit {
+				return models.ErrTooLong.WithMessage(fmt.Sprintf("%s must be at most %d characters long", name, limit))
+			}
+		case "oneof":
+			values := strings.Fields(arg)
+			if v.String() != "" && !slices.Contains(values, v.String()) {
+				return models.ErrInvalidValue.WithMessage(fmt.Sprintf("%s must be one of %s", name, strings.Join(values, ", ")))
+			}
+		default:
+			panic(fmt.Sprintf("dto: unknown validate rule of %s: %q", name, rule))
+		}
+	}
+
+	return nil
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Pointer:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
+
+// jsonName names a field like the JSON body of the request does.
+func jsonName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return sf.Name
+	}
+	return name
+}
@@ -0,0 +1,31 @@
+package dto
+
+import (
+	"skillsrock-test-task/internal/models"
+	"time"
+)
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" validate:"trim,required,max=2048"`
+	Events []string `json:"events" validate:"required,max=16"`
+	Secret string   `json:"secret,omitempty" validate:"max=256"`
+}
+
+type CreateWebhookResponse struct {
+	ID        uint64    `json:"id"`
+	Secret    string    `json:"secret"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GetWebhooksResponse struct {
+	Webhooks []*models.Webhook `json:"webhooks"`
+}
+
+type GetDeliveriesResponse struct {
+	Deliveries []*models.WebhookDelivery `json:"deliveries"`
+	NextCursor string                    `json:"next_cursor,omitempty"`
+}
+
+type RedeliverResponse struct {
+	ID uint64 `json:"id"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type Attachment struct {
+	ID          uint64    `json:"id"`
+	TaskID      uint64    `json:"task_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum"`
+	Uploader    string    `json:"uploader"`
+	StorageKey  string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
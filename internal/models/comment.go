@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type Comment struct {
+	ID        uint64    `json:"id"`
+	TaskID    uint64    `json:"task_id"`
+	ReplyTo   *uint64   `json:"reply_to,omitempty"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	Edited    bool      `json:"edited"`
+	Deleted   bool      `json:"deleted"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
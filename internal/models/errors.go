@@ -1,11 +1,134 @@
 package models
 
-import "errors"
+import "strings"
+
+// ErrorKind classifies the errors of the domain, the delivery layers map a kind to their statuses.
+type ErrorKind int
+
+const (
+	KindInvalid ErrorKind = iota
+	KindNotFound
+	KindConflict
+	KindUnprocessable
+	KindTooLarge
+	KindUnsupported
+	KindRangeNotSatisfiable
+	KindUnauthorized
+	KindUnavailable
+	KindTooManyRequests
+)
+
+// Error is an error of the domain. Code is stable and machine-readable, the message may change.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is matches the errors with the same code, so an error made by WithMessage matches its sentinel.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithMessage returns the error with another message, e.g. one naming the field that is invalid.
+func (e *Error) WithMessage(message string) *Error {
+	return newError(e.Kind, e.Code, message)
+}
+
+func newError(kind ErrorKind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
 
 var (
-	ErrFailedToParsePage  = errors.New("page number is invalid")
-	ErrFailedToParseLimit = errors.New("limit number is invalid")
-	ErrFailedToParseID    = errors.New("task id is invalid")
-	ErrNotFound           = errors.New("nothing was found")
-	ErrInvalidStatus      = errors.New("status is invalid")
+	ErrFailedToParsePage         = newError(KindInvalid, "invalid_page", "page number is invalid")
+	ErrFailedToParseLimit        = newError(KindInvalid, "invalid_limit", "limit number is invalid")
+	ErrFailedToParseID           = newError(KindInvalid, "invalid_task_id", "task id is invalid")
+	ErrFailedToParseCommentID    = newError(KindInvalid, "invalid_comment_id", "comment id is invalid")
+	ErrFailedToParseCursor       = newError(KindInvalid, "invalid_cursor", "cursor is invalid")
+	ErrFailedToParseAttachmentID = newError(KindInvalid, "invalid_attachment_id", "attachment id is invalid")
+	ErrNotFound                  = newError(KindNotFound, "not_found", "nothing was found")
+	ErrInvalidStatus             = newError(KindInvalid, "invalid_status", "status is invalid")
+	ErrEmptyAuthor               = newError(KindInvalid, "empty_author", "author is empty")
+	ErrEmptyCommentBody          = newError(KindInvalid, "empty_comment_body", "comment body is empty")
+	ErrInvalidReplyTo            = newError(KindInvalid, "invalid_reply_to", "reply_to must reference a comment of the same task")
+	ErrEmptyUploader             = newError(KindInvalid, "empty_uploader", "uploader is empty")
+	ErrFileTooLarge              = newError(KindTooLarge, "file_too_large", "file is too large")
+	ErrUnsupportedFileType       = newError(KindUnsupported, "unsupported_file_type", "file type is not allowed")
+	ErrEmptyBulk                 = newError(KindInvalid, "empty_bulk", "operations list is empty")
+	ErrBulkTooLarge              = newError(KindTooLarge, "bulk_too_large", "too many operations in one request")
+	ErrInvalidBulkMode           = newError(KindInvalid, "invalid_bulk_mode", "bulk mode is invalid")
+	ErrInvalidOperation          = newError(KindInvalid, "invalid_operation", "operation type is invalid")
+	ErrBulkRolledBack            = newError(KindUnprocessable, "bulk_rolled_back", "bulk request was rolled back")
+	ErrInvalidIdempotencyKey     = newError(KindInvalid, "invalid_idempotency_key", "idempotency key is invalid")
+	ErrIdempotencyKeyReused      = newError(KindUnprocessable, "idempotency_key_reused", "idempotency key was already used with a different request")
+	ErrIdempotencyKeyInFlight    = newError(KindConflict, "idempotency_key_in_flight", "request with this idempotency key is still in progress")
+	ErrFailedToParseWebhookID    = newError(KindInvalid, "invalid_webhook_id", "webhook id is invalid")
+	ErrFailedToParseDeliveryID   = newError(KindInvalid, "invalid_delivery_id", "delivery id is invalid")
+	ErrInvalidWebhookURL         = newError(KindInvalid, "invalid_webhook_url", "webhook url must be an absolute http or https url")
+	ErrInvalidWebhookEvents      = newError(KindInvalid, "invalid_webhook_events", "webhook events are invalid")
+	ErrForbiddenWebhookTarget    = newError(KindInvalid, "forbidden_webhook_target", "webhook url must resolve to public addresses")
+	ErrInvalidRange              = newError(KindRangeNotSatisfiable, "range_not_satisfiable", "requested range is not satisfiable")
+	ErrStreamClosed              = newError(KindUnavailable, "shutting_down", "server is shutting down")
+	ErrUnauthorized              = newError(KindUnauthorized, "unauthorized", "missing or invalid access token")
+	ErrInvalidExportFormat       = newError(KindInvalid, "invalid_export_format", "export format is invalid")
+	ErrInvalidExportColumns      = newError(KindInvalid, "invalid_export_columns", "export columns are invalid")
+	ErrEmptyTitle                = newError(KindInvalid, "empty_title", "title is empty")
+	ErrInvalidImportFormat       = newError(KindInvalid, "invalid_import_format", "import format is invalid")
+	ErrUnknownImportSource       = newError(KindNotFound, "unknown_import_source", "import source is unknown")
+	ErrInvalidImportFile         = newError(KindInvalid, "invalid_import_file", "import file is invalid")
+	ErrInvalidImportMapping      = newError(KindInvalid, "invalid_import_mapping", "import column mapping is invalid")
+	ErrFailedToParseImportJobID  = newError(KindInvalid, "invalid_import_job_id", "import job id is invalid")
+	ErrInvalidDueRange           = newError(KindInvalid, "invalid_due_range", "due date range is invalid")
+	ErrInvalidCalendarComponent  = newError(KindInvalid, "invalid_calendar_component", "calendar component is invalid")
+	ErrInvalidBody               = newError(KindInvalid, "invalid_body", "request body is invalid")
+	ErrInvalidForm               = newError(KindInvalid, "invalid_form", "multipart form is invalid")
+	ErrInvalidBoolean            = newError(KindInvalid, "invalid_boolean", "value must be true or false")
+	ErrValidation                = newError(KindInvalid, "validation_failed", "request is invalid")
+	ErrRequired                  = newError(KindInvalid, "required", "value is required")
+	ErrTooLong                   = newError(KindInvalid, "too_long", "value is too long")
+	ErrInvalidValue              = newError(KindInvalid, "invalid_value", "value is not allowed")
+	ErrInvalidType               = newError(KindInvalid, "invalid_type", "value has a wrong type")
+	ErrUnknownField              = newError(KindInvalid, "unknown_field", "field is unknown")
+	ErrBodyTooLarge              = newError(KindTooLarge, "body_too_large", "request body is too large")
+	ErrUnsupportedContentType    = newError(KindUnsupported, "unsupported_content_type", "request body must be application/json")
+	ErrRateLimited               = newError(KindTooManyRequests, "rate_limited", "too many requests")
 )
+
+// FieldError tells why a field of a request is invalid.
+type FieldError struct {
+	Field string
+	Err   *Error
+}
+
+// ValidationError lists the invalid fields of a request. It matches ErrValidation and the error of every field.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// NewFieldError returns the ValidationError of a single field.
+func NewFieldError(field string, err *Error) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Field: field, Err: err}}}
+}
+
+// Error joins the messages of the fields, which name the fields themselves.
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Fields)+1)
+	errs = append(errs, ErrValidation)
+	for _, field := range e.Fields {
+		errs = append(errs, field.Err)
+	}
+	return errs
+}
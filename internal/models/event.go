@@ -0,0 +1,79 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventTaskCreated       = "task.created"
+	EventTaskUpdated       = "task.updated"
+	EventTaskStatusChanged = "task.status_changed"
+	EventTaskDeleted       = "task.deleted"
+)
+
+var TaskEventTypes = []string{
+	EventTaskCreated,
+	EventTaskUpdated,
+	EventTaskStatusChanged,
+	EventTaskDeleted,
+}
+
+type TaskEvent struct {
+	Type           string    `json:"event"`
+	TaskID         uint64    `json:"task_id"`
+	Task           *Task     `json:"task,omitempty"`
+	PreviousStatus string    `json:"previous_status,omitempty"`
+	OccurredAt     time.Time `json:"occurred_at"`
+}
+
+// OutboxEvent is a task event stored in the same transaction as the mutation that produced it.
+// Payload holds the JSON encoded TaskEvent.
+type OutboxEvent struct {
+	ID        uint64
+	Type      string
+	TaskID    uint64
+	Payload   json.RawMessage
+	CreatedAt time.Time
+}
+
+func TaskCreatedEvents(task *Task) []*TaskEvent {
+	return []*TaskEvent{{
+		Type:       EventTaskCreated,
+		TaskID:     task.ID,
+		Task:       task,
+		OccurredAt: task.CreatedAt,
+	}}
+}
+
+// TaskUpdatedEvents additionally reports a status change when the status differs from the previous one.
+func TaskUpdatedEvents(task *Task, previousStatus string) []*TaskEvent {
+	events := []*TaskEvent{{
+		Type:       EventTaskUpdated,
+		TaskID:     task.ID,
+		Task:       task,
+		OccurredAt: task.UpdatedAt,
+	}}
+
+	if previousStatus != task.Status {
+		events = append(events, &TaskEvent{
+			Type:           EventTaskStatusChanged,
+			TaskID:         task.ID,
+			Task:           task,
+			PreviousStatus: previousStatus,
+			OccurredAt:     task.UpdatedAt,
+		})
+	}
+
+	return events
+}
+
+// TaskDeletedEvents carries the last state of the deleted task.
+func TaskDeletedEvents(task *Task, deletedAt time.Time) []*TaskEvent {
+	return []*TaskEvent{{
+		Type:       EventTaskDeleted,
+		TaskID:     task.ID,
+		Task:       task,
+		OccurredAt: deletedAt,
+	}}
+}
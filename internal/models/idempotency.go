@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type IdempotencyRecord struct {
+	Scope       string
+	Key         string
+	Fingerprint string
+	Completed   bool
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
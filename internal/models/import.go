@@ -0,0 +1,101 @@
+package models
+
+import "time"
+
+const (
+	ImportPending   = "pending"
+	ImportRunning   = "running"
+	ImportSucceeded = "succeeded"
+	ImportFailed    = "failed"
+)
+
+// ImportReport counts the rows of an imported file. Rows that fail validation are skipped,
+// only the first of them are listed.
+type ImportReport struct {
+	DryRun       bool             `json:"dry_run"`
+	TotalRows    uint64           `json:"total_rows"`
+	ImportedRows uint64           `json:"imported_rows"`
+	FailedRows   uint64           `json:"failed_rows"`
+	Errors       []ImportRowError `json:"errors"`
+}
+
+// ImportRowError is a problem with a row, Row is the line of the file it starts on. A row has an error for
+// every invalid field.
+type ImportRowError struct {
+	Row   uint64 `json:"row"`
+	Field string `json:"field,omitempty"`
+	Error string `json:"error"`
+}
+
+// ImportJob imports a large file in the background, its report grows while the job is running.
+type ImportJob struct {
+	ID         uint64            `json:"id"`
+	Status     string            `json:"status"`
+	Format     string            `json:"format"`
+	Mapping    map[string]string `json:"mapping"`
+	Report     ImportReport      `json:"report"`
+	Error      string            `json:"error,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	StartedAt  *time.Time        `json:"started_at,omitempty"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+
+	StorageKey string `json:"-"`
+	Attempts   int    `json:"-"`
+}
+
+const (
+	SourceTrello = "trello"
+	SourceJira   = "jira"
+	SourceGitHub = "github"
+
+	ExternalCreated   = "created"
+	ExternalUpdated   = "updated"
+	ExternalUnchanged = "unchanged"
+)
+
+// ExternalTask is a task read from the export of another tracker, SourceID identifies it there
+// so that importing the export again updates the task instead of creating another one.
+type ExternalTask struct {
+	SourceID string
+	// SourceState is the state the tracker reported, e.g. the list of a Trello card.
+	SourceState string
+	Task        *Task
+	// Outcome is set by the import to ExternalCreated, ExternalUpdated or ExternalUnchanged.
+	Outcome string
+}
+
+// ExternalImportReport tells how the items of an export were mapped and what the import changed.
+type ExternalImportReport struct {
+	Source     string                `json:"source"`
+	DryRun     bool                  `json:"dry_run"`
+	TotalItems uint64                `json:"total_items"`
+	Created    uint64                `json:"created"`
+	Updated    uint64                `json:"updated"`
+	Unchanged  uint64                `json:"unchanged"`
+	Skipped    uint64                `json:"skipped"`
+	States     []StateMapping        `json:"states"`
+	Tasks      []ExternalTaskMapping `json:"tasks"`
+	Errors     []ExternalImportError `json:"errors"`
+}
+
+// StateMapping is the status given to the items in a state of the tracker.
+type StateMapping struct {
+	State  string `json:"state"`
+	Status string `json:"status"`
+	Count  uint64 `json:"count"`
+}
+
+// ExternalTaskMapping links an item of the tracker to its task, TaskID is 0 in a dry run of a new item.
+type ExternalTaskMapping struct {
+	SourceID string `json:"source_id"`
+	TaskID   uint64 `json:"task_id"`
+	Outcome  string `json:"outcome"`
+}
+
+// ExternalImportError is an item that was skipped, an invalid item has an error for every invalid field.
+type ExternalImportError struct {
+	SourceID string `json:"source_id,omitempty"`
+	Item     uint64 `json:"item"`
+	Field    string `json:"field,omitempty"`
+	Error    string `json:"error"`
+}
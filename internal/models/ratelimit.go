@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// RateLimit is a token bucket holding up to Burst tokens, which refills at Burst tokens per Period.
+type RateLimit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// Rate is the number of tokens the bucket gains per second.
+func (l RateLimit) Rate() float64 {
+	return float64(l.Burst) / l.Period.Seconds()
+}
+
+// Refill returns the tokens of a bucket that had the tokens elapsed ago.
+func (l RateLimit) Refill(tokens float64, elapsed time.Duration) float64 {
+	return min(float64(l.Burst), tokens+max(elapsed.Seconds(), 0)*l.Rate())
+}
+
+// RateLimitBucket is the state of a bucket after a request took a token from it, Taken is false when none was left.
+type RateLimitBucket struct {
+	Tokens float64
+	Taken  bool
+}
+
+// RateLimitResult tells a client its budget: Reset is the time until the bucket is full again and RetryAfter,
+// set when the request was not allowed, the time until the next token.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      RateLimit
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
@@ -3,10 +3,54 @@ package models
 import "time"
 
 type Task struct {
-	ID          uint64    `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID            uint64     `json:"id"`
+	Title         string     `json:"title"`
+	Description   string     `json:"description"`
+	Status        string     `json:"status"`
+	Owner         string     `json:"owner"`
+	DueAt         *time.Time `json:"due_at,omitempty"`
+	CommentsCount uint64     `json:"comments_count"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TaskFilter narrows a list of tasks, empty fields match everything.
+type TaskFilter struct {
+	Status string
+	Owner  string
+}
+
+// CalendarFilter selects the tasks with a due date that a calendar shows, empty fields match everything.
+// A task is due in the range when DueFrom <= due_at < DueTo.
+type CalendarFilter struct {
+	Owner    string
+	Statuses []string
+	DueFrom  *time.Time
+	DueTo    *time.Time
+}
+
+// TasksVersion changes whenever a task of a set is created, updated or leaves the set.
+type TasksVersion struct {
+	Count     uint64
+	IDSum     uint64
+	UpdatedAt *time.Time
+}
+
+const (
+	OperationCreate = "create"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+)
+
+type TaskOperation struct {
+	Type string
+	ID   uint64
+	Task *Task
+}
+
+type OperationResult struct {
+	ID             uint64
+	PreviousStatus string
+	Task           *Task
+	Err            error
 }
@@ -0,0 +1,30 @@
+package publisher
+
+import (
+	"context"
+	"skillsrock-test-task/internal/models"
+	"skillsrock-test-task/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// LogPublisher writes events to the application log, it is meant for development and debugging.
+type LogPublisher struct {
+	logger logger.Logger
+}
+
+func NewLogPublisher(log logger.Logger) *LogPublisher {
+	return &LogPublisher{
+		logger: log,
+	}
+}
+
+func (p *LogPublisher) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	p.logger.Info(ctx, "Task event",
+		zap.Uint64("id", event.ID),
+		zap.String("event", event.Type),
+		zap.Uint64("task_id", event.TaskID),
+		zap.ByteString("payload", event.Payload),
+	)
+	return nil
+}
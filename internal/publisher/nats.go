@@ -0,0 +1,210 @@
+package publisher
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"skillsrock-test-task/internal/models"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultNATSPort = "4222"
+
+// NATSPublisher publishes events to "<prefix>.<event>" subjects over the NATS client protocol.
+// Every message carries a Nats-Msg-Id header with the outbox ID, so a JetStream stream listening
+// on the subjects drops the duplicates caused by redeliveries. A publish is confirmed with a PING
+// round trip, which the server answers only after it processed the preceding message.
+type NATSPublisher struct {
+	addr     string
+	user     string
+	password string
+	prefix   string
+	timeout  time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func NewNATSPublisher(rawURL, subjectPrefix string, timeout time.Duration) (*NATSPublisher, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NATS url: %w", err)
+	}
+	if u.Scheme != "nats" || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid NATS url %q", rawURL)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = defaultNATSPort
+	}
+
+	p := &NATSPublisher{
+		addr:    net.JoinHostPort(u.Hostname(), port),
+		prefix:  subjectPrefix,
+		timeout: timeout,
+	}
+
+	if u.User != nil {
+		p.user = u.User.Username()
+		p.password, _ = u.User.Password()
+	}
+
+	return p, nil
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.publish(ctx, event); err != nil {
+		p.close()
+		return err
+	}
+	return nil
+}
+
+func (p *NATSPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.close()
+}
+
+func (p *NATSPublisher) publish(ctx context.Context, event *models.OutboxEvent) error {
+	if p.conn == nil {
+		if err := p.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	deadline := time.Now().Add(p.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := p.conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	subject := event.Type
+	if p.prefix != "" {
+		subject = p.prefix + "." + event.Type
+	}
+
+	headers := "NATS/1.0\r\nNats-Msg-Id: " + strconv.FormatUint(event.ID, 10) + "\r\n\r\n"
+
+	msg := fmt.Sprintf("HPUB %s %d %d\r\n%s%s\r\nPING\r\n",
+		subject, len(headers), len(headers)+len(event.Payload), headers, event.Payload)
+
+	if _, err := p.conn.Write([]byte(msg)); err != nil {
+		return err
+	}
+
+	return p.awaitPong()
+}
+
+func (p *NATSPublisher) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: p.timeout}
+
+	conn, err := dialer.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return err
+	}
+
+	p.conn = conn
+	p.reader = bufio.NewReader(conn)
+
+	if err := conn.SetDeadline(time.Now().Add(p.timeout)); err != nil {
+		return err
+	}
+
+	line, err := p.readLine()
+	if err != nil {
+		return err
+	}
+
+	var info struct {
+		Headers     bool `json:"headers"`
+		TLSRequired bool `json:"tls_required"`
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("unexpected NATS greeting %q", line)
+	}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info); err != nil {
+		return fmt.Errorf("invalid NATS server info: %w", err)
+	}
+	if info.TLSRequired {
+		return errors.New("NATS server requires TLS which is not supported")
+	}
+	if !info.Headers {
+		return errors.New("NATS server does not support message headers")
+	}
+
+	options, err := json.Marshal(map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"headers":  true,
+		"lang":     "go",
+		"name":     "skillsrock-test-task",
+		"protocol": 1,
+		"user":     p.user,
+		"pass":     p.password,
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", options); err != nil {
+		return err
+	}
+
+	return p.awaitPong()
+}
+
+// awaitPong reads until the server answers our PING, failing on protocol errors reported before it.
+func (p *NATSPublisher) awaitPong() error {
+	for {
+		line, err := p.readLine()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := p.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+func (p *NATSPublisher) readLine() (string, error) {
+	line, err := p.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (p *NATSPublisher) close() error {
+	if p.conn == nil {
+		return nil
+	}
+
+	err := p.conn.Close()
+	p.conn = nil
+	p.reader = nil
+	return err
+}
@@ -0,0 +1,36 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"skillsrock-test-task/internal/models"
+)
+
+type Publisher interface {
+	Publish(ctx context.Context, event *models.OutboxEvent) error
+}
+
+// Multi publishes every event to all publishers in turn. When one of them fails the event is retried
+// on all of them, which the at-least-once contract allows.
+type Multi struct {
+	publishers []Publisher
+	names      []string
+}
+
+func NewMulti() *Multi {
+	return &Multi{}
+}
+
+func (m *Multi) Add(name string, publisher Publisher) {
+	m.publishers = append(m.publishers, publisher)
+	m.names = append(m.names, name)
+}
+
+func (m *Multi) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	for i, publisher := range m.publishers {
+		if err := publisher.Publish(ctx, event); err != nil {
+			return fmt.Errorf("%s publisher: %w", m.names[i], err)
+		}
+	}
+	return nil
+}
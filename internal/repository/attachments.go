@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"skillsrock-test-task/internal/database/postgres"
+	"skillsrock-test-task/internal/models"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type AttachmentRepository struct {
+	db sq.StatementBuilderType
+	pg *postgres.Database
+}
+
+func NewAttachmentRepository(pg *postgres.Database) *AttachmentRepository {
+	return &AttachmentRepository{
+		db: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+		pg: pg,
+	}
+}
+
+func (r *AttachmentRepository) TaskExists(ctx context.Context, taskID uint64) (bool, error) {
+	return taskExists(ctx, r.pg, taskID)
+}
+
+func (r *AttachmentRepository) CreateAttachment(ctx context.Context, attachment *models.Attachment) (uint64, error) {
+	query := r.db.
+		Insert("attachments").
+		Columns("task_id", "storage_key", "filename", "content_type", "size", "checksum", "uploader", "created_at").
+		Values(
+			attachment.TaskID,
+			attachment.StorageKey,
+			attachment.Filename,
+			attachment.ContentType,
+			attachment.Size,
+			attachment.Checksum,
+			attachment.Uploader,
+			attachment.CreatedAt,
+		).
+		Suffix("RETURNING id")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var id uint64
+	err = r.pg.Pool.QueryRow(ctx, sql, args...).Scan(&id)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return 0, models.ErrNotFound
+	}
+	return id, err
+}
+
+func (r *AttachmentRepository) GetAttachments(ctx context.Context, taskID uint64) ([]*models.Attachment, error) {
+	exists, err := taskExists(ctx, r.pg, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, models.ErrNotFound
+	}
+
+	query := r.db.
+		Select("id", "task_id", "storage_key", "filename", "content_type", "size", "checksum", "uploader", "created_at").
+		From("attachments").
+		Where(sq.Eq{"task_id": taskID}).
+		OrderBy("id ASC")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAttachments(rows)
+}
+
+func (r *AttachmentRepository) GetAttachmentsByTaskIDs(ctx context.Context, taskIDs []uint64) ([]*models.Attachment, error) {
+	query := r.db.
+		Select("id", "task_id", "storage_key", "filename", "content_type", "size", "checksum", "uploader", "created_at").
+		From("attachments").
+		Where(sq.Eq{"task_id": taskIDs}).
+		OrderBy("task_id ASC", "id ASC")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAttachments(rows)
+}
+
+func scanAttachments(rows pgx.Rows) ([]*models.Attachment, error) {
+	defer rows.Close()
+
+	attachments := make([]*models.Attachment, 0)
+	for rows.Next() {
+		var attachment models.Attachment
+		if err := rows.Scan(
+			&attachment.ID,
+			&attachment.TaskID,
+			&attachment.StorageKey,
+			&attachment.Filename,
+			&attachment.ContentType,
+			&attachment.Size,
+			&attachment.Checksum,
+			&attachment.Uploader,
+			&attachment.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, &attachment)
+	}
+
+	return attachments, rows.Err()
+}
+
+func (r *AttachmentRepository) GetAttachment(ctx context.Context, taskID, attachmentID uint64) (*models.Attachment, error) {
+	query := r.db.
+		Select("id", "task_id", "storage_key", "filename", "content_type", "size", "checksum", "uploader", "created_at").
+		From("attachments").
+		Where(sq.Eq{"id": attachmentID, "task_id": taskID})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var attachment models.Attachment
+	err = r.pg.Pool.QueryRow(ctx, sql, args...).Scan(
+		&attachment.ID,
+		&attachment.TaskID,
+		&attachment.StorageKey,
+		&attachment.Filename,
+		&attachment.ContentType,
+		&attachment.Size,
+		&attachment.Checksum,
+		&attachment.Uploader,
+		&attachment.CreatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, models.ErrNotFound
+	}
+	return &attachment, err
+}
+
+func (r *AttachmentRepository) DeleteAttachment(ctx context.Context, taskID, attachmentID uint64) error {
+	query := r.db.
+		Delete("attachments").
+		Where(sq.Eq{"id": attachmentID, "task_id": taskID})
+
+	return execAffectingOne(ctx, r.pg, query)
+}
+
+// GetOrphanedBlobs returns storage keys whose attachment rows are gone.
+// They are queued by a trigger on attachments, which also covers rows removed by the task cascade.
+func (r *AttachmentRepository) GetOrphanedBlobs(ctx context.Context, limit uint64) ([]string, error) {
+	query := r.db.
+		Select("storage_key").
+		From("orphaned_blobs").
+		OrderBy("created_at ASC").
+		Limit(limit)
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+func (r *AttachmentRepository) DeleteOrphanedBlob(ctx context.Context, key string) error {
+	query := r.db.
+		Delete("orphaned_blobs").
+		Where(sq.Eq{"storage_key": key})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pg.Pool.Exec(ctx, sql, args...)
+	return err
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"skillsrock-test-task/internal/models"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// ApplyOperations runs all operations as one pipelined batch inside a transaction.
+// In atomic mode the first failed operation rolls the whole batch back.
+// Otherwise operations that did not match any task are reported per item,
+// and if a statement fails at the database level the batch is replayed one operation at a time.
+func (r *TaskRepository) ApplyOperations(ctx context.Context, ops []*models.TaskOperation, atomic bool) ([]*models.OperationResult, error) {
+	results, err := r.applyBatch(ctx, ops, atomic)
+	if err == nil || atomic {
+		return results, err
+	}
+
+	return r.applyEach(ctx, ops), nil
+}
+
+func (r *TaskRepository) applyBatch(ctx context.Context, ops []*models.TaskOperation, atomic bool) ([]*models.OperationResult, error) {
+	batch := &pgx.Batch{}
+	for _, op := range ops {
+		query, err := r.operationQuery(op)
+		if err != nil {
+			return nil, err
+		}
+
+		sql, args, err := query.ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		batch.Queue(sql, args...)
+	}
+
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	results := make([]*models.OperationResult, len(ops))
+	for i, op := range ops {
+		results[i] = &models.OperationResult{ID: op.ID}
+	}
+
+	failed := false
+
+	br := tx.SendBatch(ctx, batch)
+	for i, op := range ops {
+		switch op.Type {
+		case models.OperationCreate:
+			err = br.QueryRow().Scan(&results[i].ID)
+		case models.OperationUpdate:
+			results[i].Task, results[i].PreviousStatus, err = scanUpdatedTask(br.QueryRow())
+		default:
+			results[i].Task, err = scanDeletedTask(br.QueryRow())
+		}
+
+		if err == models.ErrNotFound {
+			results[i].Err = err
+			failed = true
+			continue
+		}
+
+		if err != nil {
+			results[i].Err = err
+			br.Close()
+			return results, fmt.Errorf("%w: %w", models.ErrBulkRolledBack, err)
+		}
+	}
+
+	if err := br.Close(); err != nil {
+		return results, err
+	}
+
+	if atomic && failed {
+		return results, models.ErrBulkRolledBack
+	}
+
+	if err := r.writeEvents(ctx, tx, operationEvents(ops, results, time.Now())); err != nil {
+		return results, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return results, err
+	}
+
+	return results, nil
+}
+
+func (r *TaskRepository) applyEach(ctx context.Context, ops []*models.TaskOperation) []*models.OperationResult {
+	results := make([]*models.OperationResult, len(ops))
+	for i, op := range ops {
+		results[i] = &models.OperationResult{ID: op.ID}
+
+		switch op.Type {
+		case models.OperationCreate:
+			results[i].ID, results[i].Err = r.CreateTask(ctx, op.Task)
+		case models.OperationUpdate:
+			results[i].Err = r.UpdateTask(ctx, op.ID, op.Task)
+		case models.OperationDelete:
+			results[i].Err = r.DeleteTask(ctx, op.ID)
+		}
+	}
+
+	return results
+}
+
+func operationEvents(ops []*models.TaskOperation, results []*models.OperationResult, now time.Time) []*models.TaskEvent {
+	events := make([]*models.TaskEvent, 0, len(ops))
+	for i, op := range ops {
+		if results[i].Err != nil {
+			continue
+		}
+
+		switch op.Type {
+		case models.OperationCreate:
+			created := *op.Task
+			created.ID = results[i].ID
+			events = append(events, models.TaskCreatedEvents(&created)...)
+		case models.OperationUpdate:
+			events = append(events, models.TaskUpdatedEvents(results[i].Task, results[i].PreviousStatus)...)
+		case models.OperationDelete:
+			events = append(events, models.TaskDeletedEvents(results[i].Task, now)...)
+		}
+	}
+
+	return events
+}
+
+func (r *TaskRepository) operationQuery(op *models.TaskOperation) (sq.Sqlizer, error) {
+	switch op.Type {
+	case models.OperationCreate:
+		return r.createTaskQuery(op.Task), nil
+	case models.OperationUpdate:
+		return r.updateTaskQuery(op.ID, taskValues(op.Task)), nil
+	case models.OperationDelete:
+		return r.deleteTaskQuery(op.ID), nil
+	default:
+		return nil, errors.New("unknown operation type " + op.Type)
+	}
+}
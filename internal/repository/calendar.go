@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"skillsrock-test-task/internal/models"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// GetDueTasks returns the tasks with a due date matching the filter, the earliest due first.
+func (r *TaskRepository) GetDueTasks(ctx context.Context, filter *models.CalendarFilter) ([]*models.Task, error) {
+	query := r.calendarWhere(r.db.
+		Select("id", "title", "description", "status", "owner", "due_at", commentsCountColumn, "created_at", "updated_at").
+		From("tasks").
+		OrderBy("due_at", "id"), filter)
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pg.Reader(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTasks(rows)
+}
+
+// GetDueTasksVersion reads the version of the tasks GetDueTasks returns without sending them. Created and
+// updated tasks move the latest update, deleted tasks and tasks that lost their due date change the count.
+func (r *TaskRepository) GetDueTasksVersion(ctx context.Context, filter *models.CalendarFilter) (*models.TasksVersion, error) {
+	query := r.calendarWhere(r.db.
+		Select("count(*)", "coalesce(sum(id), 0)", "max(updated_at)").
+		From("tasks"), filter)
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var version models.TasksVersion
+	err = r.pg.Reader(ctx).QueryRow(ctx, sql, args...).Scan(&version.Count, &version.IDSum, &version.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+func (r *TaskRepository) calendarWhere(query sq.SelectBuilder, filter *models.CalendarFilter) sq.SelectBuilder {
+	query = query.Where(sq.NotEq{"due_at": nil})
+
+	if filter.Owner != "" {
+		query = query.Where(sq.Eq{"owner": filter.Owner})
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where(sq.Eq{"status": filter.Statuses})
+	}
+	if filter.DueFrom != nil {
+		query = query.Where(sq.GtOrEq{"due_at": *filter.DueFrom})
+	}
+	if filter.DueTo != nil {
+		query = query.Where(sq.Lt{"due_at": *filter.DueTo})
+	}
+
+	return query
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"skillsrock-test-task/internal/database/postgres"
+	"skillsrock-test-task/internal/models"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type CommentRepository struct {
+	db sq.StatementBuilderType
+	pg *postgres.Database
+}
+
+func NewCommentRepository(pg *postgres.Database) *CommentRepository {
+	return &CommentRepository{
+		db: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+		pg: pg,
+	}
+}
+
+func (r *CommentRepository) CreateComment(ctx context.Context, comment *models.Comment) (uint64, error) {
+	if comment.ReplyTo != nil {
+		if err := r.checkReplyTo(ctx, comment.TaskID, *comment.ReplyTo); err != nil {
+			return 0, err
+		}
+	}
+
+	query := r.db.
+		Insert("comments").
+		Columns("task_id", "reply_to", "author", "body", "created_at", "updated_at").
+		Values(comment.TaskID, comment.ReplyTo, comment.Author, comment.Body, comment.CreatedAt, comment.CreatedAt).
+		Suffix("RETURNING id")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var id uint64
+	err = r.pg.Pool.QueryRow(ctx, sql, args...).Scan(&id)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return 0, models.ErrNotFound
+	}
+	return id, err
+}
+
+func (r *CommentRepository) checkReplyTo(ctx context.Context, taskID, replyTo uint64) error {
+	query := r.db.
+		Select("task_id").
+		From("comments").
+		Where(sq.Eq{"id": replyTo})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	var parentTaskID uint64
+	err = r.pg.Pool.QueryRow(ctx, sql, args...).Scan(&parentTaskID)
+	if err == pgx.ErrNoRows || (err == nil && parentTaskID != taskID) {
+		return models.ErrInvalidReplyTo
+	}
+	return err
+}
+
+func (r *CommentRepository) GetComments(ctx context.Context, taskID, after, limit uint64) ([]*models.Comment, error) {
+	exists, err := taskExists(ctx, r.pg, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, models.ErrNotFound
+	}
+
+	query := r.db.
+		Select("id", "task_id", "reply_to", "author", "body", "edited", "deleted_at IS NOT NULL", "created_at", "updated_at").
+		From("comments").
+		Where(sq.Eq{"task_id": taskID}).
+		Where(sq.Gt{"id": after}).
+		OrderBy("id ASC").
+		Limit(limit)
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanComments(rows)
+}
+
+// GetFirstComments returns up to limit oldest comments of every task in one query.
+func (r *CommentRepository) GetFirstComments(ctx context.Context, taskIDs []uint64, limit uint64) ([]*models.Comment, error) {
+	numbered := r.db.
+		Select(
+			"id", "task_id", "reply_to", "author", "body", "edited", "deleted_at IS NOT NULL AS deleted", "created_at", "updated_at",
+			"ROW_NUMBER() OVER (PARTITION BY task_id ORDER BY id ASC) AS position",
+		).
+		From("comments").
+		Where(sq.Eq{"task_id": taskIDs})
+
+	query := r.db.
+		Select("id", "task_id", "reply_to", "author", "body", "edited", "deleted", "created_at", "updated_at").
+		FromSelect(numbered, "numbered").
+		Where(sq.LtOrEq{"position": limit}).
+		OrderBy("task_id ASC", "id ASC")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanComments(rows)
+}
+
+func scanComments(rows pgx.Rows) ([]*models.Comment, error) {
+	defer rows.Close()
+
+	comments := make([]*models.Comment, 0)
+	for rows.Next() {
+		var comment models.Comment
+		if err := rows.Scan(
+			&comment.ID,
+			&comment.TaskID,
+			&comment.ReplyTo,
+			&comment.Author,
+			&comment.Body,
+			&comment.Edited,
+			&comment.Deleted,
+			&comment.CreatedAt,
+			&comment.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		// Deleted comments stay in the thread so that replies keep their context.
+		if comment.Deleted {
+			comment.Body = ""
+		}
+
+		comments = append(comments, &comment)
+	}
+
+	return comments, rows.Err()
+}
+
+func (r *CommentRepository) UpdateComment(ctx context.Context, taskID, commentID uint64, body string, updatedAt time.Time) error {
+	query := r.db.
+		Update("comments").
+		SetMap(map[string]interface{}{
+			"body":       body,
+			"edited":     true,
+			"updated_at": updatedAt,
+		}).
+		Where(sq.Eq{"id": commentID, "task_id": taskID, "deleted_at": nil})
+
+	return execAffectingOne(ctx, r.pg, query)
+}
+
+func (r *CommentRepository) DeleteComment(ctx context.Context, taskID, commentID uint64, deletedAt time.Time) error {
+	query := r.db.
+		Update("comments").
+		SetMap(map[string]interface{}{
+			"deleted_at": deletedAt,
+			"updated_at": deletedAt,
+		}).
+		Where(sq.Eq{"id": commentID, "task_id": taskID, "deleted_at": nil})
+
+	return execAffectingOne(ctx, r.pg, query)
+}
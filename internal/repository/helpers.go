@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"skillsrock-test-task/internal/database/postgres"
+	"skillsrock-test-task/internal/models"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const foreignKeyViolation = "23503"
+
+func taskExists(ctx context.Context, pg *postgres.Database, taskID uint64) (bool, error) {
+	var exists bool
+	err := pg.Pool.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM tasks WHERE id = $1)", taskID).Scan(&exists)
+	return exists, err
+}
+
+func execAffectingOne(ctx context.Context, pg *postgres.Database, query sq.Sqlizer) error {
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	cmdTag, err := pg.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"skillsrock-test-task/internal/database/postgres"
+	"skillsrock-test-task/internal/models"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+type IdempotencyRepository struct {
+	db sq.StatementBuilderType
+	pg *postgres.Database
+}
+
+func NewIdempotencyRepository(pg *postgres.Database) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		db: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+		pg: pg,
+	}
+}
+
+// ReserveKey stores a new in-flight record. An expired record with the same key is taken over.
+// It reports false when a live record already holds the key.
+func (r *IdempotencyRepository) ReserveKey(ctx context.Context, record *models.IdempotencyRecord) (bool, error) {
+	query := r.db.
+		Insert("idempotency_keys").
+		Columns("scope", "key", "fingerprint", "created_at", "expires_at").
+		Values(record.Scope, record.Key, record.Fingerprint, record.CreatedAt, record.ExpiresAt).
+		Suffix(`ON CONFLICT (scope, key) DO UPDATE SET
+			fingerprint = EXCLUDED.fingerprint,
+			status_code = NULL,
+			content_type = NULL,
+			response_body = NULL,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at < EXCLUDED.created_at
+		RETURNING key`)
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	var key string
+	err = r.pg.Pool.QueryRow(ctx, sql, args...).Scan(&key)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *IdempotencyRepository) GetKey(ctx context.Context, scope, key string) (*models.IdempotencyRecord, error) {
+	query := r.db.
+		Select("scope", "key", "fingerprint", "status_code IS NOT NULL", "COALESCE(status_code, 0)", "COALESCE(content_type, '')", "response_body", "created_at", "expires_at").
+		From("idempotency_keys").
+		Where(sq.Eq{"scope": scope, "key": key})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var record models.IdempotencyRecord
+	err = r.pg.Pool.QueryRow(ctx, sql, args...).Scan(
+		&record.Scope,
+		&record.Key,
+		&record.Fingerprint,
+		&record.Completed,
+		&record.StatusCode,
+		&record.ContentType,
+		&record.Body,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, models.ErrNotFound
+	}
+	return &record, err
+}
+
+func (r *IdempotencyRepository) CompleteKey(ctx context.Context, record *models.IdempotencyRecord) error {
+	query := r.db.
+		Update("idempotency_keys").
+		SetMap(map[string]interface{}{
+			"status_code":   record.StatusCode,
+			"content_type":  record.ContentType,
+			"response_body": record.Body,
+			"expires_at":    record.ExpiresAt,
+		}).
+		Where(sq.Eq{"scope": record.Scope, "key": record.Key})
+
+	return execAffectingOne(ctx, r.pg, query)
+}
+
+func (r *IdempotencyRepository) ReleaseKey(ctx context.Context, scope, key string) error {
+	query := r.db.
+		Delete("idempotency_keys").
+		Where(sq.Eq{"scope": scope, "key": key, "status_code": nil})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pg.Pool.Exec(ctx, sql, args...)
+	return err
+}
+
+func (r *IdempotencyRepository) DeleteExpiredKeys(ctx context.Context, now time.Time) (int64, error) {
+	query := r.db.
+		Delete("idempotency_keys").
+		Where(sq.Lt{"expires_at": now})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	cmdTag, err := r.pg.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+	return cmdTag.RowsAffected(), nil
+}
@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"skillsrock-test-task/internal/database/postgres"
+	"skillsrock-test-task/internal/models"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// claimImportJobQuery takes the oldest pending job, or a running one whose worker stopped renewing its lease.
+const claimImportJobQuery = `
+WITH next AS (
+	SELECT id FROM import_jobs
+	WHERE status = 'pending' OR (status = 'running' AND locked_until <= $1)
+	ORDER BY id
+	LIMIT 1
+	FOR UPDATE SKIP LOCKED
+)
+UPDATE import_jobs j
+SET status = 'running', attempts = j.attempts + 1, locked_until = $2, started_at = COALESCE(j.started_at, $1)
+FROM next
+WHERE j.id = next.id
+RETURNING j.id, j.format, j.mapping, j.dry_run, j.storage_key, j.attempts, j.created_at, j.started_at`
+
+// ImportRepository writes the events of the imported tasks to the outbox of tasks.
+type ImportRepository struct {
+	db    sq.StatementBuilderType
+	pg    *postgres.Database
+	tasks *TaskRepository
+}
+
+func NewImportRepository(pg *postgres.Database, tasks *TaskRepository) *ImportRepository {
+	return &ImportRepository{
+		db:    sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+		pg:    pg,
+		tasks: tasks,
+	}
+}
+
+// ImportTasks runs fn in a transaction, every batch fn passes to insert is written with one INSERT and gets
+// the IDs of its tasks. The creation events of the tasks are written when fn is done. Nothing is imported
+// when fn or a batch fails.
+func (r *ImportRepository) ImportTasks(ctx context.Context, fn func(insert func(tasks []*models.Task) error) error) error {
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var events []*models.TaskEvent
+
+	insert := func(tasks []*models.Task) error {
+		if err := r.insertTasks(ctx, tx, tasks); err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			created := *task
+			events = append(events, models.TaskCreatedEvents(&created)...)
+		}
+		return nil
+	}
+
+	if err := fn(insert); err != nil {
+		return err
+	}
+
+	if err := r.tasks.writeEvents(ctx, tx, events); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// insertTasks sets the IDs of the tasks, the rows of a multi-row VALUES are returned in the order they are listed.
+func (r *ImportRepository) insertTasks(ctx context.Context, tx pgx.Tx, tasks []*models.Task) error {
+	query := r.db.
+		Insert("tasks").
+		Columns("title", "description", "status", "owner", "due_at", "created_at", "updated_at")
+
+	for _, task := range tasks {
+		query = query.Values(task.Title, task.Description, task.Status, task.Owner, task.DueAt, task.CreatedAt, task.CreatedAt)
+	}
+
+	sql, args, err := query.Suffix("RETURNING id").ToSql()
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for i := 0; rows.Next(); i++ {
+		if err := rows.Scan(&tasks[i].ID); err != nil {
+			return err
+		}
+		tasks[i].UpdatedAt = tasks[i].CreatedAt
+	}
+
+	return rows.Err()
+}
+
+func (r *ImportRepository) CreateImportJob(ctx context.Context, job *models.ImportJob) (uint64, error) {
+	query := r.db.
+		Insert("import_jobs").
+		Columns("status", "format", "mapping", "dry_run", "storage_key", "created_at").
+		Values(job.Status, job.Format, job.Mapping, job.Report.DryRun, job.StorageKey, job.CreatedAt).
+		Suffix("RETURNING id")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var id uint64
+	err = r.pg.Pool.QueryRow(ctx, sql, args...).Scan(&id)
+	return id, err
+}
+
+func (r *ImportRepository) GetImportJob(ctx context.Context, id uint64) (*models.ImportJob, error) {
+	query := r.db.
+		Select(
+			"id", "status", "format", "mapping", "dry_run", "total_rows", "imported_rows", "failed_rows", "errors",
+			"COALESCE(error, '')", "created_at", "started_at", "finished_at",
+		).
+		From("import_jobs").
+		Where(sq.Eq{"id": id})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var job models.ImportJob
+	err = r.pg.Pool.QueryRow(ctx, sql, args...).Scan(
+		&job.ID,
+		&job.Status,
+		&job.Format,
+		&job.Mapping,
+		&job.Report.DryRun,
+		&job.Report.TotalRows,
+		&job.Report.ImportedRows,
+		&job.Report.FailedRows,
+		&job.Report.Errors,
+		&job.Error,
+		&job.CreatedAt,
+		&job.StartedAt,
+		&job.FinishedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// ClaimImportJob returns ErrNotFound when no job is waiting.
+func (r *ImportRepository) ClaimImportJob(ctx context.Context, now, leaseUntil time.Time) (*models.ImportJob, error) {
+	var job models.ImportJob
+	err := r.pg.Pool.QueryRow(ctx, claimImportJobQuery, now, leaseUntil).Scan(
+		&job.ID,
+		&job.Format,
+		&job.Mapping,
+		&job.Report.DryRun,
+		&job.StorageKey,
+		&job.Attempts,
+		&job.CreatedAt,
+		&job.StartedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	job.Status = models.ImportRunning
+
+	return &job, nil
+}
+
+// UpdateImportJob saves the report and status of a job, a running job keeps its lease until leaseUntil.
+func (r *ImportRepository) UpdateImportJob(ctx context.Context, job *models.ImportJob, leaseUntil time.Time) error {
+	query := r.db.
+		Update("import_jobs").
+		SetMap(map[string]interface{}{
+			"status":        job.Status,
+			"total_rows":    job.Report.TotalRows,
+			"imported_rows": job.Report.ImportedRows,
+			"failed_rows":   job.Report.FailedRows,
+			"errors":        job.Report.Errors,
+			"error":         job.Error,
+			"locked_until":  leaseUntil,
+			"finished_at":   job.FinishedAt,
+		}).
+		Where(sq.Eq{"id": job.ID})
+
+	return execAffectingOne(ctx, r.pg, query)
+}
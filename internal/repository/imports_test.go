@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"skillsrock-test-task/internal/config"
+	"skillsrock-test-task/internal/database/postgres"
+	"skillsrock-test-task/internal/models"
+	"skillsrock-test-task/pkg/logger"
+	"skillsrock-test-task/pkg/migrator"
+	"slices"
+	"testing"
+	"time"
+)
+
+// testDatabase migrates and opens the database named by TEST_POSTGRES_DB, reached with the POSTGRES_* settings.
+// The test is skipped without it, it never writes to the database of the app.
+func testDatabase(t *testing.T) *postgres.Database {
+	t.Helper()
+
+	name := os.Getenv("TEST_POSTGRES_DB")
+	if name == "" {
+		t.Skip("TEST_POSTGRES_DB is not set")
+	}
+
+	cfg, err := config.New("")
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	cfg.Postgres.Name = name
+	cfg.Postgres.Replicas = ""
+
+	ctx := logger.SetToCtx(context.Background(), logger.NewNop())
+	if err := migrator.Start(ctx, cfg); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	db, err := postgres.NewDatabase(ctx, cfg.Postgres)
+	if err != nil {
+		t.Fatalf("NewDatabase: %v", err)
+	}
+	t.Cleanup(db.Close)
+
+	return db
+}
+
+func TestImportTasksWritesEvents(t *testing.T) {
+	ctx := context.Background()
+	db := testDatabase(t)
+	repo := NewImportRepository(db, NewTaskRepository(db, "task_events_test"))
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	batches := [][]*models.Task{
+		{
+			{Title: "First", Status: "new", Owner: "importer", CreatedAt: now},
+			{Title: "Second", Status: "done", Owner: "importer", CreatedAt: now},
+		},
+		{
+			{Title: "Third", Status: "in_progress", Owner: "importer", CreatedAt: now},
+		},
+	}
+
+	err := repo.ImportTasks(ctx, func(insert func(tasks []*models.Task) error) error {
+		for _, batch := range batches {
+			if err := insert(batch); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ImportTasks: %v", err)
+	}
+
+	var ids []uint64
+	for _, batch := range batches {
+		for _, task := range batch {
+			if task.ID == 0 {
+				t.Fatalf("task %q has no ID", task.Title)
+			}
+			ids = append(ids, task.ID)
+		}
+	}
+
+	rows, err := db.Pool.Query(ctx,
+		"SELECT task_id, payload->'task'->>'title' FROM outbox WHERE event_type = $1 AND task_id = ANY($2) ORDER BY id",
+		models.EventTaskCreated, ids)
+	if err != nil {
+		t.Fatalf("outbox: %v", err)
+	}
+	defer rows.Close()
+
+	var (
+		eventIDs []uint64
+		titles   []string
+	)
+	for rows.Next() {
+		var (
+			id    uint64
+			title string
+		)
+		if err := rows.Scan(&id, &title); err != nil {
+			t.Fatalf("outbox: %v", err)
+		}
+		eventIDs = append(eventIDs, id)
+		titles = append(titles, title)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("outbox: %v", err)
+	}
+
+	if !slices.Equal(eventIDs, ids) {
+		t.Fatalf("task.created events of tasks %v, want %v", eventIDs, ids)
+	}
+	if want := []string{"First", "Second", "Third"}; !slices.Equal(titles, want) {
+		t.Fatalf("titles of the events = %q, want %q", titles, want)
+	}
+}
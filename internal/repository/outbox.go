@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"skillsrock-test-task/internal/database/postgres"
+	"skillsrock-test-task/internal/models"
+	"strconv"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// outboxRelayLock is the advisory lock key held by the relay publishing the outbox,
+	// only one replica publishes at a time so that events leave in order.
+	outboxRelayLock = 7_310_001
+
+	// outboxWriteLock is held by a transaction from the moment its events are numbered until it commits, so
+	// the seq of the events follows the order of the commits. A relay that sees an event has seen all events
+	// with a lower seq, unlike with the id, which is taken when the row is inserted.
+	outboxWriteLock = 7_310_002
+)
+
+type OutboxRepository struct {
+	db sq.StatementBuilderType
+	pg *postgres.Database
+}
+
+func NewOutboxRepository(pg *postgres.Database) *OutboxRepository {
+	return &OutboxRepository{
+		db: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+		pg: pg,
+	}
+}
+
+// PublishPending hands unpublished events to publish in the order their transactions committed and then marks
+// them as published. It stops at the first failed event, which is retried by the next call together with
+// everything after it. Publishing calls other services, so it runs outside of a transaction, holding a session
+// lock on a connection of its own instead. Nothing is done when another relay holds the lock.
+func (r *OutboxRepository) PublishPending(ctx context.Context, limit uint64, publish func(ctx context.Context, event *models.OutboxEvent) error) (int, error) {
+	conn, err := r.pg.Pool.Acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	var locked bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", outboxRelayLock).Scan(&locked); err != nil {
+		return 0, err
+	}
+	if !locked {
+		return 0, nil
+	}
+	defer func() {
+		// A connection that could not release the lock is closed, which releases it, rather than returned to
+		// the pool still holding it.
+		if _, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", outboxRelayLock); err != nil {
+			conn.Conn().Close(context.Background())
+		}
+	}()
+
+	events, err := r.pendingEvents(ctx, conn, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]uint64, 0, len(events))
+
+	var publishErr error
+	for _, event := range events {
+		if publishErr = publish(ctx, event); publishErr != nil {
+			break
+		}
+		ids = append(ids, event.ID)
+	}
+
+	if len(ids) > 0 {
+		query := r.db.
+			Update("outbox").
+			Set("published_at", time.Now()).
+			Where(sq.Eq{"id": ids})
+
+		sql, args, err := query.ToSql()
+		if err != nil {
+			return 0, err
+		}
+
+		// The events published so far are marked even when a later one failed.
+		if _, err := conn.Exec(ctx, sql, args...); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(ids), publishErr
+}
+
+func (r *OutboxRepository) pendingEvents(ctx context.Context, conn *pgxpool.Conn, limit uint64) ([]*models.OutboxEvent, error) {
+	query := r.db.
+		Select("id", "event_type", "task_id", "payload", "created_at").
+		From("outbox").
+		Where(sq.Eq{"published_at": nil}).
+		OrderBy("seq ASC").
+		Limit(limit)
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanOutboxEvents(rows)
+}
+
+// GetRecentEvents returns the latest events of the outbox in the order their transactions committed.
+func (r *OutboxRepository) GetRecentEvents(ctx context.Context, limit uint64) ([]*models.OutboxEvent, error) {
+	query := r.db.
+		Select("id", "event_type", "task_id", "payload", "created_at").
+		FromSelect(r.db.
+			Select("id", "event_type", "task_id", "payload", "created_at", "seq").
+			From("outbox").
+			OrderBy("seq DESC").
+			Limit(limit), "recent").
+		OrderBy("seq ASC")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanOutboxEvents(rows)
+}
+
+func (r *OutboxRepository) GetEvent(ctx context.Context, id uint64) (*models.OutboxEvent, error) {
+	query := r.db.
+		Select("id", "event_type", "task_id", "payload", "created_at").
+		From("outbox").
+		Where(sq.Eq{"id": id})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var event models.OutboxEvent
+	err = r.pg.Pool.QueryRow(ctx, sql, args...).Scan(
+		&event.ID,
+		&event.Type,
+		&event.TaskID,
+		&event.Payload,
+		&event.CreatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, models.ErrNotFound
+	}
+	return &event, err
+}
+
+// ListenEvents listens for the IDs of events announced on the channel with NOTIFY on a connection taken
+// out of the pool. listening is called once the subscription is active, so that events written before it
+// can be loaded without a gap. It returns when the context is cancelled or the connection breaks.
+func (r *OutboxRepository) ListenEvents(ctx context.Context, channel string, listening func(), notified func(id uint64)) error {
+	conn, err := r.pg.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	pgConn := conn.Hijack()
+	defer pgConn.Close(context.Background())
+
+	if _, err := pgConn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return err
+	}
+
+	listening()
+
+	for {
+		notification, err := pgConn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		id, err := strconv.ParseUint(notification.Payload, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		notified(id)
+	}
+}
+
+func (r *OutboxRepository) DeletePublishedEvents(ctx context.Context, before time.Time) (int64, error) {
+	query := r.db.
+		Delete("outbox").
+		Where(sq.Lt{"published_at": before})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	cmdTag, err := r.pg.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+	return cmdTag.RowsAffected(), nil
+}
+
+func scanOutboxEvents(rows pgx.Rows) ([]*models.OutboxEvent, error) {
+	events := make([]*models.OutboxEvent, 0)
+	for rows.Next() {
+		var event models.OutboxEvent
+		if err := rows.Scan(
+			&event.ID,
+			&event.Type,
+			&event.TaskID,
+			&event.Payload,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+
+	return events, rows.Err()
+}
+
+// writeEvents stores the events in the outbox within the transaction of the task mutation that produced them,
+// and announces their IDs with NOTIFY, which Postgres delivers to the listeners when the transaction commits,
+// so the task stream does not wait for the relay. It must be the last statement before the commit: the
+// transaction waits for the write lock, and holds it from the numbering of its events until it commits.
+func (r *TaskRepository) writeEvents(ctx context.Context, tx pgx.Tx, events []*models.TaskEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", outboxWriteLock); err != nil {
+		return err
+	}
+
+	query := r.db.
+		Insert("outbox").
+		Columns("event_type", "task_id", "payload", "created_at")
+
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		query = query.Values(event.Type, event.TaskID, payload, event.OccurredAt)
+	}
+
+	sql, args, err := query.Suffix("RETURNING id").ToSql()
+	if err != nil {
+		return err
+	}
+
+	notify := "WITH written AS (" + sql + ") " +
+		"SELECT pg_notify($" + strconv.Itoa(len(args)+1) + ", id::text) FROM written ORDER BY id"
+
+	_, err = tx.Exec(ctx, notify, append(args, r.eventsChannel)...)
+	return err
+}
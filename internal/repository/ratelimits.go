@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"skillsrock-test-task/internal/database/postgres"
+	"skillsrock-test-task/internal/models"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// takeRateLimitTokenQuery refills the bucket for the time passed since its last request and takes a token when
+// one is left. The conflicting row is locked, so concurrent requests of a client on several replicas queue up.
+// $1 is the key, $2 the burst, $3 the tokens gained per second and $4 the time of the request.
+const takeRateLimitTokenQuery = `
+INSERT INTO rate_limits AS b (key, tokens, taken, updated_at)
+VALUES ($1, $2::float8 - 1, TRUE, $4)
+ON CONFLICT (key) DO UPDATE SET
+	tokens = LEAST($2::float8, b.tokens + GREATEST(EXTRACT(EPOCH FROM ($4::timestamp - b.updated_at))::float8, 0) * $3::float8)
+		- CASE WHEN LEAST($2::float8, b.tokens + GREATEST(EXTRACT(EPOCH FROM ($4::timestamp - b.updated_at))::float8, 0) * $3::float8) >= 1 THEN 1 ELSE 0 END,
+	taken = LEAST($2::float8, b.tokens + GREATEST(EXTRACT(EPOCH FROM ($4::timestamp - b.updated_at))::float8, 0) * $3::float8) >= 1,
+	updated_at = GREATEST(b.updated_at, $4::timestamp)
+RETURNING tokens, taken`
+
+// RateLimitRepository keeps the buckets in Postgres, so every replica takes from the same buckets.
+type RateLimitRepository struct {
+	db sq.StatementBuilderType
+	pg *postgres.Database
+}
+
+func NewRateLimitRepository(pg *postgres.Database) *RateLimitRepository {
+	return &RateLimitRepository{
+		db: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+		pg: pg,
+	}
+}
+
+func (r *RateLimitRepository) TakeToken(ctx context.Context, key string, limit models.RateLimit, now time.Time) (*models.RateLimitBucket, error) {
+	var bucket models.RateLimitBucket
+
+	err := r.pg.Pool.QueryRow(ctx, takeRateLimitTokenQuery, key, float64(limit.Burst), limit.Rate(), now).Scan(&bucket.Tokens, &bucket.Taken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bucket, nil
+}
+
+// DeleteIdleBuckets deletes the buckets last used before the time, they would be full by now anyway.
+func (r *RateLimitRepository) DeleteIdleBuckets(ctx context.Context, before time.Time) (int64, error) {
+	query := r.db.
+		Delete("rate_limits").
+		Where(sq.Lt{"updated_at": before})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	tag, err := r.pg.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+type memoryBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// MemoryRateLimitRepository keeps the buckets of a single instance in memory.
+type MemoryRateLimitRepository struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+}
+
+func NewMemoryRateLimitRepository() *MemoryRateLimitRepository {
+	return &MemoryRateLimitRepository{
+		buckets: make(map[string]*memoryBucket),
+	}
+}
+
+func (r *MemoryRateLimitRepository) TakeToken(ctx context.Context, key string, limit models.RateLimit, now time.Time) (*models.RateLimitBucket, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &memoryBucket{tokens: float64(limit.Burst), updatedAt: now}
+		r.buckets[key] = b
+	}
+
+	b.tokens = limit.Refill(b.tokens, now.Sub(b.updatedAt))
+	if now.After(b.updatedAt) {
+		b.updatedAt = now
+	}
+
+	taken := b.tokens >= 1
+	if taken {
+		b.tokens--
+	}
+
+	return &models.RateLimitBucket{Tokens: b.tokens, Taken: taken}, nil
+}
+
+func (r *MemoryRateLimitRepository) DeleteIdleBuckets(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for key, b := range r.buckets {
+		if b.updatedAt.Before(before) {
+			delete(r.buckets, key)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
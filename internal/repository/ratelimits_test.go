@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"skillsrock-test-task/internal/models"
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimitRepositoryTakeToken(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRateLimitRepository()
+	limit := models.RateLimit{Burst: 2, Period: time.Second}
+	start := time.Date(2025, 6, 23, 9, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		name   string
+		at     time.Duration
+		taken  bool
+		tokens float64
+	}{
+		{name: "new bucket is full", at: 0, taken: true, tokens: 1},
+		{name: "last token", at: 0, taken: true, tokens: 0},
+		{name: "exhausted", at: 0, taken: false, tokens: 0},
+		{name: "refilled a quarter", at: 125 * time.Millisecond, taken: false, tokens: 0.25},
+		{name: "refilled a token", at: 500 * time.Millisecond, taken: true, tokens: 0},
+		{name: "clock going back adds nothing", at: 250 * time.Millisecond, taken: false, tokens: 0},
+		{name: "refill stops at the burst", at: time.Hour, taken: true, tokens: 1},
+	}
+	for _, step := range steps {
+		bucket, err := repo.TakeToken(ctx, "tasks.read:ip:127.0.0.1", limit, start.Add(step.at))
+		if err != nil {
+			t.Fatalf("%s: TakeToken: %v", step.name, err)
+		}
+		if bucket.Taken != step.taken || bucket.Tokens != step.tokens {
+			t.Fatalf("%s: TakeToken = %+v, want taken %v with %v tokens", step.name, bucket, step.taken, step.tokens)
+		}
+	}
+
+	other, err := repo.TakeToken(ctx, "tasks.write:ip:127.0.0.1", limit, start)
+	if err != nil {
+		t.Fatalf("TakeToken of another key: %v", err)
+	}
+	if !other.Taken || other.Tokens != 1 {
+		t.Fatalf("TakeToken of another key = %+v, want a full bucket of its own", other)
+	}
+}
+
+func TestMemoryRateLimitRepositoryDeleteIdleBuckets(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRateLimitRepository()
+	limit := models.RateLimit{Burst: 1, Period: time.Minute}
+	start := time.Date(2025, 6, 23, 9, 0, 0, 0, time.UTC)
+
+	for _, take := range []struct {
+		key string
+		at  time.Time
+	}{
+		{key: "idle", at: start},
+		{key: "active", at: start.Add(2 * time.Minute)},
+	} {
+		if _, err := repo.TakeToken(ctx, take.key, limit, take.at); err != nil {
+			t.Fatalf("TakeToken(%q): %v", take.key, err)
+		}
+	}
+
+	deleted, err := repo.DeleteIdleBuckets(ctx, start.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("DeleteIdleBuckets: %v", err)
+	}
+	if deleted != 1 {
+		t.Fatalf("DeleteIdleBuckets deleted %d buckets, want 1", deleted)
+	}
+
+	// The idle bucket starts full again, the active one is still empty.
+	if bucket, _ := repo.TakeToken(ctx, "idle", limit, start.Add(2*time.Minute)); !bucket.Taken {
+		t.Fatal("idle bucket was not deleted")
+	}
+	if bucket, _ := repo.TakeToken(ctx, "active", limit, start.Add(2*time.Minute)); bucket.Taken {
+		t.Fatal("active bucket was deleted")
+	}
+}
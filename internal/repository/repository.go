@@ -2,45 +2,148 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"skillsrock-test-task/internal/database/postgres"
 	"skillsrock-test-task/internal/models"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v5"
 )
 
+const (
+	commentsCountColumn = "(SELECT COUNT(*) FROM comments WHERE comments.task_id = tasks.id AND comments.deleted_at IS NULL) AS comments_count"
+	exportFetchSize     = 1000
+)
+
+// TaskRepository announces the events of the task mutations on eventsChannel with NOTIFY.
 type TaskRepository struct {
-	db sq.StatementBuilderType
-	pg *postgres.Database
+	db            sq.StatementBuilderType
+	pg            *postgres.Database
+	eventsChannel string
 }
 
-func NewTaskRepository(pg *postgres.Database) *TaskRepository {
+func NewTaskRepository(pg *postgres.Database, eventsChannel string) *TaskRepository {
 	return &TaskRepository{
-		db: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
-		pg: pg,
+		db:            sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+		pg:            pg,
+		eventsChannel: eventsChannel,
 	}
 }
 
-func (r *TaskRepository) CreateTask(ctx context.Context, task *models.Task) (uint64, error) {
-	query := r.db.
+func (r *TaskRepository) createTaskQuery(task *models.Task) sq.InsertBuilder {
+	return r.db.
 		Insert("tasks").
-		Columns("title", "description", "status", "created_at").
-		Values(task.Title, task.Description, task.Status, task.CreatedAt).
+		Columns("title", "description", "status", "owner", "due_at", "created_at").
+		Values(task.Title, task.Description, task.Status, task.Owner, task.DueAt, task.CreatedAt).
 		Suffix("RETURNING id")
+}
 
-	sql, args, err := query.ToSql()
+// updateTaskQuery returns the status the task had before the update followed by the updated row,
+// the row is locked to read the previous status consistently.
+func (r *TaskRepository) updateTaskQuery(id uint64, values map[string]interface{}) sq.UpdateBuilder {
+	return r.db.
+		Update("tasks").
+		SetMap(values).
+		FromSelect(r.db.Select("id", "status").From("tasks").Where(sq.Eq{"id": id}).Suffix("FOR UPDATE"), "prev").
+		Where("tasks.id = prev.id").
+		Suffix("RETURNING prev.status, tasks.id, tasks.title, tasks.description, tasks.status, tasks.owner, tasks.due_at, tasks.created_at, tasks.updated_at")
+}
+
+func taskValues(task *models.Task) map[string]interface{} {
+	return map[string]interface{}{
+		"title":       task.Title,
+		"description": task.Description,
+		"status":      task.Status,
+		"due_at":      task.DueAt,
+		"updated_at":  task.UpdatedAt,
+	}
+}
+
+// deleteTaskQuery returns the deleted row, it is published with the deletion event.
+func (r *TaskRepository) deleteTaskQuery(id uint64) sq.DeleteBuilder {
+	return r.db.
+		Delete("tasks").
+		Where(sq.Eq{"id": id}).
+		Suffix("RETURNING id, title, description, status, owner, due_at, created_at, updated_at")
+}
+
+func scanUpdatedTask(row pgx.Row) (*models.Task, string, error) {
+	var (
+		task           models.Task
+		previousStatus string
+	)
+	err := row.Scan(
+		&previousStatus,
+		&task.ID,
+		&task.Title,
+		&task.Description,
+		&task.Status,
+		&task.Owner,
+		&task.DueAt,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, "", models.ErrNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return &task, previousStatus, nil
+}
+
+func scanDeletedTask(row pgx.Row) (*models.Task, error) {
+	var task models.Task
+	err := row.Scan(
+		&task.ID,
+		&task.Title,
+		&task.Description,
+		&task.Status,
+		&task.Owner,
+		&task.DueAt,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *TaskRepository) CreateTask(ctx context.Context, task *models.Task) (uint64, error) {
+	sql, args, err := r.createTaskQuery(task).ToSql()
 	if err != nil {
 		return 0, err
 	}
 
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
 	var id uint64
-	err = r.pg.Pool.QueryRow(ctx, sql, args...).Scan(&id)
-	return id, err
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	created := *task
+	created.ID = id
+
+	if err := r.writeEvents(ctx, tx, models.TaskCreatedEvents(&created)); err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit(ctx)
 }
 
 func (r *TaskRepository) GetTaskByID(ctx context.Context, id uint64) (*models.Task, error) {
 	query := r.db.
-		Select("id", "title", "description", "status", "created_at", "updated_at").
+		Select("id", "title", "description", "status", "owner", "due_at", commentsCountColumn, "created_at", "updated_at").
 		From("tasks").
 		Where(sq.Eq{"id": id}).
 		Limit(1)
@@ -51,11 +154,14 @@ func (r *TaskRepository) GetTaskByID(ctx context.Context, id uint64) (*models.Ta
 	}
 
 	var task models.Task
-	err = r.pg.Pool.QueryRow(ctx, sql, args...).Scan(
+	err = r.pg.Reader(ctx).QueryRow(ctx, sql, args...).Scan(
 		&task.ID,
 		&task.Title,
 		&task.Description,
 		&task.Status,
+		&task.Owner,
+		&task.DueAt,
+		&task.CommentsCount,
 		&task.CreatedAt,
 		&task.UpdatedAt,
 	)
@@ -66,45 +172,159 @@ func (r *TaskRepository) GetTaskByID(ctx context.Context, id uint64) (*models.Ta
 }
 
 func (r *TaskRepository) DeleteTask(ctx context.Context, id uint64) error {
-	query := r.db.
-		Delete("tasks").
-		Where(sq.Eq{"id": id})
+	sql, args, err := r.deleteTaskQuery(id).ToSql()
+	if err != nil {
+		return err
+	}
 
-	sql, args, err := query.ToSql()
+	tx, err := r.pg.Pool.Begin(ctx)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback(ctx)
 
-	cmdTag, err := r.pg.Pool.Exec(ctx, sql, args...)
+	deleted, err := scanDeletedTask(tx.QueryRow(ctx, sql, args...))
 	if err != nil {
 		return err
 	}
-	if cmdTag.RowsAffected() == 0 {
-		return models.ErrNotFound
+
+	if err := r.writeEvents(ctx, tx, models.TaskDeletedEvents(deleted, time.Now())); err != nil {
+		return err
 	}
-	return nil
+
+	return tx.Commit(ctx)
 }
 
-func (r *TaskRepository) GetTasks(ctx context.Context, limit, offset uint64) ([]*models.Task, error) {
+func (r *TaskRepository) GetTasks(ctx context.Context, filter *models.TaskFilter, limit, offset uint64) ([]*models.Task, error) {
 	query := r.db.
-		Select("id", "title", "description", "status", "created_at", "updated_at").
+		Select("id", "title", "description", "status", "owner", "due_at", commentsCountColumn, "created_at", "updated_at").
 		From("tasks").
 		Limit(limit).
 		Offset(offset).
 		OrderBy("id DESC")
 
+	if filter.Status != "" {
+		query = query.Where(sq.Eq{"status": filter.Status})
+	}
+	if filter.Owner != "" {
+		query = query.Where(sq.Eq{"owner": filter.Owner})
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pg.Reader(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tasks) == 0 {
+		return nil, models.ErrNotFound
+	}
+
+	return tasks, nil
+}
+
+// GetTasksBefore pages through tasks from the newest one, before is the ID of the last task of the
+// previous page or 0 for the first page. Unlike GetTasks an empty page is not an error.
+func (r *TaskRepository) GetTasksBefore(ctx context.Context, filter *models.TaskFilter, before, limit uint64) ([]*models.Task, error) {
+	query := r.db.
+		Select("id", "title", "description", "status", "owner", "due_at", commentsCountColumn, "created_at", "updated_at").
+		From("tasks").
+		Limit(limit).
+		OrderBy("id DESC")
+
+	if before != 0 {
+		query = query.Where(sq.Lt{"id": before})
+	}
+	if filter.Status != "" {
+		query = query.Where(sq.Eq{"status": filter.Status})
+	}
+	if filter.Owner != "" {
+		query = query.Where(sq.Eq{"owner": filter.Owner})
+	}
+
 	sql, args, err := query.ToSql()
 	if err != nil {
 		return nil, err
 	}
 
-	rows, err := r.pg.Pool.Query(ctx, sql, args...)
+	rows, err := r.pg.Reader(ctx).Query(ctx, sql, args...)
 	if err != nil {
 		return nil, err
 	}
+
+	return scanTasks(rows)
+}
+
+// ExportTasks passes all tasks matching the filter to fn, oldest first. The tasks are read through a
+// cursor in batches of exportFetchSize, so memory use does not grow with the number of tasks, and
+// within one transaction, so the export is a consistent snapshot. An error of fn stops the export.
+func (r *TaskRepository) ExportTasks(ctx context.Context, filter *models.TaskFilter, fn func(task *models.Task) error) error {
+	query := r.db.
+		Select("id", "title", "description", "status", "owner", "due_at", commentsCountColumn, "created_at", "updated_at").
+		From("tasks").
+		OrderBy("id")
+
+	if filter.Status != "" {
+		query = query.Where(sq.Eq{"status": filter.Status})
+	}
+	if filter.Owner != "" {
+		query = query.Where(sq.Eq{"owner": filter.Owner})
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.pg.Reader(ctx).BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "DECLARE export_tasks NO SCROLL CURSOR FOR "+sql, args...); err != nil {
+		return err
+	}
+
+	fetch := fmt.Sprintf("FETCH %d FROM export_tasks", exportFetchSize)
+	for {
+		rows, err := tx.Query(ctx, fetch)
+		if err != nil {
+			return err
+		}
+
+		tasks, err := scanTasks(rows)
+		if err != nil {
+			return err
+		}
+
+		for _, task := range tasks {
+			if err := fn(task); err != nil {
+				return err
+			}
+		}
+
+		if len(tasks) < exportFetchSize {
+			break
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func scanTasks(rows pgx.Rows) ([]*models.Task, error) {
 	defer rows.Close()
 
-	var tasks []*models.Task
+	tasks := make([]*models.Task, 0)
 	for rows.Next() {
 		var task models.Task
 		if err := rows.Scan(
@@ -112,6 +332,9 @@ func (r *TaskRepository) GetTasks(ctx context.Context, limit, offset uint64) ([]
 			&task.Title,
 			&task.Description,
 			&task.Status,
+			&task.Owner,
+			&task.DueAt,
+			&task.CommentsCount,
 			&task.CreatedAt,
 			&task.UpdatedAt,
 		); err != nil {
@@ -120,35 +343,41 @@ func (r *TaskRepository) GetTasks(ctx context.Context, limit, offset uint64) ([]
 		tasks = append(tasks, &task)
 	}
 
-	if len(tasks) == 0 {
-		return nil, models.ErrNotFound
-	}
-
-	return tasks, nil
+	return tasks, rows.Err()
 }
 
 func (r *TaskRepository) UpdateTask(ctx context.Context, id uint64, task *models.Task) error {
-	query := r.db.
-		Update("tasks").
-		SetMap(map[string]interface{}{
-			"title":       task.Title,
-			"description": task.Description,
-			"status":      task.Status,
-			"updated_at":  task.UpdatedAt,
-		}).
-		Where(sq.Eq{"id": id})
+	return r.updateTask(ctx, r.updateTaskQuery(id, taskValues(task)))
+}
 
+// UpdateTaskStatus changes only the status, e.g. when a card is moved on a board.
+func (r *TaskRepository) UpdateTaskStatus(ctx context.Context, id uint64, status string, updatedAt time.Time) error {
+	return r.updateTask(ctx, r.updateTaskQuery(id, map[string]interface{}{
+		"status":     status,
+		"updated_at": updatedAt,
+	}))
+}
+
+func (r *TaskRepository) updateTask(ctx context.Context, query sq.UpdateBuilder) error {
 	sql, args, err := query.ToSql()
 	if err != nil {
 		return err
 	}
 
-	cmdTag, err := r.pg.Pool.Exec(ctx, sql, args...)
+	tx, err := r.pg.Pool.Begin(ctx)
 	if err != nil {
 		return err
 	}
-	if cmdTag.RowsAffected() == 0 {
-		return models.ErrNotFound
+	defer tx.Rollback(ctx)
+
+	updated, previousStatus, err := scanUpdatedTask(tx.QueryRow(ctx, sql, args...))
+	if err != nil {
+		return err
 	}
-	return nil
+
+	if err := r.writeEvents(ctx, tx, models.TaskUpdatedEvents(updated, previousStatus)); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"skillsrock-test-task/internal/models"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+const sourcesBatchSize = 1000
+
+// createSourcedTaskQuery creates a task together with the link to the item it was imported from.
+const createSourcedTaskQuery = `
+WITH created AS (
+	INSERT INTO tasks (title, description, status, owner, due_at, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id
+)
+INSERT INTO task_sources (source, source_id, task_id)
+SELECT $8, $9, id FROM created
+RETURNING task_id`
+
+// ImportExternalTasks creates the tasks of items imported from a source for the first time and updates
+// the tasks of the others, a task whose fields did not change is left alone. The outcome and the ID are set
+// on every task. Imports of the same source run one after the other, a dry run is rolled back.
+func (r *TaskRepository) ImportExternalTasks(ctx context.Context, source string, tasks []*models.ExternalTask, dryRun bool) error {
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext('task_sources:' || $1))", source); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var events []*models.TaskEvent
+
+	for start := 0; start < len(tasks); start += sourcesBatchSize {
+		batchEvents, err := r.importExternalBatch(ctx, tx, source, tasks[start:min(start+sourcesBatchSize, len(tasks))], now)
+		if err != nil {
+			return err
+		}
+		events = append(events, batchEvents...)
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	if err := r.writeEvents(ctx, tx, events); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *TaskRepository) importExternalBatch(ctx context.Context, tx pgx.Tx, source string, tasks []*models.ExternalTask, now time.Time) ([]*models.TaskEvent, error) {
+	existing, err := r.sourcedTaskIDs(ctx, tx, source, tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &pgx.Batch{}
+	for _, ext := range tasks {
+		task := ext.Task
+		task.UpdatedAt = now
+
+		id, ok := existing[ext.SourceID]
+		if !ok {
+			batch.Queue(createSourcedTaskQuery,
+				task.Title, task.Description, task.Status, task.Owner, task.DueAt, task.CreatedAt, task.UpdatedAt, source, ext.SourceID)
+			continue
+		}
+
+		sql, args, err := r.updateTaskQuery(id, taskValues(task)).
+			Where("(tasks.title, tasks.description, tasks.status, tasks.due_at) IS DISTINCT FROM (?, ?, ?, ?::timestamptz)",
+				task.Title, task.Description, task.Status, task.DueAt).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+		batch.Queue(sql, args...)
+	}
+
+	events := make([]*models.TaskEvent, 0, len(tasks))
+
+	br := tx.SendBatch(ctx, batch)
+	defer br.Close()
+
+	for _, ext := range tasks {
+		id, ok := existing[ext.SourceID]
+		if !ok {
+			if err := br.QueryRow().Scan(&ext.Task.ID); err != nil {
+				return nil, err
+			}
+			ext.Outcome = models.ExternalCreated
+			events = append(events, models.TaskCreatedEvents(ext.Task)...)
+			continue
+		}
+
+		updated, previousStatus, err := scanUpdatedTask(br.QueryRow())
+		if errors.Is(err, models.ErrNotFound) {
+			ext.Task.ID = id
+			ext.Outcome = models.ExternalUnchanged
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		ext.Task = updated
+		ext.Outcome = models.ExternalUpdated
+		events = append(events, models.TaskUpdatedEvents(updated, previousStatus)...)
+	}
+
+	return events, br.Close()
+}
+
+func (r *TaskRepository) sourcedTaskIDs(ctx context.Context, tx pgx.Tx, source string, tasks []*models.ExternalTask) (map[string]uint64, error) {
+	sourceIDs := make([]string, len(tasks))
+	for i, ext := range tasks {
+		sourceIDs[i] = ext.SourceID
+	}
+
+	sql, args, err := r.db.
+		Select("source_id", "task_id").
+		From("task_sources").
+		Where(sq.Eq{"source": source, "source_id": sourceIDs}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]uint64, len(tasks))
+	for rows.Next() {
+		var (
+			sourceID string
+			taskID   uint64
+		)
+		if err := rows.Scan(&sourceID, &taskID); err != nil {
+			return nil, err
+		}
+		ids[sourceID] = taskID
+	}
+
+	return ids, rows.Err()
+}
@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"context"
+	"skillsrock-test-task/internal/database/postgres"
+	"skillsrock-test-task/internal/models"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+const claimDeliveriesQuery = `
+WITH due AS (
+	SELECT id FROM webhook_deliveries
+	WHERE status = 'pending' AND next_attempt_at <= $1
+	ORDER BY next_attempt_at
+	LIMIT $2
+	FOR UPDATE SKIP LOCKED
+)
+UPDATE webhook_deliveries d
+SET next_attempt_at = $3
+FROM due, webhooks w
+WHERE d.id = due.id AND w.id = d.webhook_id
+RETURNING d.id, d.webhook_id, d.event, d.payload, d.attempts, w.url, w.secret`
+
+const enqueueDeliveriesQuery = `
+INSERT INTO webhook_deliveries (webhook_id, event, payload, next_attempt_at, created_at, updated_at)
+SELECT id, $1, $2, $3, $3, $3 FROM webhooks
+WHERE active AND $1 = ANY(events)`
+
+type WebhookRepository struct {
+	db sq.StatementBuilderType
+	pg *postgres.Database
+}
+
+func NewWebhookRepository(pg *postgres.Database) *WebhookRepository {
+	return &WebhookRepository{
+		db: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+		pg: pg,
+	}
+}
+
+func (r *WebhookRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) (uint64, error) {
+	query := r.db.
+		Insert("webhooks").
+		Columns("owner", "url", "events", "secret", "active", "created_at").
+		Values(webhook.Owner, webhook.URL, webhook.Events, webhook.Secret, webhook.Active, webhook.CreatedAt).
+		Suffix("RETURNING id")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var id uint64
+	err = r.pg.Pool.QueryRow(ctx, sql, args...).Scan(&id)
+	return id, err
+}
+
+func (r *WebhookRepository) GetWebhooks(ctx context.Context, owner string) ([]*models.Webhook, error) {
+	query := r.db.
+		Select("id", "owner", "url", "events", "active", "created_at").
+		From("webhooks").
+		Where(sq.Eq{"owner": owner}).
+		OrderBy("id ASC")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := make([]*models.Webhook, 0)
+	for rows.Next() {
+		var webhook models.Webhook
+		if err := rows.Scan(
+			&webhook.ID,
+			&webhook.Owner,
+			&webhook.URL,
+			&webhook.Events,
+			&webhook.Active,
+			&webhook.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, &webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+func (r *WebhookRepository) DeleteWebhook(ctx context.Context, owner string, id uint64) error {
+	query := r.db.
+		Delete("webhooks").
+		Where(sq.Eq{"id": id, "owner": owner})
+
+	return execAffectingOne(ctx, r.pg, query)
+}
+
+// EnqueueDeliveries creates a pending delivery for every active webhook subscribed to the event.
+func (r *WebhookRepository) EnqueueDeliveries(ctx context.Context, event string, payload []byte, now time.Time) (int64, error) {
+	cmdTag, err := r.pg.Pool.Exec(ctx, enqueueDeliveriesQuery, event, payload, now)
+	if err != nil {
+		return 0, err
+	}
+	return cmdTag.RowsAffected(), nil
+}
+
+// ClaimDeliveries picks due deliveries and pushes their next attempt to leaseUntil,
+// so that other replicas skip them and a crashed dispatcher's work is retried after the lease.
+func (r *WebhookRepository) ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit uint64) ([]*models.WebhookDelivery, error) {
+	rows, err := r.pg.Pool.Query(ctx, claimDeliveriesQuery, now, limit, leaseUntil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]*models.WebhookDelivery, 0)
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		if err := rows.Scan(
+			&delivery.ID,
+			&delivery.WebhookID,
+			&delivery.Event,
+			&delivery.Payload,
+			&delivery.Attempts,
+			&delivery.URL,
+			&delivery.Secret,
+		); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	query := r.db.
+		Update("webhook_deliveries").
+		SetMap(map[string]interface{}{
+			"status":           delivery.Status,
+			"attempts":         delivery.Attempts,
+			"next_attempt_at":  delivery.NextAttemptAt,
+			"last_status_code": delivery.LastStatusCode,
+			"last_error":       delivery.LastError,
+			"updated_at":       delivery.UpdatedAt,
+		}).
+		Where(sq.Eq{"id": delivery.ID})
+
+	return execAffectingOne(ctx, r.pg, query)
+}
+
+// GetDeliveries lists the deliveries of a webhook of the owner, the webhooks of others are not found.
+func (r *WebhookRepository) GetDeliveries(ctx context.Context, owner string, webhookID, before, limit uint64) ([]*models.WebhookDelivery, error) {
+	query := r.db.
+		Select(
+			"id", "webhook_id", "event", "payload", "status", "attempts", "next_attempt_at",
+			"last_status_code", "COALESCE(last_error, '')", "created_at", "updated_at",
+		).
+		From("webhook_deliveries").
+		Where(sq.Eq{"webhook_id": webhookID}).
+		Where(ownedWebhook(owner)).
+		OrderBy("id DESC").
+		Limit(limit)
+
+	if before != 0 {
+		query = query.Where(sq.Lt{"id": before})
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]*models.WebhookDelivery, 0)
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		if err := rows.Scan(
+			&delivery.ID,
+			&delivery.WebhookID,
+			&delivery.Event,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.NextAttemptAt,
+			&delivery.LastStatusCode,
+			&delivery.LastError,
+			&delivery.CreatedAt,
+			&delivery.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(deliveries) == 0 {
+		exists, err := r.webhookExists(ctx, owner, webhookID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, models.ErrNotFound
+		}
+	}
+
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) webhookExists(ctx context.Context, owner string, id uint64) (bool, error) {
+	var exists bool
+	err := r.pg.Pool.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM webhooks WHERE id = $1 AND owner = $2)", id, owner).Scan(&exists)
+	return exists, err
+}
+
+// ownedWebhook matches the deliveries of the webhooks of the owner.
+func ownedWebhook(owner string) sq.Sqlizer {
+	return sq.Expr("webhook_id IN (SELECT id FROM webhooks WHERE owner = ?)", owner)
+}
+
+// Redeliver queues a copy of an existing delivery of a webhook of the owner, the original stays in the log untouched.
+func (r *WebhookRepository) Redeliver(ctx context.Context, owner string, webhookID, deliveryID uint64, now time.Time) (uint64, error) {
+	query := r.db.
+		Insert("webhook_deliveries").
+		Columns("webhook_id", "event", "payload", "next_attempt_at", "created_at", "updated_at").
+		Select(r.db.
+			Select("webhook_id", "event", "payload").
+			Column(sq.Expr("?::timestamp, ?::timestamp, ?::timestamp", now, now, now)).
+			From("webhook_deliveries").
+			Where(sq.Eq{"id": deliveryID, "webhook_id": webhookID}).
+			Where(ownedWebhook(owner)),
+		).
+		Suffix("RETURNING id")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var id uint64
+	err = r.pg.Pool.QueryRow(ctx, sql, args...).Scan(&id)
+	if err == pgx.ErrNoRows {
+		return 0, models.ErrNotFound
+	}
+	return id, err
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"skillsrock-test-task/internal/dto"
+	"skillsrock-test-task/internal/models"
+	"skillsrock-test-task/pkg/blobstore"
+	"skillsrock-test-task/pkg/logger"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	sniffLength       = 512
+	orphanedBlobBatch = 100
+)
+
+type AttachmentRepository interface {
+	TaskExists(ctx context.Context, taskID uint64) (bool, error)
+	CreateAttachment(ctx context.Context, attachment *models.Attachment) (uint64, error)
+	GetAttachments(ctx context.Context, taskID uint64) ([]*models.Attachment, error)
+	GetAttachmentsByTaskIDs(ctx context.Context, taskIDs []uint64) ([]*models.Attachment, error)
+	GetAttachment(ctx context.Context, taskID, attachmentID uint64) (*models.Attachment, error)
+	DeleteAttachment(ctx context.Context, taskID, attachmentID uint64) error
+	GetOrphanedBlobs(ctx context.Context, limit uint64) ([]string, error)
+	DeleteOrphanedBlob(ctx context.Context, key string) error
+}
+
+type AttachmentService struct {
+	repo         AttachmentRepository
+	store        blobstore.BlobStore
+	maxSize      int64
+	allowedTypes []string
+}
+
+func NewAttachmentService(repo AttachmentRepository, store blobstore.BlobStore, maxSize int64, allowedTypes []string) *AttachmentService {
+	return &AttachmentService{
+		repo:         repo,
+		store:        store,
+		maxSize:      maxSize,
+		allowedTypes: allowedTypes,
+	}
+}
+
+func (s *AttachmentService) UploadAttachment(ctx context.Context, taskIDStr string, upload *dto.UploadAttachmentRequest) (*dto.UploadAttachmentResponse, error) {
+	taskID, err := strconv.ParseUint(taskIDStr, 10, 64)
+	if err != nil {
+		return nil, models.ErrFailedToParseID
+	}
+
+	uploader := strings.TrimSpace(upload.Uploader)
+	if uploader == "" {
+		return nil, models.NewFieldError("uploader", models.ErrEmptyUploader)
+	}
+
+	if upload.Size > s.maxSize {
+		return nil, models.ErrFileTooLarge
+	}
+
+	exists, err := s.repo.TaskExists(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, models.ErrNotFound
+	}
+
+	// The declared Content-Type of a multipart part is client-controlled, so the type is sniffed from the content.
+	reader := bufio.NewReaderSize(upload.File, sniffLength)
+	head, err := reader.Peek(sniffLength)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil || !s.isAllowedType(contentType) {
+		return nil, models.ErrUnsupportedFileType
+	}
+
+	key, err := newStorageKey(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	if err := s.store.Put(ctx, key, io.TeeReader(reader, hash), upload.Size, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store the file: %w", err)
+	}
+
+	attachment := &models.Attachment{
+		TaskID:      taskID,
+		Filename:    path.Base(upload.Filename),
+		ContentType: contentType,
+		Size:        upload.Size,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		Uploader:    uploader,
+		StorageKey:  key,
+		CreatedAt:   time.Now(),
+	}
+
+	attachment.ID, err = s.repo.CreateAttachment(ctx, attachment)
+	if err != nil {
+		_ = s.store.Delete(context.Background(), key)
+		return nil, err
+	}
+
+	return &dto.UploadAttachmentResponse{
+		Attachment: attachment,
+	}, nil
+}
+
+func (s *AttachmentService) GetAttachments(ctx context.Context, taskIDStr string) (*dto.GetAttachmentsResponse, error) {
+	taskID, err := strconv.ParseUint(taskIDStr, 10, 64)
+	if err != nil {
+		return nil, models.ErrFailedToParseID
+	}
+
+	attachments, err := s.repo.GetAttachments(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.GetAttachmentsResponse{
+		Attachments: attachments,
+	}, nil
+}
+
+// GetAttachmentsByTaskIDs loads the attachments of several tasks at once, grouped by task ID.
+func (s *AttachmentService) GetAttachmentsByTaskIDs(ctx context.Context, taskIDs []uint64) (map[uint64][]*models.Attachment, error) {
+	attachments, err := s.repo.GetAttachmentsByTaskIDs(ctx, taskIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byTask := make(map[uint64][]*models.Attachment, len(taskIDs))
+	for _, attachment := range attachments {
+		byTask[attachment.TaskID] = append(byTask[attachment.TaskID], attachment)
+	}
+
+	return byTask, nil
+}
+
+func (s *AttachmentService) GetAttachment(ctx context.Context, taskIDStr, attachmentIDStr string) (*models.Attachment, error) {
+	taskID, attachmentID, err := parseAttachmentIDs(taskIDStr, attachmentIDStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetAttachment(ctx, taskID, attachmentID)
+}
+
+func (s *AttachmentService) OpenAttachment(ctx context.Context, attachment *models.Attachment, offset, length int64) (io.ReadCloser, error) {
+	if offset < 0 || offset > attachment.Size || (length >= 0 && offset+length > attachment.Size) {
+		return nil, models.ErrInvalidRange
+	}
+
+	reader, err := s.store.Get(ctx, attachment.StorageKey, offset, length)
+	if err == blobstore.ErrNotFound {
+		return nil, models.ErrNotFound
+	}
+	return reader, err
+}
+
+func (s *AttachmentService) DeleteAttachment(ctx context.Context, taskIDStr, attachmentIDStr string) error {
+	taskID, attachmentID, err := parseAttachmentIDs(taskIDStr, attachmentIDStr)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DeleteAttachment(ctx, taskID, attachmentID)
+}
+
+// RunCleanup removes blobs of deleted attachments until the context is cancelled.
+// Attachments disappear together with their task through the foreign key cascade,
+// so blob removal is driven by the database rather than by the delete handlers.
+func (s *AttachmentService) RunCleanup(ctx context.Context, interval time.Duration) {
+	log := logger.GetLoggerFromCtx(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.purgeOrphanedBlobs(ctx); err != nil && ctx.Err() == nil {
+			log.Error(ctx, "Failed to purge orphaned blobs", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *AttachmentService) purgeOrphanedBlobs(ctx context.Context) error {
+	for {
+		keys, err := s.repo.GetOrphanedBlobs(ctx, orphanedBlobBatch)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if err := s.store.Delete(ctx, key); err != nil {
+				return fmt.Errorf("failed to delete blob %s: %w", key, err)
+			}
+
+			if err := s.repo.DeleteOrphanedBlob(ctx, key); err != nil {
+				return err
+			}
+		}
+
+		if len(keys) < orphanedBlobBatch {
+			return nil
+		}
+	}
+}
+
+func (s *AttachmentService) isAllowedType(contentType string) bool {
+	for _, allowed := range s.allowedTypes {
+		if allowed == contentType {
+			return true
+		}
+
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseAttachmentIDs(taskIDStr, attachmentIDStr string) (uint64, uint64, error) {
+	taskID, err := strconv.ParseUint(taskIDStr, 10, 64)
+	if err != nil {
+		return 0, 0, models.ErrFailedToParseID
+	}
+
+	attachmentID, err := strconv.ParseUint(attachmentIDStr, 10, 64)
+	if err != nil {
+		return 0, 0, models.ErrFailedToParseAttachmentID
+	}
+
+	return taskID, attachmentID, nil
+}
+
+func newStorageKey(taskID uint64) (string, error) {
+	suffix := make([]byte, 16)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("tasks/%d/%s", taskID, hex.EncodeToString(suffix)), nil
+}
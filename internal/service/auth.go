@@ -0,0 +1,50 @@
+package service
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"skillsrock-test-task/internal/models"
+	"strings"
+)
+
+// AuthService authenticates clients by static bearer tokens configured as "token:user" pairs.
+// Tokens are kept hashed so that looking them up does not depend on how much of a token matched.
+type AuthService struct {
+	users map[[sha256.Size]byte]string
+}
+
+func NewAuthService(tokens string) (*AuthService, error) {
+	users := make(map[[sha256.Size]byte]string)
+
+	for _, pair := range strings.Split(tokens, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		token, user, ok := strings.Cut(pair, ":")
+		if !ok || token == "" || user == "" {
+			return nil, fmt.Errorf("invalid auth token entry, expected token:user")
+		}
+
+		users[sha256.Sum256([]byte(token))] = user
+	}
+
+	return &AuthService{
+		users: users,
+	}, nil
+}
+
+// Authenticate returns the user the token belongs to.
+func (s *AuthService) Authenticate(token string) (string, error) {
+	if token == "" {
+		return "", models.ErrUnauthorized
+	}
+
+	user, ok := s.users[sha256.Sum256([]byte(token))]
+	if !ok {
+		return "", models.ErrUnauthorized
+	}
+
+	return user, nil
+}
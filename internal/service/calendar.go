@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"skillsrock-test-task/internal/dto"
+	"skillsrock-test-task/internal/models"
+	"slices"
+	"strings"
+)
+
+// GetCalendar returns the tasks with a due date of a calendar feed. The version is read first and the tasks
+// only when the client does not have it, so polling an unchanged calendar costs one aggregate query.
+func (s *TaskService) GetCalendar(ctx context.Context, req *dto.GetCalendarRequest) (*dto.GetCalendarResponse, error) {
+	filter, err := calendarFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := s.repo.GetDueTasksVersion(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &dto.GetCalendarResponse{
+		Version: calendarVersion(version),
+	}
+	if slices.Contains(req.Versions, res.Version) {
+		res.NotModified = true
+		return res, nil
+	}
+
+	// A task changed after the version was read is sent with the older version, the next poll fetches it again.
+	if res.Tasks, err = s.repo.GetDueTasks(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func calendarFilter(req *dto.GetCalendarRequest) (*models.CalendarFilter, error) {
+	filter := &models.CalendarFilter{
+		Owner: req.Owner,
+	}
+
+	if req.Status != "" {
+		for _, status := range strings.Split(req.Status, ",") {
+			status = strings.TrimSpace(status)
+			if !isValidStatus(status) {
+				return nil, models.ErrInvalidStatus
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	if req.From != "" {
+		from, err := parseDueAt(req.From)
+		if err != nil {
+			return nil, fmt.Errorf("%w: from: %w", models.ErrInvalidDueRange, err)
+		}
+		filter.DueFrom = &from
+	}
+	if req.To != "" {
+		to, err := parseDueAt(req.To)
+		if err != nil {
+			return nil, fmt.Errorf("%w: to: %w", models.ErrInvalidDueRange, err)
+		}
+		filter.DueTo = &to
+	}
+	if filter.DueFrom != nil && filter.DueTo != nil && !filter.DueFrom.Before(*filter.DueTo) {
+		return nil, fmt.Errorf("%w: from must be before to", models.ErrInvalidDueRange)
+	}
+
+	return filter, nil
+}
+
+func calendarVersion(version *models.TasksVersion) string {
+	updatedAt := int64(0)
+	if version.UpdatedAt != nil {
+		updatedAt = version.UpdatedAt.UnixMicro()
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%d:%d", version.Count, version.IDSum, updatedAt)))
+	return hex.EncodeToString(sum[:12])
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"skillsrock-test-task/internal/dto"
+	"skillsrock-test-task/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCommentsLimit = 20
+	maxCommentsLimit     = 100
+)
+
+type CommentRepository interface {
+	CreateComment(ctx context.Context, comment *models.Comment) (uint64, error)
+	GetComments(ctx context.Context, taskID, after, limit uint64) ([]*models.Comment, error)
+	GetFirstComments(ctx context.Context, taskIDs []uint64, limit uint64) ([]*models.Comment, error)
+	UpdateComment(ctx context.Context, taskID, commentID uint64, body string, updatedAt time.Time) error
+	DeleteComment(ctx context.Context, taskID, commentID uint64, deletedAt time.Time) error
+}
+
+type CommentService struct {
+	repo CommentRepository
+}
+
+func NewCommentService(repo CommentRepository) *CommentService {
+	return &CommentService{
+		repo: repo,
+	}
+}
+
+func (s *CommentService) CreateComment(ctx context.Context, taskIDStr string, comment *dto.CreateCommentRequest) (*dto.CreateCommentResponse, error) {
+	taskID, err := strconv.ParseUint(taskIDStr, 10, 64)
+	if err != nil {
+		return nil, models.ErrFailedToParseID
+	}
+
+	author := strings.TrimSpace(comment.Author)
+	if author == "" {
+		return nil, models.NewFieldError("author", models.ErrEmptyAuthor)
+	}
+
+	if strings.TrimSpace(comment.Body) == "" {
+		return nil, models.NewFieldError("body", models.ErrEmptyCommentBody)
+	}
+
+	now := time.Now()
+
+	id, err := s.repo.CreateComment(ctx, &models.Comment{
+		TaskID:    taskID,
+		ReplyTo:   comment.ReplyTo,
+		Author:    author,
+		Body:      comment.Body,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.CreateCommentResponse{
+		ID:        id,
+		CreatedAt: now,
+	}, nil
+}
+
+func (s *CommentService) GetComments(ctx context.Context, taskIDStr, cursor, limitStr string) (*dto.GetCommentsResponse, error) {
+	taskID, err := strconv.ParseUint(taskIDStr, 10, 64)
+	if err != nil {
+		return nil, models.ErrFailedToParseID
+	}
+
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := strconv.ParseUint(limitStr, 10, 64)
+	if err != nil && limitStr != "" {
+		return nil, models.ErrFailedToParseLimit
+	}
+
+	if limit == 0 {
+		limit = defaultCommentsLimit
+	}
+	if limit > maxCommentsLimit {
+		limit = maxCommentsLimit
+	}
+
+	// One extra row tells whether there is a next page without a separate count query.
+	comments, err := s.repo.GetComments(ctx, taskID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &dto.GetCommentsResponse{
+		Comments: comments,
+	}
+
+	if uint64(len(comments)) > limit {
+		res.Comments = comments[:limit]
+		res.NextCursor = encodeCursor(res.Comments[limit-1].ID)
+	}
+
+	return res, nil
+}
+
+// GetFirstComments loads the first comments of several tasks at once, grouped by task ID.
+func (s *CommentService) GetFirstComments(ctx context.Context, taskIDs []uint64, limit uint64) (map[uint64][]*models.Comment, error) {
+	if limit == 0 {
+		limit = defaultCommentsLimit
+	}
+	if limit > maxCommentsLimit {
+		limit = maxCommentsLimit
+	}
+
+	comments, err := s.repo.GetFirstComments(ctx, taskIDs, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	byTask := make(map[uint64][]*models.Comment, len(taskIDs))
+	for _, comment := range comments {
+		byTask[comment.TaskID] = append(byTask[comment.TaskID], comment)
+	}
+
+	return byTask, nil
+}
+
+func (s *CommentService) UpdateComment(ctx context.Context, taskIDStr, commentIDStr string, comment *dto.UpdateCommentRequest) error {
+	taskID, commentID, err := parseCommentIDs(taskIDStr, commentIDStr)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(comment.Body) == "" {
+		return models.NewFieldError("body", models.ErrEmptyCommentBody)
+	}
+
+	return s.repo.UpdateComment(ctx, taskID, commentID, comment.Body, time.Now())
+}
+
+func (s *CommentService) DeleteComment(ctx context.Context, taskIDStr, commentIDStr string) error {
+	taskID, commentID, err := parseCommentIDs(taskIDStr, commentIDStr)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DeleteComment(ctx, taskID, commentID, time.Now())
+}
+
+func parseCommentIDs(taskIDStr, commentIDStr string) (uint64, uint64, error) {
+	taskID, err := strconv.ParseUint(taskIDStr, 10, 64)
+	if err != nil {
+		return 0, 0, models.ErrFailedToParseID
+	}
+
+	commentID, err := strconv.ParseUint(commentIDStr, 10, 64)
+	if err != nil {
+		return 0, 0, models.ErrFailedToParseCommentID
+	}
+
+	return taskID, commentID, nil
+}
+
+func encodeCursor(id uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(id, 10)))
+}
+
+func decodeCursor(cursor string) (uint64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, models.ErrFailedToParseCursor
+	}
+
+	id, err := strconv.ParseUint(string(raw), 10, 64)
+	if err != nil {
+		return 0, models.ErrFailedToParseCursor
+	}
+
+	return id, nil
+}
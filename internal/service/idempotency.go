@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"skillsrock-test-task/internal/models"
+	"skillsrock-test-task/pkg/logger"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// inFlightTimeout bounds how long a reservation blocks retries if the process dies before completing it.
+const inFlightTimeout = time.Minute
+
+type IdempotencyRepository interface {
+	ReserveKey(ctx context.Context, record *models.IdempotencyRecord) (bool, error)
+	GetKey(ctx context.Context, scope, key string) (*models.IdempotencyRecord, error)
+	CompleteKey(ctx context.Context, record *models.IdempotencyRecord) error
+	ReleaseKey(ctx context.Context, scope, key string) error
+	DeleteExpiredKeys(ctx context.Context, now time.Time) (int64, error)
+}
+
+type IdempotencyService struct {
+	repo IdempotencyRepository
+	ttl  time.Duration
+}
+
+func NewIdempotencyService(repo IdempotencyRepository, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{
+		repo: repo,
+		ttl:  ttl,
+	}
+}
+
+// Begin reserves the key for a new request. It returns the stored response when the request was already completed.
+func (s *IdempotencyService) Begin(ctx context.Context, scope, key, fingerprint string) (*models.IdempotencyRecord, error) {
+	now := time.Now()
+
+	reserved, err := s.repo.ReserveKey(ctx, &models.IdempotencyRecord{
+		Scope:       scope,
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(inFlightTimeout),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	record, err := s.repo.GetKey(ctx, scope, key)
+	if err == models.ErrNotFound {
+		// The record expired and was purged between the two queries, the client may simply retry.
+		return nil, models.ErrIdempotencyKeyInFlight
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if record.Fingerprint != fingerprint {
+		return nil, models.ErrIdempotencyKeyReused
+	}
+	if !record.Completed {
+		return nil, models.ErrIdempotencyKeyInFlight
+	}
+
+	return record, nil
+}
+
+func (s *IdempotencyService) Complete(ctx context.Context, scope, key string, statusCode int, contentType string, body []byte) error {
+	return s.repo.CompleteKey(ctx, &models.IdempotencyRecord{
+		Scope:       scope,
+		Key:         key,
+		StatusCode:  statusCode,
+		ContentType: contentType,
+		Body:        body,
+		ExpiresAt:   time.Now().Add(s.ttl),
+	})
+}
+
+// Abort frees the key so that a failed request can be retried with it.
+func (s *IdempotencyService) Abort(ctx context.Context, scope, key string) error {
+	return s.repo.ReleaseKey(ctx, scope, key)
+}
+
+func (s *IdempotencyService) RunCleanup(ctx context.Context, interval time.Duration) {
+	log := logger.GetLoggerFromCtx(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := s.repo.DeleteExpiredKeys(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			log.Error(ctx, "Failed to delete expired idempotency keys", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,621 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"skillsrock-test-task/internal/dto"
+	"skillsrock-test-task/internal/models"
+	"skillsrock-test-task/pkg/blobstore"
+	"skillsrock-test-task/pkg/logger"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+
+	importBatchSize   = 1000
+	importMaxErrors   = 100
+	importLease       = 5 * time.Minute
+	importMaxAttempts = 3
+	importMaxLineSize = 1 << 20
+)
+
+// importFields are the task fields a file can set, a column of the same name is used unless it is mapped.
+var importFields = []string{"title", "description", "owner", "status", "due_at"}
+
+type ImportRepository interface {
+	ImportTasks(ctx context.Context, fn func(insert func(tasks []*models.Task) error) error) error
+	CreateImportJob(ctx context.Context, job *models.ImportJob) (uint64, error)
+	GetImportJob(ctx context.Context, id uint64) (*models.ImportJob, error)
+	ClaimImportJob(ctx context.Context, now, leaseUntil time.Time) (*models.ImportJob, error)
+	UpdateImportJob(ctx context.Context, job *models.ImportJob, leaseUntil time.Time) error
+}
+
+type ImportService struct {
+	repo        ImportRepository
+	tasks       ExternalTaskRepository
+	store       blobstore.BlobStore
+	maxSize     int64
+	syncMaxSize int64
+	logger      logger.Logger
+	wake        chan struct{}
+}
+
+func NewImportService(repo ImportRepository, tasks ExternalTaskRepository, store blobstore.BlobStore, maxSize, syncMaxSize int64, log logger.Logger) *ImportService {
+	return &ImportService{
+		repo:        repo,
+		tasks:       tasks,
+		store:       store,
+		maxSize:     maxSize,
+		syncMaxSize: syncMaxSize,
+		logger:      log,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// ImportTasks imports a CSV or NDJSON file. Files up to syncMaxSize are imported right away and
+// the report is returned, larger files are stored and imported by a background job.
+func (s *ImportService) ImportTasks(ctx context.Context, req *dto.ImportTasksRequest) (*dto.ImportTasksResponse, error) {
+	format, err := importFormat(req.Format, req.Filename)
+	if err != nil {
+		return nil, err
+	}
+
+	mapping, err := parseImportMapping(req.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Size > s.maxSize {
+		return nil, models.ErrFileTooLarge
+	}
+
+	if req.Size <= s.syncMaxSize {
+		report, err := s.importFile(ctx, req.File, format, mapping, req.DryRun, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		return &dto.ImportTasksResponse{
+			Report: report,
+		}, nil
+	}
+
+	key, err := newImportKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Put(ctx, key, req.File, req.Size, "application/octet-stream"); err != nil {
+		return nil, fmt.Errorf("failed to store the file: %w", err)
+	}
+
+	job := &models.ImportJob{
+		Status:     models.ImportPending,
+		Format:     format,
+		Mapping:    mapping,
+		Report:     models.ImportReport{DryRun: req.DryRun, Errors: []models.ImportRowError{}},
+		CreatedAt:  time.Now(),
+		StorageKey: key,
+	}
+
+	job.ID, err = s.repo.CreateImportJob(ctx, job)
+	if err != nil {
+		_ = s.store.Delete(context.Background(), key)
+		return nil, err
+	}
+
+	s.wakeWorker()
+
+	return &dto.ImportTasksResponse{
+		Job: job,
+	}, nil
+}
+
+func (s *ImportService) GetImportJob(ctx context.Context, idStr string) (*dto.GetImportJobResponse, error) {
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, models.ErrFailedToParseImportJobID
+	}
+
+	job, err := s.repo.GetImportJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.GetImportJobResponse{
+		Job: job,
+	}, nil
+}
+
+// RunWorker imports the files of pending jobs until the context is cancelled. It polls on the interval
+// and is woken up early when this instance creates a job. A job whose instance stopped is resumed
+// from the start once its lease expired, the tasks of an import are committed together.
+func (s *ImportService) RunWorker(ctx context.Context, interval time.Duration) {
+	log := logger.GetLoggerFromCtx(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.runPending(ctx); err != nil && ctx.Err() == nil {
+			log.Error(ctx, "Failed to run import jobs", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+func (s *ImportService) wakeWorker() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *ImportService) runPending(ctx context.Context) error {
+	for {
+		now := time.Now()
+
+		job, err := s.repo.ClaimImportJob(ctx, now, now.Add(importLease))
+		if errors.Is(err, models.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		s.runJob(ctx, job)
+	}
+}
+
+func (s *ImportService) runJob(ctx context.Context, job *models.ImportJob) {
+	var err error
+	if job.Attempts > importMaxAttempts {
+		err = errors.New("import was interrupted too many times")
+	} else {
+		var report *models.ImportReport
+		report, err = s.importStoredFile(ctx, job)
+		if report != nil {
+			job.Report = *report
+		}
+	}
+
+	// The job is resumed by another instance when this one is shutting down.
+	if ctx.Err() != nil {
+		return
+	}
+
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Status = models.ImportSucceeded
+
+	if err != nil {
+		job.Status = models.ImportFailed
+		job.Report.ImportedRows = 0
+		job.Error = importErrorMessage(err)
+
+		if job.Error == "unknown error occurred" {
+			s.logger.Error(ctx, "Failed to import a file", zap.Uint64("job_id", job.ID), zap.Error(err))
+		}
+	}
+
+	if err := s.repo.UpdateImportJob(ctx, job, now); err != nil {
+		s.logger.Error(ctx, "Failed to save the import job", zap.Uint64("job_id", job.ID), zap.Error(err))
+		return
+	}
+
+	if err := s.store.Delete(ctx, job.StorageKey); err != nil && !errors.Is(err, blobstore.ErrNotFound) {
+		s.logger.Error(ctx, "Failed to delete an imported file", zap.String("key", job.StorageKey), zap.Error(err))
+	}
+}
+
+// importStoredFile imports the file of a job, the report is saved after every batch so that it can be
+// polled and the lease of the job is renewed.
+func (s *ImportService) importStoredFile(ctx context.Context, job *models.ImportJob) (*models.ImportReport, error) {
+	file, err := s.store.Get(ctx, job.StorageKey, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return s.importFile(ctx, file, job.Format, job.Mapping, job.Report.DryRun, func(report *models.ImportReport) error {
+		job.Report = *report
+		return s.repo.UpdateImportJob(ctx, job, time.Now().Add(importLease))
+	})
+}
+
+// importFile validates every row and inserts the valid ones in batches. Rows that are not valid are
+// skipped and reported, a dry run only reports. An error that is not about a row fails the import.
+func (s *ImportService) importFile(
+	ctx context.Context,
+	r io.Reader,
+	format string,
+	mapping map[string]string,
+	dryRun bool,
+	progress func(report *models.ImportReport) error,
+) (*models.ImportReport, error) {
+	rows, err := newRowReader(r, format, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ImportReport{
+		DryRun: dryRun,
+		Errors: []models.ImportRowError{},
+	}
+
+	importRows := func(insert func(tasks []*models.Task) error) error {
+		now := time.Now()
+		batch := make([]*models.Task, 0, importBatchSize)
+
+		flush := func() error {
+			if len(batch) > 0 {
+				if err := insert(batch); err != nil {
+					return err
+				}
+				report.ImportedRows += uint64(len(batch))
+				batch = batch[:0]
+			}
+
+			if progress != nil {
+				return progress(report)
+			}
+			return nil
+		}
+
+		for {
+			row, err := rows.next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			report.TotalRows++
+
+			task, rowErrs := importTask(row, now)
+			if len(rowErrs) > 0 {
+				report.FailedRows++
+				for _, rowErr := range rowErrs {
+					if len(report.Errors) < importMaxErrors {
+						report.Errors = append(report.Errors, rowErr)
+					}
+				}
+				continue
+			}
+
+			batch = append(batch, task)
+			if len(batch) == importBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+
+		return flush()
+	}
+
+	if dryRun {
+		err = importRows(func([]*models.Task) error { return nil })
+	} else {
+		err = s.repo.ImportTasks(ctx, importRows)
+	}
+	if err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
+
+// importTask applies the rules of CreateTask to a row, a row may set the status as well. Every invalid field of
+// the row is reported.
+func importTask(row *importRow, now time.Time) (*models.Task, []models.ImportRowError) {
+	if row.err != nil {
+		return nil, []models.ImportRowError{{Row: row.line, Error: row.err.Error()}}
+	}
+
+	req := &dto.CreateTaskRequest{
+		Title:       row.fields["title"],
+		Description: row.fields["description"],
+		Owner:       row.fields["owner"],
+	}
+
+	var rowErrs []models.ImportRowError
+	if err := dto.Validate(req); err != nil {
+		rowErrs = append(rowErrs, importRowErrors(row.line, err)...)
+	}
+
+	status := row.fields["status"]
+	if status != "" && !isValidStatus(status) {
+		rowErrs = append(rowErrs, models.ImportRowError{Row: row.line, Field: "status", Error: models.ErrInvalidStatus.Error()})
+	}
+
+	if value := row.fields["due_at"]; value != "" {
+		dueAt, err := parseDueAt(value)
+		if err != nil {
+			rowErrs = append(rowErrs, models.ImportRowError{Row: row.line, Field: "due_at", Error: err.Error()})
+		} else {
+			req.DueAt = &dueAt
+		}
+	}
+
+	if len(rowErrs) > 0 {
+		return nil, rowErrs
+	}
+
+	task, err := newTask(req, now)
+	if err != nil {
+		return nil, importRowErrors(row.line, err)
+	}
+	if status != "" {
+		task.Status = status
+	}
+
+	return task, nil
+}
+
+// importRowErrors reports the fields of a validation error one by one, other errors are about the whole row.
+func importRowErrors(line uint64, err error) []models.ImportRowError {
+	var validationErr *models.ValidationError
+	if !errors.As(err, &validationErr) {
+		return []models.ImportRowError{{Row: line, Error: err.Error()}}
+	}
+
+	rowErrs := make([]models.ImportRowError, len(validationErr.Fields))
+	for i, field := range validationErr.Fields {
+		rowErrs[i] = models.ImportRowError{Row: line, Field: field.Field, Error: field.Err.Error()}
+	}
+
+	return rowErrs
+}
+
+// parseDueAt accepts an RFC 3339 time or a date, which is due at its start in UTC.
+func parseDueAt(value string) (time.Time, error) {
+	if dueAt, err := time.Parse(time.RFC3339, value); err == nil {
+		return dueAt, nil
+	}
+	if dueAt, err := time.Parse(time.DateOnly, value); err == nil {
+		return dueAt, nil
+	}
+	return time.Time{}, fmt.Errorf("due date must be an RFC 3339 time or a date like 2006-01-02")
+}
+
+func importFormat(format, filename string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(path.Ext(filename)) {
+		case ".csv":
+			format = ImportFormatCSV
+		case ".ndjson", ".jsonl":
+			format = ImportFormatNDJSON
+		}
+	}
+
+	if format != ImportFormatCSV && format != ImportFormatNDJSON {
+		return "", models.ErrInvalidImportFormat
+	}
+
+	return format, nil
+}
+
+// parseImportMapping returns the column of every task field, fields that are not mapped use their own name.
+func parseImportMapping(raw string) (map[string]string, error) {
+	mapping := make(map[string]string, len(importFields))
+	for _, field := range importFields {
+		mapping[field] = field
+	}
+
+	if raw == "" {
+		return mapping, nil
+	}
+
+	var custom map[string]string
+	if err := json.Unmarshal([]byte(raw), &custom); err != nil {
+		return nil, fmt.Errorf("%w: mapping must be a JSON object of strings", models.ErrInvalidImportMapping)
+	}
+
+	for field, column := range custom {
+		if _, ok := mapping[field]; !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", models.ErrInvalidImportMapping, field)
+		}
+		if strings.TrimSpace(column) == "" {
+			return nil, fmt.Errorf("%w: column of %q is empty", models.ErrInvalidImportMapping, field)
+		}
+		mapping[field] = column
+	}
+
+	return mapping, nil
+}
+
+// importErrorMessage exposes the errors about the file, like a missing column, and hides the others.
+func importErrorMessage(err error) string {
+	if errors.Is(err, models.ErrInvalidImportMapping) || errors.Is(err, models.ErrInvalidImportFile) {
+		return err.Error()
+	}
+
+	return "unknown error occurred"
+}
+
+func newImportKey() (string, error) {
+	suffix := make([]byte, 16)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+
+	return "imports/" + hex.EncodeToString(suffix), nil
+}
+
+// importRow holds the task fields of a row, err is set when the row could not be parsed.
+type importRow struct {
+	line   uint64
+	fields map[string]string
+	err    error
+}
+
+type rowReader interface {
+	// next returns io.EOF after the last row.
+	next() (*importRow, error)
+}
+
+func newRowReader(r io.Reader, format string, mapping map[string]string) (rowReader, error) {
+	if format == ImportFormatCSV {
+		return newCSVRowReader(r, mapping)
+	}
+
+	return newNDJSONRowReader(r, mapping), nil
+}
+
+// csvRowReader reads a CSV file whose first line names the columns, names are matched ignoring case.
+type csvRowReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVRowReader(r io.Reader, mapping map[string]string) (*csvRowReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: file is empty", models.ErrInvalidImportFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read the header: %v", models.ErrInvalidImportFile, err)
+	}
+
+	names := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		names[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	columns := make(map[string]int, len(mapping))
+	for field, column := range mapping {
+		i, ok := names[strings.ToLower(strings.TrimSpace(column))]
+		if !ok {
+			if field == "title" {
+				return nil, fmt.Errorf("%w: header has no %q column for the title", models.ErrInvalidImportFile, column)
+			}
+			continue
+		}
+		columns[field] = i
+	}
+
+	return &csvRowReader{
+		reader:  reader,
+		columns: columns,
+	}, nil
+}
+
+func (r *csvRowReader) next() (*importRow, error) {
+	record, err := r.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, io.EOF
+	}
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return &importRow{line: uint64(parseErr.StartLine), err: parseErr.Err}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	line, _ := r.reader.FieldPos(0)
+
+	fields := make(map[string]string, len(r.columns))
+	for field, i := range r.columns {
+		if i < len(record) {
+			fields[field] = record[i]
+		}
+	}
+
+	return &importRow{line: uint64(line), fields: fields}, nil
+}
+
+// ndjsonRowReader reads a JSON object per line, blank lines are skipped.
+type ndjsonRowReader struct {
+	scanner *bufio.Scanner
+	mapping map[string]string
+	line    uint64
+}
+
+func newNDJSONRowReader(r io.Reader, mapping map[string]string) *ndjsonRowReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), importMaxLineSize)
+
+	return &ndjsonRowReader{
+		scanner: scanner,
+		mapping: mapping,
+	}
+}
+
+func (r *ndjsonRowReader) next() (*importRow, error) {
+	for r.scanner.Scan() {
+		r.line++
+
+		data := bytes.TrimSpace(r.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		row := &importRow{line: r.line}
+
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(data, &object); err != nil {
+			row.err = errors.New("line is not a JSON object")
+			return row, nil
+		}
+
+		row.fields = make(map[string]string, len(r.mapping))
+		for field, key := range r.mapping {
+			value, ok := object[key]
+			if !ok {
+				continue
+			}
+
+			var s *string
+			if err := json.Unmarshal(value, &s); err != nil {
+				row.err = fmt.Errorf("%q is not a string", key)
+				return row, nil
+			}
+			if s != nil {
+				row.fields[field] = *s
+			}
+		}
+
+		return row, nil
+	}
+
+	if errors.Is(r.scanner.Err(), bufio.ErrTooLong) {
+		return nil, fmt.Errorf("%w: line %d is longer than %d bytes", models.ErrInvalidImportFile, r.line+1, importMaxLineSize)
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"skillsrock-test-task/internal/models"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestImportTaskValidation(t *testing.T) {
+	now := time.Date(2025, 6, 20, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		fields map[string]string
+		want   []string
+	}{
+		{name: "blank title", fields: map[string]string{"title": "  "}, want: []string{"title"}},
+		{name: "long title", fields: map[string]string{"title": strings.Repeat("a", 201)}, want: []string{"title"}},
+		{name: "long owner", fields: map[string]string{"title": "Task", "owner": strings.Repeat("o", 101)}, want: []string{"owner"}},
+		{name: "long description", fields: map[string]string{"title": "Task", "description": strings.Repeat("d", 10001)}, want: []string{"description"}},
+		{name: "every field", fields: map[string]string{
+			"title":  "",
+			"owner":  strings.Repeat("o", 101),
+			"status": "blocked",
+			"due_at": "tomorrow",
+		}, want: []string{"title", "owner", "status", "due_at"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task, rowErrs := importTask(&importRow{line: 7, fields: tt.fields}, now)
+			if task != nil {
+				t.Fatalf("importTask = %+v, want the row rejected", task)
+			}
+
+			var fields []string
+			for _, rowErr := range rowErrs {
+				if rowErr.Row != 7 || rowErr.Error == "" {
+					t.Errorf("row error = %+v, want an error of row 7", rowErr)
+				}
+				fields = append(fields, rowErr.Field)
+			}
+			if !slices.Equal(fields, tt.want) {
+				t.Fatalf("fields of the row errors = %q, want %q", fields, tt.want)
+			}
+		})
+	}
+
+	task, rowErrs := importTask(&importRow{line: 8, fields: map[string]string{
+		"title":  "  Trimmed  ",
+		"owner":  " alice ",
+		"status": "done",
+		"due_at": "2025-07-01",
+	}}, now)
+	if len(rowErrs) > 0 {
+		t.Fatalf("importTask errors = %+v", rowErrs)
+	}
+	if task.Title != "Trimmed" || task.Owner != "alice" || task.Status != "done" || !task.DueAt.Equal(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("importTask = %+v, want the trimmed fields of the row", task)
+	}
+}
+
+func TestExternalTasksValidation(t *testing.T) {
+	s := &ImportService{}
+	report := &models.ExternalImportReport{}
+
+	items := []*externalItem{
+		{sourceID: "1", title: "Valid", state: "To Do"},
+		{sourceID: "2", title: " ", state: "To Do"},
+		{sourceID: "3", title: strings.Repeat("a", 201), description: strings.Repeat("d", 10001), state: "To Do"},
+	}
+
+	tasks := s.externalTasks(items, map[string]string{}, report)
+
+	if len(tasks) != 1 || tasks[0].SourceID != "1" {
+		t.Fatalf("externalTasks imported %d tasks, want only item 1", len(tasks))
+	}
+	if report.Skipped != 2 {
+		t.Fatalf("Skipped = %d, want 2", report.Skipped)
+	}
+
+	want := []models.ExternalImportError{
+		{SourceID: "2", Item: 2, Field: "title"},
+		{SourceID: "3", Item: 3, Field: "title"},
+		{SourceID: "3", Item: 3, Field: "description"},
+	}
+	if len(report.Errors) != len(want) {
+		t.Fatalf("Errors = %+v, want %d errors", report.Errors, len(want))
+	}
+	for i, itemErr := range report.Errors {
+		if itemErr.SourceID != want[i].SourceID || itemErr.Item != want[i].Item || itemErr.Field != want[i].Field || itemErr.Error == "" {
+			t.Errorf("Errors[%d] = %+v, want %+v with a message", i, itemErr, want[i])
+		}
+	}
+}
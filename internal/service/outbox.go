@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"skillsrock-test-task/internal/models"
+	"skillsrock-test-task/pkg/logger"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	outboxBatch     = 100
+	outboxRetention = 24 * time.Hour
+)
+
+type OutboxRepository interface {
+	PublishPending(ctx context.Context, limit uint64, publish func(ctx context.Context, event *models.OutboxEvent) error) (int, error)
+	DeletePublishedEvents(ctx context.Context, before time.Time) (int64, error)
+}
+
+// EventPublisher delivers outbox events to the outside world. An event is published at least once,
+// so a publisher may see the same event again after a failure or a restart.
+type EventPublisher interface {
+	Publish(ctx context.Context, event *models.OutboxEvent) error
+}
+
+type OutboxService struct {
+	repo      OutboxRepository
+	publisher EventPublisher
+}
+
+func NewOutboxService(repo OutboxRepository, publisher EventPublisher) *OutboxService {
+	return &OutboxService{
+		repo:      repo,
+		publisher: publisher,
+	}
+}
+
+// RunRelay publishes the outbox until the context is cancelled. Full batches are relayed back to back,
+// otherwise the outbox is polled on the interval.
+func (s *OutboxService) RunRelay(ctx context.Context, interval time.Duration) {
+	log := logger.GetLoggerFromCtx(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		published, err := s.repo.PublishPending(ctx, outboxBatch, s.publisher.Publish)
+		if err != nil && ctx.Err() == nil {
+			log.Error(ctx, "Failed to publish outbox events", zap.Int("published", published), zap.Error(err))
+		}
+
+		if err == nil && published == outboxBatch {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunCleanup deletes events that were published long enough ago.
+func (s *OutboxService) RunCleanup(ctx context.Context, interval time.Duration) {
+	log := logger.GetLoggerFromCtx(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := s.repo.DeletePublishedEvents(ctx, time.Now().Add(-outboxRetention)); err != nil && ctx.Err() == nil {
+			log.Error(ctx, "Failed to delete published outbox events", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+type viewer struct {
+	user    string
+	updates chan []string
+}
+
+// PresenceService tracks who is viewing which task on this instance. A user viewing a task from
+// several connections is listed once.
+type PresenceService struct {
+	mu      sync.Mutex
+	viewers map[uint64]map[*viewer]struct{}
+}
+
+func NewPresenceService() *PresenceService {
+	return &PresenceService{
+		viewers: make(map[uint64]map[*viewer]struct{}),
+	}
+}
+
+// View registers the user as a viewer of the task until the context is cancelled. The returned channel
+// receives the current list of viewers whenever it changes, only the latest list is kept for slow readers.
+func (s *PresenceService) View(ctx context.Context, taskID uint64, user string) <-chan []string {
+	v := &viewer{
+		user:    user,
+		updates: make(chan []string, 1),
+	}
+
+	s.mu.Lock()
+	if s.viewers[taskID] == nil {
+		s.viewers[taskID] = make(map[*viewer]struct{})
+	}
+	s.viewers[taskID][v] = struct{}{}
+	s.broadcast(taskID)
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		delete(s.viewers[taskID], v)
+		if len(s.viewers[taskID]) == 0 {
+			delete(s.viewers, taskID)
+		} else {
+			s.broadcast(taskID)
+		}
+		close(v.updates)
+	}()
+
+	return v.updates
+}
+
+// broadcast is called with the lock held.
+func (s *PresenceService) broadcast(taskID uint64) {
+	users := make([]string, 0, len(s.viewers[taskID]))
+	for v := range s.viewers[taskID] {
+		if !slices.Contains(users, v.user) {
+			users = append(users, v.user)
+		}
+	}
+	slices.Sort(users)
+
+	for v := range s.viewers[taskID] {
+		select {
+		case <-v.updates:
+		default:
+		}
+		v.updates <- users
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"skillsrock-test-task/internal/models"
+	"skillsrock-test-task/pkg/logger"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	RateLimitRead  = "read"
+	RateLimitWrite = "write"
+)
+
+type RateLimitRepository interface {
+	TakeToken(ctx context.Context, key string, limit models.RateLimit, now time.Time) (*models.RateLimitBucket, error)
+	DeleteIdleBuckets(ctx context.Context, before time.Time) (int64, error)
+}
+
+// RateLimitService gives every client a read and a write budget per route group. Groups without limits of
+// their own share the defaults, but not their buckets.
+type RateLimitService struct {
+	repo     RateLimitRepository
+	defaults map[string]models.RateLimit
+	groups   map[string]models.RateLimit
+	// idle is the longest period, a bucket not used for that long is full again.
+	idle time.Duration
+}
+
+// NewRateLimitService parses the limits, written as "<burst>/<period>", e.g. "60/1m" for bursts of 60 requests
+// refilled at a request per second. Groups overrides them as comma separated "<group>.<read|write>=<limit>" pairs.
+func NewRateLimitService(repo RateLimitRepository, read, write, groups string) (*RateLimitService, error) {
+	s := &RateLimitService{
+		repo:     repo,
+		defaults: make(map[string]models.RateLimit),
+		groups:   make(map[string]models.RateLimit),
+	}
+
+	for kind, value := range map[string]string{RateLimitRead: read, RateLimitWrite: write} {
+		limit, err := parseRateLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s rate limit: %w", kind, err)
+		}
+		s.defaults[kind] = limit
+		s.idle = max(s.idle, limit.Period)
+	}
+
+	for _, pair := range strings.Split(groups, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(pair, "=")
+		group, kind, _ := strings.Cut(name, ".")
+		if !ok || group == "" || (kind != RateLimitRead && kind != RateLimitWrite) {
+			return nil, fmt.Errorf("invalid rate limit entry %q, expected <group>.<read|write>=<limit>", pair)
+		}
+
+		limit, err := parseRateLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit of %s: %w", name, err)
+		}
+		s.groups[name] = limit
+		s.idle = max(s.idle, limit.Period)
+	}
+
+	return s, nil
+}
+
+func parseRateLimit(value string) (models.RateLimit, error) {
+	burst, period, ok := strings.Cut(strings.TrimSpace(value), "/")
+	if !ok {
+		return models.RateLimit{}, fmt.Errorf("%q is not <burst>/<period>", value)
+	}
+
+	var (
+		limit models.RateLimit
+		err   error
+	)
+
+	limit.Burst, err = strconv.Atoi(burst)
+	if err != nil || limit.Burst < 1 {
+		return models.RateLimit{}, fmt.Errorf("burst %q is not a positive number", burst)
+	}
+
+	limit.Period, err = time.ParseDuration(period)
+	if err != nil || limit.Period <= 0 {
+		return models.RateLimit{}, fmt.Errorf("period %q is not a positive duration", period)
+	}
+
+	return limit, nil
+}
+
+// Take takes a token of the client from the read or the write bucket of the group.
+func (s *RateLimitService) Take(ctx context.Context, group string, write bool, client string) (*models.RateLimitResult, error) {
+	kind := RateLimitRead
+	if write {
+		kind = RateLimitWrite
+	}
+
+	limit, ok := s.groups[group+"."+kind]
+	if !ok {
+		limit = s.defaults[kind]
+	}
+
+	bucket, err := s.repo.TakeToken(ctx, group+"."+kind+":"+client, limit, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	res := &models.RateLimitResult{
+		Allowed:   bucket.Taken,
+		Limit:     limit,
+		Remaining: int(math.Floor(bucket.Tokens)),
+		Reset:     tokensDuration(float64(limit.Burst)-bucket.Tokens, limit),
+	}
+	if !bucket.Taken {
+		res.RetryAfter = tokensDuration(1-bucket.Tokens, limit)
+	}
+
+	return res, nil
+}
+
+// tokensDuration is the time the bucket takes to gain the tokens.
+func tokensDuration(tokens float64, limit models.RateLimit) time.Duration {
+	return time.Duration(tokens / limit.Rate() * float64(time.Second))
+}
+
+// RunCleanup periodically deletes the buckets that are full again.
+func (s *RateLimitService) RunCleanup(ctx context.Context, interval time.Duration) {
+	log := logger.GetLoggerFromCtx(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := s.repo.DeleteIdleBuckets(ctx, time.Now().Add(-s.idle)); err != nil && ctx.Err() == nil {
+			log.Error(ctx, "Failed to delete idle rate limit buckets", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"skillsrock-test-task/internal/models"
+	"skillsrock-test-task/internal/repository"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewRateLimitServiceGroups(t *testing.T) {
+	s, err := NewRateLimitService(repository.NewMemoryRateLimitRepository(), "60/1m", "20/1m",
+		" imports.write=2/1h , board.read=5/10s,,tasks.read=100/1m")
+	if err != nil {
+		t.Fatalf("NewRateLimitService: %v", err)
+	}
+
+	tests := []struct {
+		group string
+		write bool
+		want  models.RateLimit
+	}{
+		{group: "imports", write: true, want: models.RateLimit{Burst: 2, Period: time.Hour}},
+		{group: "imports", write: false, want: models.RateLimit{Burst: 60, Period: time.Minute}},
+		{group: "board", write: false, want: models.RateLimit{Burst: 5, Period: 10 * time.Second}},
+		{group: "tasks", write: false, want: models.RateLimit{Burst: 100, Period: time.Minute}},
+		{group: "tasks", write: true, want: models.RateLimit{Burst: 20, Period: time.Minute}},
+		{group: "comments", write: false, want: models.RateLimit{Burst: 60, Period: time.Minute}},
+	}
+	for _, tt := range tests {
+		res, err := s.Take(context.Background(), tt.group, tt.write, "ip:127.0.0.1")
+		if err != nil {
+			t.Fatalf("Take(%s, %v): %v", tt.group, tt.write, err)
+		}
+		if res.Limit != tt.want {
+			t.Errorf("Take(%s, %v) limit = %+v, want %+v", tt.group, tt.write, res.Limit, tt.want)
+		}
+	}
+
+	if s.idle != time.Hour {
+		t.Errorf("idle = %s, want the longest period 1h", s.idle)
+	}
+}
+
+func TestNewRateLimitServiceInvalid(t *testing.T) {
+	tests := []struct {
+		name               string
+		read, write, group string
+		want               string
+	}{
+		{name: "read without period", read: "60", write: "20/1m", want: "invalid read rate limit"},
+		{name: "zero write burst", read: "60/1m", write: "0/1m", want: "invalid write rate limit"},
+		{name: "negative period", read: "60/-1m", write: "20/1m", want: "invalid read rate limit"},
+		{name: "group without limit", read: "60/1m", write: "20/1m", group: "tasks.read", want: "expected <group>.<read|write>=<limit>"},
+		{name: "group without kind", read: "60/1m", write: "20/1m", group: "tasks=5/1m", want: "expected <group>.<read|write>=<limit>"},
+		{name: "unknown kind", read: "60/1m", write: "20/1m", group: "tasks.delete=5/1m", want: "expected <group>.<read|write>=<limit>"},
+		{name: "group without name", read: "60/1m", write: "20/1m", group: ".read=5/1m", want: "expected <group>.<read|write>=<limit>"},
+		{name: "invalid group limit", read: "60/1m", write: "20/1m", group: "tasks.read=5/often", want: "invalid rate limit of tasks.read"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRateLimitService(repository.NewMemoryRateLimitRepository(), tt.read, tt.write, tt.group)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("NewRateLimitService error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitServiceTake(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewRateLimitService(repository.NewMemoryRateLimitRepository(), "2/1h", "1/1h", "")
+	if err != nil {
+		t.Fatalf("NewRateLimitService: %v", err)
+	}
+
+	for i, remaining := range []int{1, 0} {
+		res, err := s.Take(ctx, "tasks", false, "ip:127.0.0.1")
+		if err != nil {
+			t.Fatalf("Take %d: %v", i, err)
+		}
+		if !res.Allowed || res.Remaining != remaining || res.RetryAfter != 0 {
+			t.Fatalf("Take %d = %+v, want allowed with %d remaining", i, res, remaining)
+		}
+	}
+
+	res, err := s.Take(ctx, "tasks", false, "ip:127.0.0.1")
+	if err != nil {
+		t.Fatalf("Take: %v", err)
+	}
+	// A token comes back every 30 minutes, the bucket is full after an hour.
+	if res.Allowed || res.Remaining != 0 {
+		t.Fatalf("Take over the limit = %+v, want rejected", res)
+	}
+	if res.RetryAfter <= 29*time.Minute || res.RetryAfter > 30*time.Minute {
+		t.Errorf("RetryAfter = %s, want about 30m", res.RetryAfter)
+	}
+	if res.Reset <= 59*time.Minute || res.Reset > time.Hour {
+		t.Errorf("Reset = %s, want about 1h", res.Reset)
+	}
+
+	// The write budget and the budgets of other clients and groups have buckets of their own.
+	for _, take := range []struct {
+		group, client string
+		write         bool
+	}{
+		{group: "tasks", client: "ip:127.0.0.1", write: true},
+		{group: "tasks", client: "ip:127.0.0.2", write: false},
+		{group: "comments", client: "ip:127.0.0.1", write: false},
+	} {
+		res, err := s.Take(ctx, take.group, take.write, take.client)
+		if err != nil {
+			t.Fatalf("Take(%+v): %v", take, err)
+		}
+		if !res.Allowed {
+			t.Errorf("Take(%+v) was rejected by the exhausted bucket", take)
+		}
+	}
+}
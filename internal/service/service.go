@@ -2,9 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
 	"skillsrock-test-task/internal/dto"
 	"skillsrock-test-task/internal/models"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -14,33 +16,55 @@ const (
 	statusDone     = "done"
 )
 
+const (
+	defaultTasksLimit = 10
+	maxTasksLimit     = 100
+)
+
+const (
+	bulkModeAtomic     = "atomic"
+	bulkModeBestEffort = "best_effort"
+
+	bulkStatusOK         = "ok"
+	bulkStatusFailed     = "failed"
+	bulkStatusRolledBack = "rolled_back"
+)
+
 type TaskRepository interface {
 	CreateTask(ctx context.Context, task *models.Task) (uint64, error)
 	GetTaskByID(ctx context.Context, id uint64) (*models.Task, error)
 	DeleteTask(ctx context.Context, id uint64) error
-	GetTasks(ctx context.Context, limit, offset uint64) ([]*models.Task, error)
+	GetTasks(ctx context.Context, filter *models.TaskFilter, limit, offset uint64) ([]*models.Task, error)
+	GetTasksBefore(ctx context.Context, filter *models.TaskFilter, before, limit uint64) ([]*models.Task, error)
+	ExportTasks(ctx context.Context, filter *models.TaskFilter, fn func(task *models.Task) error) error
+	GetDueTasks(ctx context.Context, filter *models.CalendarFilter) ([]*models.Task, error)
+	GetDueTasksVersion(ctx context.Context, filter *models.CalendarFilter) (*models.TasksVersion, error)
 	UpdateTask(ctx context.Context, id uint64, task *models.Task) error
+	UpdateTaskStatus(ctx context.Context, id uint64, status string, updatedAt time.Time) error
+	ApplyOperations(ctx context.Context, ops []*models.TaskOperation, atomic bool) ([]*models.OperationResult, error)
 }
 
 type TaskService struct {
-	repo TaskRepository
+	repo              TaskRepository
+	maxBulkOperations int
 }
 
-func NewTaskService(repo TaskRepository) *TaskService {
+func NewTaskService(repo TaskRepository, maxBulkOperations int) *TaskService {
 	return &TaskService{
-		repo: repo,
+		repo:              repo,
+		maxBulkOperations: maxBulkOperations,
 	}
 }
 
-func (s *TaskService) CreateTask(ctx context.Context, task *dto.CreateTaskRequest) (*dto.CreateTaskResponse, error) {
+func (s *TaskService) CreateTask(ctx context.Context, req *dto.CreateTaskRequest) (*dto.CreateTaskResponse, error) {
 	now := time.Now()
 
-	id, err := s.repo.CreateTask(ctx, &models.Task{
-		Title:       task.Title,
-		Description: task.Description,
-		Status:      statusNew,
-		CreatedAt:   now,
-	})
+	task, err := newTask(req, now)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.repo.CreateTask(ctx, task)
 
 	return &dto.CreateTaskResponse{
 		ID:        id,
@@ -74,7 +98,7 @@ func (s *TaskService) GetTaskByID(ctx context.Context, taskIDStr string) (*dto.G
 	}, nil
 }
 
-func (s *TaskService) GetTasks(ctx context.Context, pageStr string, limitStr string) (*dto.GetTasksResponse, error) {
+func (s *TaskService) GetTasks(ctx context.Context, pageStr string, limitStr string, filter *models.TaskFilter) (*dto.GetTasksResponse, error) {
 	page, err := strconv.ParseUint(pageStr, 10, 64)
 	if err != nil && pageStr != "" {
 		return nil, models.ErrFailedToParsePage
@@ -85,16 +109,20 @@ func (s *TaskService) GetTasks(ctx context.Context, pageStr string, limitStr str
 		return nil, models.ErrFailedToParseLimit
 	}
 
+	if filter.Status != "" && !isValidStatus(filter.Status) {
+		return nil, models.ErrInvalidStatus
+	}
+
 	if page < 1 {
 		page = 1
 	}
 	if limit == 0 {
-		limit = 10
+		limit = defaultTasksLimit
 	}
 
 	offset := (page - 1) * limit
 
-	tasks, err := s.repo.GetTasks(ctx, limit, offset)
+	tasks, err := s.repo.GetTasks(ctx, filter, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -104,14 +132,61 @@ func (s *TaskService) GetTasks(ctx context.Context, pageStr string, limitStr str
 	}, nil
 }
 
+// GetTasksPage pages through tasks with cursors, which unlike page numbers stay stable while tasks are created.
+func (s *TaskService) GetTasksPage(ctx context.Context, cursor string, limit uint64, filter *models.TaskFilter) (*dto.GetTasksPageResponse, error) {
+	before, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.Status != "" && !isValidStatus(filter.Status) {
+		return nil, models.ErrInvalidStatus
+	}
+
+	if limit == 0 {
+		limit = defaultTasksLimit
+	}
+	if limit > maxTasksLimit {
+		limit = maxTasksLimit
+	}
+
+	tasks, err := s.repo.GetTasksBefore(ctx, filter, before, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &dto.GetTasksPageResponse{
+		Tasks: tasks,
+	}
+
+	if uint64(len(tasks)) > limit {
+		res.Tasks = tasks[:limit]
+		res.NextCursor = encodeCursor(res.Tasks[limit-1].ID)
+	}
+
+	return res, nil
+}
+
+// ExportTasks checks the filter and returns the export of the matching tasks. Nothing is read until the
+// export runs, so an invalid filter is reported before the caller starts writing a response.
+func (s *TaskService) ExportTasks(filter *models.TaskFilter) (dto.TaskExport, error) {
+	if filter.Status != "" && !isValidStatus(filter.Status) {
+		return nil, models.ErrInvalidStatus
+	}
+
+	return func(ctx context.Context, fn func(task *models.Task) error) error {
+		return s.repo.ExportTasks(ctx, filter, fn)
+	}, nil
+}
+
 func (s *TaskService) UpdateTask(ctx context.Context, taskIDStr string, task *dto.UpdateTaskRequest) error {
 	taskID, err := strconv.ParseUint(taskIDStr, 10, 64)
 	if err != nil {
 		return models.ErrFailedToParseID
 	}
 
-	if task.Status != statusNew && task.Status != statusProgress && task.Status != statusDone {
-		return models.ErrInvalidStatus
+	if !isValidStatus(task.Status) {
+		return models.NewFieldError("status", models.ErrInvalidStatus)
 	}
 
 	return s.repo.UpdateTask(ctx, taskID, &models.Task{
@@ -119,6 +194,185 @@ func (s *TaskService) UpdateTask(ctx context.Context, taskIDStr string, task *dt
 		Title:       task.Title,
 		Description: task.Description,
 		Status:      task.Status,
+		DueAt:       task.DueAt,
 		UpdatedAt:   time.Now(),
 	})
 }
+
+// MoveTask changes the status of a task leaving the rest of it untouched.
+func (s *TaskService) MoveTask(ctx context.Context, taskIDStr, status string) error {
+	taskID, err := strconv.ParseUint(taskIDStr, 10, 64)
+	if err != nil {
+		return models.ErrFailedToParseID
+	}
+
+	if !isValidStatus(status) {
+		return models.ErrInvalidStatus
+	}
+
+	return s.repo.UpdateTaskStatus(ctx, taskID, status, time.Now())
+}
+
+func (s *TaskService) BulkTasks(ctx context.Context, req *dto.BulkTasksRequest) (*dto.BulkTasksResponse, error) {
+	mode := req.Mode
+	if mode == "" {
+		mode = bulkModeAtomic
+	}
+	if mode != bulkModeAtomic && mode != bulkModeBestEffort {
+		return nil, models.ErrInvalidBulkMode
+	}
+
+	if len(req.Operations) == 0 {
+		return nil, models.ErrEmptyBulk
+	}
+	if len(req.Operations) > s.maxBulkOperations {
+		return nil, models.ErrBulkTooLarge
+	}
+
+	res := &dto.BulkTasksResponse{
+		Mode:    mode,
+		Results: make([]dto.BulkOperationResult, len(req.Operations)),
+	}
+
+	now := time.Now()
+	ops := make([]*models.TaskOperation, 0, len(req.Operations))
+	positions := make([]int, 0, len(req.Operations))
+
+	for i, op := range req.Operations {
+		res.Results[i] = dto.BulkOperationResult{Index: i, Op: op.Op, ID: op.ID}
+
+		taskOp, err := newTaskOperation(op, now)
+		if err != nil {
+			res.Results[i].Status = bulkStatusFailed
+			res.Results[i].Error = err.Error()
+			continue
+		}
+
+		ops = append(ops, taskOp)
+		positions = append(positions, i)
+	}
+
+	atomic := mode == bulkModeAtomic
+
+	if atomic && len(ops) != len(req.Operations) {
+		for _, i := range positions {
+			res.Results[i].Status = bulkStatusRolledBack
+		}
+		return res, models.ErrBulkRolledBack
+	}
+
+	if len(ops) == 0 {
+		res.Committed = true
+		return res, nil
+	}
+
+	results, err := s.repo.ApplyOperations(ctx, ops, atomic)
+	if err != nil && !errors.Is(err, models.ErrBulkRolledBack) {
+		return nil, err
+	}
+
+	for j, result := range results {
+		item := &res.Results[positions[j]]
+
+		switch {
+		case result.Err != nil:
+			item.Status = bulkStatusFailed
+			item.Error = bulkErrorMessage(result.Err)
+		case err != nil:
+			item.Status = bulkStatusRolledBack
+			if item.Op == models.OperationCreate {
+				item.ID = 0
+			}
+		default:
+			item.Status = bulkStatusOK
+			item.ID = result.ID
+		}
+	}
+
+	if err != nil {
+		return res, models.ErrBulkRolledBack
+	}
+
+	res.Committed = true
+
+	return res, nil
+}
+
+func newTaskOperation(op dto.BulkOperation, now time.Time) (*models.TaskOperation, error) {
+	switch op.Op {
+	case models.OperationCreate:
+		task, err := newTask(&dto.CreateTaskRequest{
+			Title:       op.Title,
+			Description: op.Description,
+			Owner:       op.Owner,
+			DueAt:       op.DueAt,
+		}, now)
+		if err != nil {
+			return nil, err
+		}
+
+		return &models.TaskOperation{
+			Type: op.Op,
+			Task: task,
+		}, nil
+	case models.OperationUpdate:
+		if op.ID == 0 {
+			return nil, models.ErrFailedToParseID
+		}
+		if !isValidStatus(op.Status) {
+			return nil, models.NewFieldError("status", models.ErrInvalidStatus)
+		}
+
+		return &models.TaskOperation{
+			Type: op.Op,
+			ID:   op.ID,
+			Task: &models.Task{
+				ID:          op.ID,
+				Title:       op.Title,
+				Description: op.Description,
+				Status:      op.Status,
+				DueAt:       op.DueAt,
+				UpdatedAt:   now,
+			},
+		}, nil
+	case models.OperationDelete:
+		if op.ID == 0 {
+			return nil, models.ErrFailedToParseID
+		}
+
+		return &models.TaskOperation{
+			Type: op.Op,
+			ID:   op.ID,
+		}, nil
+	default:
+		return nil, models.ErrInvalidOperation
+	}
+}
+
+// newTask applies the rules of new tasks, whether they are created one by one, in bulk or by an import.
+func newTask(req *dto.CreateTaskRequest, now time.Time) (*models.Task, error) {
+	if strings.TrimSpace(req.Title) == "" {
+		return nil, models.NewFieldError("title", models.ErrEmptyTitle)
+	}
+
+	return &models.Task{
+		Title:       req.Title,
+		Description: req.Description,
+		Status:      statusNew,
+		Owner:       req.Owner,
+		DueAt:       req.DueAt,
+		CreatedAt:   now,
+	}, nil
+}
+
+func bulkErrorMessage(err error) string {
+	if errors.Is(err, models.ErrNotFound) {
+		return err.Error()
+	}
+
+	return "unknown error occurred"
+}
+
+func isValidStatus(status string) bool {
+	return status == statusNew || status == statusProgress || status == statusDone
+}
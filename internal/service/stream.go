@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"skillsrock-test-task/internal/dto"
+	"skillsrock-test-task/internal/models"
+	"skillsrock-test-task/pkg/logger"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	streamReplaySize       = 1000
+	streamSubscriberBuffer = 64
+	streamReconnectDelay   = 3 * time.Second
+)
+
+type StreamRepository interface {
+	ListenEvents(ctx context.Context, channel string, listening func(), notified func(id uint64)) error
+	GetEvent(ctx context.Context, id uint64) (*models.OutboxEvent, error)
+	GetRecentEvents(ctx context.Context, limit uint64) ([]*models.OutboxEvent, error)
+}
+
+type streamEvent struct {
+	outbox *models.OutboxEvent
+	task   models.TaskEvent
+}
+
+type streamSubscriber struct {
+	status string
+	owner  string
+	events chan *models.OutboxEvent
+}
+
+// StreamService fans task events out to the subscribers connected to this instance. Events arrive
+// through Postgres LISTEN/NOTIFY, so every replica sees the events of all others. The latest events
+// are kept in a bounded buffer to let reconnecting subscribers resume from the last event they got.
+type StreamService struct {
+	repo    StreamRepository
+	channel string
+
+	mu          sync.Mutex
+	buffer      []*streamEvent
+	seen        map[uint64]struct{}
+	subscribers map[*streamSubscriber]struct{}
+	closed      bool
+}
+
+func NewStreamService(repo StreamRepository, channel string) *StreamService {
+	return &StreamService{
+		repo:        repo,
+		channel:     channel,
+		buffer:      make([]*streamEvent, 0, streamReplaySize),
+		seen:        make(map[uint64]struct{}, streamReplaySize),
+		subscribers: make(map[*streamSubscriber]struct{}),
+	}
+}
+
+// Subscribe registers a subscriber until the context is cancelled. The events after LastEventID that
+// are still buffered are returned for replay, Reset is set when they are not available anymore.
+// The events channel is closed when the subscriber falls too far behind or the service is closed.
+func (s *StreamService) Subscribe(ctx context.Context, req *dto.StreamTasksRequest) (*dto.TaskStream, error) {
+	if req.Status != "" && !isValidStatus(req.Status) {
+		return nil, models.ErrInvalidStatus
+	}
+
+	sub := &streamSubscriber{
+		status: req.Status,
+		owner:  req.Owner,
+		events: make(chan *models.OutboxEvent, streamSubscriberBuffer),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, models.ErrStreamClosed
+	}
+
+	stream := &dto.TaskStream{
+		Events: sub.events,
+	}
+
+	if req.LastEventID != "" {
+		stream.Replay, stream.Reset = s.replay(sub, req.LastEventID)
+	}
+
+	s.subscribers[sub] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		s.unsubscribe(sub)
+	}()
+
+	return stream, nil
+}
+
+// Close disconnects all subscribers, it is called before the server shuts down.
+func (s *StreamService) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for sub := range s.subscribers {
+		delete(s.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// RunListener receives the events announced by the transactions that write them until the context is cancelled,
+// reconnecting when the connection breaks. Events written while it was not listening are loaded
+// from the outbox after every reconnect.
+func (s *StreamService) RunListener(ctx context.Context) {
+	log := logger.GetLoggerFromCtx(ctx)
+
+	listening := func() {
+		events, err := s.repo.GetRecentEvents(ctx, streamReplaySize)
+		if err != nil {
+			log.Error(ctx, "Failed to load recent task events", zap.Error(err))
+			return
+		}
+
+		for _, event := range events {
+			s.publish(ctx, event)
+		}
+	}
+
+	notified := func(id uint64) {
+		event, err := s.repo.GetEvent(ctx, id)
+		if err != nil {
+			if !errors.Is(err, models.ErrNotFound) && ctx.Err() == nil {
+				log.Error(ctx, "Failed to load the task event", zap.Uint64("id", id), zap.Error(err))
+			}
+			return
+		}
+
+		s.publish(ctx, event)
+	}
+
+	for {
+		err := s.repo.ListenEvents(ctx, s.channel, listening, notified)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Error(ctx, "Task events listener disconnected", zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(streamReconnectDelay):
+		}
+	}
+}
+
+func (s *StreamService) publish(ctx context.Context, outbox *models.OutboxEvent) {
+	event := &streamEvent{outbox: outbox}
+	if err := json.Unmarshal(outbox.Payload, &event.task); err != nil {
+		logger.GetLoggerFromCtx(ctx).Error(ctx, "Failed to decode the task event", zap.Uint64("id", outbox.ID), zap.Error(err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.seen[outbox.ID]; ok {
+		return
+	}
+
+	if len(s.buffer) == streamReplaySize {
+		delete(s.seen, s.buffer[0].outbox.ID)
+		copy(s.buffer, s.buffer[1:])
+		s.buffer = s.buffer[:len(s.buffer)-1]
+	}
+	s.buffer = append(s.buffer, event)
+	s.seen[outbox.ID] = struct{}{}
+
+	for sub := range s.subscribers {
+		if !sub.matches(event) {
+			continue
+		}
+
+		select {
+		case sub.events <- outbox:
+		default:
+			// The subscriber does not keep up, it is disconnected and resumes from its last event.
+			delete(s.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// replay is called with the lock held. The buffer is kept in arrival order, which is not strictly
+// the ID order, so the position of the last event is looked up instead of comparing IDs.
+func (s *StreamService) replay(sub *streamSubscriber, lastEventID string) ([]*models.OutboxEvent, bool) {
+	lastID, err := strconv.ParseUint(lastEventID, 10, 64)
+	if err != nil {
+		return nil, true
+	}
+
+	if _, ok := s.seen[lastID]; !ok {
+		return nil, true
+	}
+
+	replay := make([]*models.OutboxEvent, 0)
+	found := false
+	for _, event := range s.buffer {
+		if found && sub.matches(event) {
+			replay = append(replay, event.outbox)
+		}
+		if event.outbox.ID == lastID {
+			found = true
+		}
+	}
+
+	return replay, false
+}
+
+func (s *StreamService) unsubscribe(sub *streamSubscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subscribers[sub]; ok {
+		delete(s.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// matches reports whether the event concerns the filtered tasks. A status change matches both the
+// previous and the new status, so that subscribers see tasks leaving their filter.
+func (sub *streamSubscriber) matches(event *streamEvent) bool {
+	task := event.task.Task
+
+	if sub.owner != "" && (task == nil || task.Owner != sub.owner) {
+		return false
+	}
+
+	if sub.status != "" && (task == nil || task.Status != sub.status) && event.task.PreviousStatus != sub.status {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,499 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"skillsrock-test-task/internal/dto"
+	"skillsrock-test-task/internal/models"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ExternalTaskRepository interface {
+	ImportExternalTasks(ctx context.Context, source string, tasks []*models.ExternalTask, dryRun bool) error
+}
+
+// externalItem is an item of an export before it is validated, a tracker parser fills it in.
+type externalItem struct {
+	sourceID    string
+	title       string
+	description string
+	state       string
+	// status is set when the tracker itself tells the status, otherwise it is guessed from the state.
+	status    string
+	createdAt time.Time
+	dueAt     *time.Time
+	// skip tells why the item is not a task, e.g. a pull request in an issues export.
+	skip string
+}
+
+type trackerParser func(data []byte) ([]*externalItem, error)
+
+var trackerParsers = map[string]trackerParser{
+	models.SourceTrello: parseTrelloBoard,
+	models.SourceJira:   parseJiraIssues,
+	models.SourceGitHub: parseGitHubIssues,
+}
+
+// stateKeywords guess the status of a state by its name, the first matching keyword wins.
+var stateKeywords = []struct {
+	keyword string
+	status  string
+}{
+	{"done", statusDone},
+	{"complete", statusDone},
+	{"closed", statusDone},
+	{"resolved", statusDone},
+	{"finished", statusDone},
+	{"archived", statusDone},
+	{"progress", statusProgress},
+	{"doing", statusProgress},
+	{"review", statusProgress},
+	{"testing", statusProgress},
+	{"started", statusProgress},
+	{"active", statusProgress},
+}
+
+// ImportFromTracker imports the JSON export of a Trello board, Jira issues or GitHub issues. The tracker IDs
+// of the items are kept, so importing an export again updates the tasks that were already imported.
+func (s *ImportService) ImportFromTracker(ctx context.Context, req *dto.ImportFromTrackerRequest) (*dto.ImportFromTrackerResponse, error) {
+	parse, ok := trackerParsers[req.Source]
+	if !ok {
+		return nil, models.ErrUnknownImportSource
+	}
+
+	statuses, err := parseStateMapping(req.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Size > s.maxSize {
+		return nil, models.ErrFileTooLarge
+	}
+
+	data, err := io.ReadAll(io.LimitReader(req.File, s.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxSize {
+		return nil, models.ErrFileTooLarge
+	}
+
+	items, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// The exports are JSON documents that would parse as the export of another tracker too.
+	if len(items) > 0 && !slices.ContainsFunc(items, func(item *externalItem) bool { return item.sourceID != "" }) {
+		return nil, fmt.Errorf("%w: no item has an id, the file is not a %s export", models.ErrInvalidImportFile, req.Source)
+	}
+
+	report := &models.ExternalImportReport{
+		Source:     req.Source,
+		DryRun:     req.DryRun,
+		TotalItems: uint64(len(items)),
+		States:     []models.StateMapping{},
+		Tasks:      []models.ExternalTaskMapping{},
+		Errors:     []models.ExternalImportError{},
+	}
+
+	tasks := s.externalTasks(items, statuses, report)
+
+	if err := s.tasks.ImportExternalTasks(ctx, req.Source, tasks, req.DryRun); err != nil {
+		return nil, err
+	}
+
+	for _, ext := range tasks {
+		switch ext.Outcome {
+		case models.ExternalCreated:
+			report.Created++
+			if req.DryRun {
+				ext.Task.ID = 0
+			}
+		case models.ExternalUpdated:
+			report.Updated++
+		case models.ExternalUnchanged:
+			report.Unchanged++
+		}
+
+		report.Tasks = append(report.Tasks, models.ExternalTaskMapping{
+			SourceID: ext.SourceID,
+			TaskID:   ext.Task.ID,
+			Outcome:  ext.Outcome,
+		})
+	}
+
+	return &dto.ImportFromTrackerResponse{
+		Report: report,
+	}, nil
+}
+
+// externalTasks validates the items like created tasks and maps their states, the items that are skipped
+// are reported. An item listed twice is imported once, as it is listed last.
+func (s *ImportService) externalTasks(items []*externalItem, statuses map[string]string, report *models.ExternalImportReport) []*models.ExternalTask {
+	addError := func(i int, item *externalItem, field, reason string) {
+		if len(report.Errors) < importMaxErrors {
+			report.Errors = append(report.Errors, models.ExternalImportError{
+				SourceID: item.sourceID,
+				Item:     uint64(i + 1),
+				Field:    field,
+				Error:    reason,
+			})
+		}
+	}
+	skip := func(i int, item *externalItem, reason string) {
+		report.Skipped++
+		addError(i, item, "", reason)
+	}
+
+	last := make(map[string]int, len(items))
+	for i, item := range items {
+		last[item.sourceID] = i
+	}
+
+	states := make(map[models.StateMapping]uint64)
+	var order []models.StateMapping
+
+	tasks := make([]*models.ExternalTask, 0, len(items))
+	for i, item := range items {
+		switch {
+		case item.skip != "":
+			skip(i, item, item.skip)
+			continue
+		case item.sourceID == "":
+			skip(i, item, "item has no id")
+			continue
+		case last[item.sourceID] != i:
+			skip(i, item, "item is listed again later")
+			continue
+		}
+
+		// Items are validated like the body of CreateTask, every invalid field is reported.
+		req := &dto.CreateTaskRequest{
+			Title:       item.title,
+			Description: item.description,
+			DueAt:       item.dueAt,
+		}
+		err := dto.Validate(req)
+		var task *models.Task
+		if err == nil {
+			task, err = newTask(req, item.createdAt)
+		}
+		if err != nil {
+			report.Skipped++
+			for _, itemErr := range importRowErrors(uint64(i+1), err) {
+				addError(i, item, itemErr.Field, itemErr.Error)
+			}
+			continue
+		}
+		task.Status = stateStatus(item, statuses)
+
+		mapping := models.StateMapping{State: item.state, Status: task.Status}
+		if _, ok := states[mapping]; !ok {
+			order = append(order, mapping)
+		}
+		states[mapping]++
+
+		tasks = append(tasks, &models.ExternalTask{
+			SourceID:    item.sourceID,
+			SourceState: item.state,
+			Task:        task,
+		})
+	}
+
+	for _, mapping := range order {
+		mapping.Count = states[mapping]
+		report.States = append(report.States, mapping)
+	}
+
+	return tasks
+}
+
+// stateStatus prefers the mapping of the request, then the status told by the tracker, then a status guessed
+// from the name of the state. States that tell nothing are new.
+func stateStatus(item *externalItem, statuses map[string]string) string {
+	if status, ok := statuses[strings.ToLower(item.state)]; ok {
+		return status
+	}
+	if item.status != "" {
+		return item.status
+	}
+
+	state := strings.ToLower(item.state)
+	for _, kw := range stateKeywords {
+		if strings.Contains(state, kw.keyword) {
+			return kw.status
+		}
+	}
+
+	return statusNew
+}
+
+// parseStateMapping reads a JSON object from state names to statuses, names are matched ignoring case.
+func parseStateMapping(raw string) (map[string]string, error) {
+	statuses := make(map[string]string)
+	if raw == "" {
+		return statuses, nil
+	}
+
+	var mapping map[string]string
+	if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+		return nil, fmt.Errorf("%w: mapping must be a JSON object of strings", models.ErrInvalidImportMapping)
+	}
+
+	for state, status := range mapping {
+		if !isValidStatus(status) {
+			return nil, fmt.Errorf("%w: status %q of %q is invalid", models.ErrInvalidImportMapping, status, state)
+		}
+		statuses[strings.ToLower(state)] = status
+	}
+
+	return statuses, nil
+}
+
+// parseTrelloBoard reads the JSON export of a board. The state of a card is its list, archived cards
+// and the cards of archived lists are done.
+func parseTrelloBoard(data []byte) ([]*externalItem, error) {
+	var board struct {
+		Cards []struct {
+			ID     string `json:"id"`
+			Name   string `json:"name"`
+			Desc   string `json:"desc"`
+			Closed bool   `json:"closed"`
+			IDList string `json:"idList"`
+			Due    string `json:"due"`
+		} `json:"cards"`
+		Lists []struct {
+			ID     string `json:"id"`
+			Name   string `json:"name"`
+			Closed bool   `json:"closed"`
+		} `json:"lists"`
+	}
+	if err := json.Unmarshal(data, &board); err != nil {
+		return nil, fmt.Errorf("%w: not a Trello board export: %s", models.ErrInvalidImportFile, jsonErrorMessage(err))
+	}
+
+	lists := make(map[string]string, len(board.Lists))
+	archivedLists := make(map[string]bool)
+	for _, list := range board.Lists {
+		lists[list.ID] = list.Name
+		archivedLists[list.ID] = list.Closed
+	}
+
+	items := make([]*externalItem, 0, len(board.Cards))
+	for _, card := range board.Cards {
+		item := &externalItem{
+			sourceID:    card.ID,
+			title:       card.Name,
+			description: card.Desc,
+			state:       lists[card.IDList],
+			createdAt:   trelloCreatedAt(card.ID),
+			dueAt:       parseTrackerDueDate(card.Due),
+		}
+		if card.Closed || archivedLists[card.IDList] {
+			item.state = "archived"
+			item.status = statusDone
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// trelloCreatedAt reads the creation time Trello keeps in the first 4 bytes of its IDs.
+func trelloCreatedAt(id string) time.Time {
+	if len(id) < 8 {
+		return time.Now()
+	}
+
+	seconds, err := strconv.ParseInt(id[:8], 16, 64)
+	if err != nil {
+		return time.Now()
+	}
+
+	return time.Unix(seconds, 0)
+}
+
+type jiraIssue struct {
+	ID     string `json:"id"`
+	Key    string `json:"key"`
+	Fields struct {
+		Summary     string          `json:"summary"`
+		Description json.RawMessage `json:"description"`
+		Created     string          `json:"created"`
+		DueDate     string          `json:"duedate"`
+		Status      struct {
+			Name           string `json:"name"`
+			StatusCategory struct {
+				Key string `json:"key"`
+			} `json:"statusCategory"`
+		} `json:"status"`
+	} `json:"fields"`
+}
+
+// jiraCategories are the status categories every Jira status belongs to.
+var jiraCategories = map[string]string{
+	"new":           statusNew,
+	"indeterminate": statusProgress,
+	"done":          statusDone,
+}
+
+// parseJiraIssues reads the issues of a Jira search response, or a JSON array of issues. Descriptions may be
+// plain text or Atlassian documents, whose text is kept.
+func parseJiraIssues(data []byte) ([]*externalItem, error) {
+	var issues []jiraIssue
+	if err := json.Unmarshal(data, &issues); err != nil {
+		var search struct {
+			Issues []jiraIssue `json:"issues"`
+		}
+		if err := json.Unmarshal(data, &search); err != nil {
+			return nil, fmt.Errorf("%w: not a Jira issues export: %s", models.ErrInvalidImportFile, jsonErrorMessage(err))
+		}
+		issues = search.Issues
+	}
+
+	items := make([]*externalItem, 0, len(issues))
+	for _, issue := range issues {
+		item := &externalItem{
+			sourceID:    issue.ID,
+			title:       issue.Fields.Summary,
+			description: jiraText(issue.Fields.Description),
+			state:       issue.Fields.Status.Name,
+			status:      jiraCategories[issue.Fields.Status.StatusCategory.Key],
+			createdAt:   parseTrackerTime(issue.Fields.Created),
+			dueAt:       parseTrackerDueDate(issue.Fields.DueDate),
+		}
+		if item.sourceID == "" {
+			item.sourceID = issue.Key
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// jiraText returns a plain text description, or the text of the paragraphs of an Atlassian document.
+func jiraText(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+
+	var node adfNode
+	if err := json.Unmarshal(raw, &node); err != nil {
+		return ""
+	}
+
+	var b strings.Builder
+	node.writeText(&b)
+
+	return strings.TrimSpace(b.String())
+}
+
+type adfNode struct {
+	Type    string    `json:"type"`
+	Text    string    `json:"text"`
+	Content []adfNode `json:"content"`
+}
+
+func (n *adfNode) writeText(b *strings.Builder) {
+	switch n.Type {
+	case "text":
+		b.WriteString(n.Text)
+	case "hardBreak":
+		b.WriteString("\n")
+	}
+
+	for i := range n.Content {
+		n.Content[i].writeText(b)
+	}
+
+	switch n.Type {
+	case "paragraph", "heading", "listItem", "codeBlock", "blockquote":
+		b.WriteString("\n")
+	}
+}
+
+// parseGitHubIssues reads a JSON array of issues as returned by the REST API or by gh issue list --json.
+// Their URL identifies them, pull requests are skipped.
+func parseGitHubIssues(data []byte) ([]*externalItem, error) {
+	var issues []struct {
+		HTMLURL     string          `json:"html_url"`
+		URL         string          `json:"url"`
+		Title       string          `json:"title"`
+		Body        string          `json:"body"`
+		State       string          `json:"state"`
+		CreatedAt   string          `json:"created_at"`
+		CreatedAtGH string          `json:"createdAt"`
+		PullRequest json.RawMessage `json:"pull_request"`
+	}
+	if err := json.Unmarshal(data, &issues); err != nil {
+		return nil, fmt.Errorf("%w: not a GitHub issues export: %s", models.ErrInvalidImportFile, jsonErrorMessage(err))
+	}
+
+	items := make([]*externalItem, 0, len(issues))
+	for _, issue := range issues {
+		item := &externalItem{
+			sourceID:    issue.HTMLURL,
+			title:       issue.Title,
+			description: issue.Body,
+			state:       strings.ToLower(issue.State),
+			createdAt:   parseTrackerTime(issue.CreatedAt + issue.CreatedAtGH),
+		}
+		if item.sourceID == "" {
+			item.sourceID = issue.URL
+		}
+		if item.state == "open" {
+			item.status = statusNew
+		}
+		if len(issue.PullRequest) > 0 && string(issue.PullRequest) != "null" || strings.Contains(item.sourceID, "/pull/") {
+			item.skip = "item is a pull request"
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// jsonErrorMessage describes a decoding error without the Go types it was decoded into.
+func jsonErrorMessage(err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		field := strings.TrimPrefix(typeErr.Field, ".")
+		if field == "" {
+			return "unexpected " + typeErr.Value
+		}
+		return fmt.Sprintf("unexpected %s in %s", typeErr.Value, field)
+	}
+
+	return err.Error()
+}
+
+// parseTrackerTime reads the times of the exports, an item without one is created now.
+func parseTrackerTime(value string) time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05.000-0700"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+
+	return time.Now()
+}
+
+// parseTrackerDueDate reads the due dates of the exports, Jira has dates without a time. An item without one,
+// or with one that cannot be read, has no due date.
+func parseTrackerDueDate(value string) *time.Time {
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,363 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	mathrand "math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"skillsrock-test-task/internal/dto"
+	"skillsrock-test-task/internal/models"
+	"skillsrock-test-task/pkg/logger"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookDelivery  = "X-Webhook-Delivery"
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+
+	deliveryBatch          = 20
+	deliveryLease          = time.Minute
+	retryBaseDelay         = 30 * time.Second
+	retryMaxDelay          = time.Hour
+	defaultDeliveriesLimit = 20
+	maxDeliveriesLimit     = 100
+)
+
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) (uint64, error)
+	GetWebhooks(ctx context.Context, owner string) ([]*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, owner string, id uint64) error
+	EnqueueDeliveries(ctx context.Context, event string, payload []byte, now time.Time) (int64, error)
+	ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit uint64) ([]*models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	GetDeliveries(ctx context.Context, owner string, webhookID, before, limit uint64) ([]*models.WebhookDelivery, error)
+	Redeliver(ctx context.Context, owner string, webhookID, deliveryID uint64, now time.Time) (uint64, error)
+}
+
+// WebhookService manages the webhooks of their owners and delivers the events to them. Targets on loopback,
+// link-local and private networks are rejected unless allowPrivate is set, the client should refuse to connect
+// to them as well, see NewWebhookClient.
+type WebhookService struct {
+	repo         WebhookRepository
+	client       *http.Client
+	maxAttempts  int
+	allowPrivate bool
+	resolver     *net.Resolver
+	logger       logger.Logger
+	wake         chan struct{}
+}
+
+func NewWebhookService(repo WebhookRepository, client *http.Client, maxAttempts int, allowPrivate bool, log logger.Logger) *WebhookService {
+	return &WebhookService{
+		repo:         repo,
+		client:       client,
+		maxAttempts:  maxAttempts,
+		allowPrivate: allowPrivate,
+		resolver:     net.DefaultResolver,
+		logger:       log,
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+func (s *WebhookService) CreateWebhook(ctx context.Context, owner string, webhook *dto.CreateWebhookRequest) (*dto.CreateWebhookResponse, error) {
+	target, err := url.Parse(webhook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return nil, models.NewFieldError("url", models.ErrInvalidWebhookURL)
+	}
+
+	if !s.allowPrivate {
+		if err := checkWebhookHost(ctx, s.resolver, target.Hostname()); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(webhook.Events) == 0 {
+		return nil, models.NewFieldError("events", models.ErrInvalidWebhookEvents)
+	}
+
+	events := make([]string, 0, len(webhook.Events))
+	for _, event := range webhook.Events {
+		if !slices.Contains(models.TaskEventTypes, event) {
+			return nil, models.NewFieldError("events", models.ErrInvalidWebhookEvents)
+		}
+		if !slices.Contains(events, event) {
+			events = append(events, event)
+		}
+	}
+
+	secret := webhook.Secret
+	if secret == "" {
+		raw := make([]byte, 32)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		secret = hex.EncodeToString(raw)
+	}
+
+	now := time.Now()
+
+	id, err := s.repo.CreateWebhook(ctx, &models.Webhook{
+		Owner:     owner,
+		URL:       target.String(),
+		Events:    events,
+		Secret:    secret,
+		Active:    true,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.CreateWebhookResponse{
+		ID:        id,
+		Secret:    secret,
+		CreatedAt: now,
+	}, nil
+}
+
+func (s *WebhookService) GetWebhooks(ctx context.Context, owner string) (*dto.GetWebhooksResponse, error) {
+	webhooks, err := s.repo.GetWebhooks(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.GetWebhooksResponse{
+		Webhooks: webhooks,
+	}, nil
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, owner, idStr string) error {
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return models.ErrFailedToParseWebhookID
+	}
+
+	return s.repo.DeleteWebhook(ctx, owner, id)
+}
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, owner, webhookIDStr, cursor, limitStr string) (*dto.GetDeliveriesResponse, error) {
+	webhookID, err := strconv.ParseUint(webhookIDStr, 10, 64)
+	if err != nil {
+		return nil, models.ErrFailedToParseWebhookID
+	}
+
+	before, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := strconv.ParseUint(limitStr, 10, 64)
+	if err != nil && limitStr != "" {
+		return nil, models.ErrFailedToParseLimit
+	}
+
+	if limit == 0 {
+		limit = defaultDeliveriesLimit
+	}
+	if limit > maxDeliveriesLimit {
+		limit = maxDeliveriesLimit
+	}
+
+	deliveries, err := s.repo.GetDeliveries(ctx, owner, webhookID, before, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &dto.GetDeliveriesResponse{
+		Deliveries: deliveries,
+	}
+
+	if uint64(len(deliveries)) > limit {
+		res.Deliveries = deliveries[:limit]
+		res.NextCursor = encodeCursor(res.Deliveries[limit-1].ID)
+	}
+
+	return res, nil
+}
+
+func (s *WebhookService) Redeliver(ctx context.Context, owner, webhookIDStr, deliveryIDStr string) (*dto.RedeliverResponse, error) {
+	webhookID, err := strconv.ParseUint(webhookIDStr, 10, 64)
+	if err != nil {
+		return nil, models.ErrFailedToParseWebhookID
+	}
+
+	deliveryID, err := strconv.ParseUint(deliveryIDStr, 10, 64)
+	if err != nil {
+		return nil, models.ErrFailedToParseDeliveryID
+	}
+
+	id, err := s.repo.Redeliver(ctx, owner, webhookID, deliveryID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	s.wakeDispatcher()
+
+	return &dto.RedeliverResponse{
+		ID: id,
+	}, nil
+}
+
+// Publish queues deliveries of the outbox event for all webhooks subscribed to it.
+func (s *WebhookService) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	queued, err := s.repo.EnqueueDeliveries(ctx, event.Type, event.Payload, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if queued > 0 {
+		s.wakeDispatcher()
+	}
+
+	return nil
+}
+
+// RunDispatcher sends due deliveries until the context is cancelled. It polls on the interval
+// and is woken up early when new deliveries are queued by this instance.
+func (s *WebhookService) RunDispatcher(ctx context.Context, interval time.Duration) {
+	log := logger.GetLoggerFromCtx(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.dispatchDue(ctx); err != nil && ctx.Err() == nil {
+			log.Error(ctx, "Failed to dispatch webhook deliveries", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+func (s *WebhookService) wakeDispatcher() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *WebhookService) dispatchDue(ctx context.Context) error {
+	for {
+		now := time.Now()
+
+		deliveries, err := s.repo.ClaimDeliveries(ctx, now, now.Add(deliveryLease), deliveryBatch)
+		if err != nil {
+			return err
+		}
+
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.deliver(ctx, delivery)
+			}()
+		}
+		wg.Wait()
+
+		if len(deliveries) < deliveryBatch {
+			return nil
+		}
+	}
+}
+
+func (s *WebhookService) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	statusCode, err := s.send(ctx, delivery)
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.UpdatedAt = now
+	delivery.NextAttemptAt = now
+	delivery.LastError = ""
+
+	if statusCode != 0 {
+		delivery.LastStatusCode = &statusCode
+	}
+
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliverySucceeded
+	case delivery.Attempts >= s.maxAttempts:
+		delivery.Status = models.DeliveryDead
+		delivery.LastError = err.Error()
+	default:
+		delivery.Status = models.DeliveryPending
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = now.Add(retryDelay(delivery.Attempts))
+	}
+
+	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil && ctx.Err() == nil {
+		s.logger.Error(ctx, "Failed to save the webhook delivery", zap.Uint64("id", delivery.ID), zap.Error(err))
+	}
+}
+
+func (s *WebhookService) send(ctx context.Context, delivery *models.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookEvent, delivery.Event)
+	req.Header.Set(HeaderWebhookDelivery, strconv.FormatUint(delivery.ID, 10))
+	req.Header.Set(HeaderWebhookTimestamp, timestamp)
+	req.Header.Set(HeaderWebhookSignature, "sha256="+SignWebhookPayload(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload computes the hex encoded HMAC-SHA256 of "timestamp.payload".
+// Receivers recompute it with the webhook secret to verify the X-Webhook-Signature header.
+func SignWebhookPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryDelay grows exponentially with the number of attempts, with up to 20% of jitter
+// so that deliveries failed together do not hammer the receiver together.
+func retryDelay(attempts int) time.Duration {
+	delay := retryMaxDelay
+	if shift := attempts - 1; shift < 20 {
+		delay = min(retryBaseDelay<<shift, retryMaxDelay)
+	}
+
+	return delay + time.Duration(mathrand.Int64N(int64(delay/5)+1))
+}
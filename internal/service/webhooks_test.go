@@ -0,0 +1,457 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"skillsrock-test-task/internal/dto"
+	"skillsrock-test-task/internal/models"
+	"skillsrock-test-task/pkg/logger"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeWebhookRepository keeps the deliveries in memory and claims them like the Postgres repository: a claimed
+// delivery is leased until leaseUntil, so a second dispatcher does not pick it up.
+type fakeWebhookRepository struct {
+	mu         sync.Mutex
+	webhooks   []*models.Webhook
+	deliveries []*models.WebhookDelivery
+	updates    []models.WebhookDelivery
+}
+
+func (r *fakeWebhookRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) (uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	webhook.ID = uint64(len(r.webhooks) + 1)
+	r.webhooks = append(r.webhooks, webhook)
+
+	return webhook.ID, nil
+}
+
+func (r *fakeWebhookRepository) GetWebhooks(ctx context.Context, owner string) ([]*models.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var webhooks []*models.Webhook
+	for _, webhook := range r.webhooks {
+		if webhook.Owner == owner {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+
+	return webhooks, nil
+}
+
+func (r *fakeWebhookRepository) DeleteWebhook(ctx context.Context, owner string, id uint64) error {
+	return errors.New("not implemented")
+}
+
+func (r *fakeWebhookRepository) EnqueueDeliveries(ctx context.Context, event string, payload []byte, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var queued int64
+	for _, webhook := range r.webhooks {
+		if !webhook.Active || !slices.Contains(webhook.Events, event) {
+			continue
+		}
+
+		r.deliveries = append(r.deliveries, &models.WebhookDelivery{
+			ID:            uint64(len(r.deliveries) + 1),
+			WebhookID:     webhook.ID,
+			Event:         event,
+			Payload:       payload,
+			Status:        models.DeliveryPending,
+			NextAttemptAt: now,
+			URL:           webhook.URL,
+			Secret:        webhook.Secret,
+		})
+		queued++
+	}
+
+	return queued, nil
+}
+
+func (r *fakeWebhookRepository) ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit uint64) ([]*models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var claimed []*models.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if uint64(len(claimed)) == limit {
+			break
+		}
+		if delivery.Status != models.DeliveryPending || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+
+		delivery.NextAttemptAt = leaseUntil
+		claim := *delivery
+		claimed = append(claimed, &claim)
+	}
+
+	return claimed, nil
+}
+
+func (r *fakeWebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := r.deliveries[delivery.ID-1]
+	stored.Status = delivery.Status
+	stored.Attempts = delivery.Attempts
+	stored.NextAttemptAt = delivery.NextAttemptAt
+	stored.LastStatusCode = delivery.LastStatusCode
+	stored.LastError = delivery.LastError
+	r.updates = append(r.updates, *delivery)
+
+	return nil
+}
+
+func (r *fakeWebhookRepository) GetDeliveries(ctx context.Context, owner string, webhookID, before, limit uint64) ([]*models.WebhookDelivery, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *fakeWebhookRepository) Redeliver(ctx context.Context, owner string, webhookID, deliveryID uint64, now time.Time) (uint64, error) {
+	return 0, errors.New("not implemented")
+}
+
+// due makes the pending deliveries due again, as if their retry delay had passed.
+func (r *fakeWebhookRepository) due() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, delivery := range r.deliveries {
+		delivery.NextAttemptAt = time.Time{}
+	}
+}
+
+func (r *fakeWebhookRepository) delivery(id uint64) models.WebhookDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return *r.deliveries[id-1]
+}
+
+// receivedDelivery is a request the stand-in receiver got.
+type receivedDelivery struct {
+	header http.Header
+	body   []byte
+}
+
+// newReceiver starts a stand-in receiver that answers the deliveries with the statuses in turn, the last one
+// for the rest.
+func newReceiver(t *testing.T, statuses ...int) (*httptest.Server, func() []receivedDelivery) {
+	t.Helper()
+
+	var (
+		mu       sync.Mutex
+		received []receivedDelivery
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		received = append(received, receivedDelivery{header: r.Header.Clone(), body: body})
+		status := statuses[min(len(received), len(statuses))-1]
+		mu.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []receivedDelivery {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(received)
+	}
+}
+
+func newTestWebhookService(t *testing.T, maxAttempts int, receiverURL string) (*WebhookService, *fakeWebhookRepository) {
+	t.Helper()
+
+	repo := &fakeWebhookRepository{}
+	// The stand-in receiver listens on loopback.
+	s := NewWebhookService(repo, NewWebhookClient(5*time.Second, true), maxAttempts, true, logger.NewNop())
+
+	_, err := s.CreateWebhook(context.Background(), "alice", &dto.CreateWebhookRequest{
+		URL:    receiverURL,
+		Events: []string{models.EventTaskCreated},
+		Secret: "top-secret",
+	})
+	if err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+
+	return s, repo
+}
+
+func publishTaskCreated(t *testing.T, s *WebhookService, payload string) {
+	t.Helper()
+
+	err := s.Publish(context.Background(), &models.OutboxEvent{Type: models.EventTaskCreated, Payload: []byte(payload)})
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+}
+
+func TestWebhookDeliveryIsSigned(t *testing.T) {
+	receiver, received := newReceiver(t, http.StatusNoContent)
+	s, repo := newTestWebhookService(t, 3, receiver.URL)
+
+	const payload = `{"id":1,"title":"Write report"}`
+	publishTaskCreated(t, s, payload)
+
+	if err := s.dispatchDue(context.Background()); err != nil {
+		t.Fatalf("dispatchDue: %v", err)
+	}
+
+	deliveries := received()
+	if len(deliveries) != 1 {
+		t.Fatalf("receiver got %d deliveries, want 1", len(deliveries))
+	}
+	got := deliveries[0]
+
+	if string(got.body) != payload {
+		t.Errorf("body = %s, want %s", got.body, payload)
+	}
+	if event := got.header.Get(HeaderWebhookEvent); event != models.EventTaskCreated {
+		t.Errorf("%s = %q, want %q", HeaderWebhookEvent, event, models.EventTaskCreated)
+	}
+	if id := got.header.Get(HeaderWebhookDelivery); id != "1" {
+		t.Errorf("%s = %q, want 1", HeaderWebhookDelivery, id)
+	}
+
+	timestamp := got.header.Get(HeaderWebhookTimestamp)
+	sentAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || time.Since(time.Unix(sentAt, 0)).Abs() > time.Minute {
+		t.Errorf("%s = %q, want the current Unix time", HeaderWebhookTimestamp, timestamp)
+	}
+
+	// The receiver recomputes the signature over "timestamp.payload" without the code of the service.
+	mac := hmac.New(sha256.New, []byte("top-secret"))
+	mac.Write([]byte(timestamp + "." + payload))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if signature := got.header.Get(HeaderWebhookSignature); signature != want {
+		t.Errorf("%s = %q, want %q", HeaderWebhookSignature, signature, want)
+	}
+
+	delivery := repo.delivery(1)
+	if delivery.Status != models.DeliverySucceeded || delivery.Attempts != 1 {
+		t.Errorf("delivery is %s after %d attempts, want succeeded after 1", delivery.Status, delivery.Attempts)
+	}
+	if delivery.LastStatusCode == nil || *delivery.LastStatusCode != http.StatusNoContent {
+		t.Errorf("last status code = %v, want %d", delivery.LastStatusCode, http.StatusNoContent)
+	}
+}
+
+func TestWebhookDeliveryRetriesWithBackoff(t *testing.T) {
+	receiver, received := newReceiver(t, http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK)
+	s, repo := newTestWebhookService(t, 5, receiver.URL)
+
+	publishTaskCreated(t, s, `{"id":1}`)
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		before := time.Now()
+		if err := s.dispatchDue(context.Background()); err != nil {
+			t.Fatalf("dispatchDue: %v", err)
+		}
+
+		delivery := repo.delivery(1)
+		if delivery.Status != models.DeliveryPending || delivery.Attempts != attempt {
+			t.Fatalf("after attempt %d the delivery is %s with %d attempts", attempt, delivery.Status, delivery.Attempts)
+		}
+		if delivery.LastError == "" {
+			t.Errorf("attempt %d failed without an error", attempt)
+		}
+
+		minDelay := retryBaseDelay << (attempt - 1)
+		if wait := delivery.NextAttemptAt.Sub(before); wait < minDelay || wait > minDelay+minDelay/5+time.Second {
+			t.Errorf("attempt %d is retried after %s, want %s plus up to 20%%", attempt, wait, minDelay)
+		}
+
+		// Not due yet, the next dispatch must leave it alone.
+		if err := s.dispatchDue(context.Background()); err != nil {
+			t.Fatalf("dispatchDue: %v", err)
+		}
+		if n := len(received()); n != attempt {
+			t.Fatalf("receiver got %d deliveries before the retry delay passed, want %d", n, attempt)
+		}
+
+		repo.due()
+	}
+
+	if err := s.dispatchDue(context.Background()); err != nil {
+		t.Fatalf("dispatchDue: %v", err)
+	}
+
+	delivery := repo.delivery(1)
+	if delivery.Status != models.DeliverySucceeded || delivery.Attempts != 3 || delivery.LastError != "" {
+		t.Fatalf("delivery is %s after %d attempts (%q), want succeeded after 3", delivery.Status, delivery.Attempts, delivery.LastError)
+	}
+}
+
+func TestWebhookDeliveryDeadAfterMaxAttempts(t *testing.T) {
+	receiver, received := newReceiver(t, http.StatusServiceUnavailable)
+	s, repo := newTestWebhookService(t, 3, receiver.URL)
+
+	publishTaskCreated(t, s, `{"id":1}`)
+
+	for range 5 {
+		if err := s.dispatchDue(context.Background()); err != nil {
+			t.Fatalf("dispatchDue: %v", err)
+		}
+		repo.due()
+	}
+
+	delivery := repo.delivery(1)
+	if delivery.Status != models.DeliveryDead || delivery.Attempts != 3 {
+		t.Fatalf("delivery is %s after %d attempts, want dead after 3", delivery.Status, delivery.Attempts)
+	}
+	if !strings.Contains(delivery.LastError, "503") {
+		t.Errorf("last error = %q, want the status of the receiver", delivery.LastError)
+	}
+	if n := len(received()); n != 3 {
+		t.Errorf("receiver got %d deliveries, want 3", n)
+	}
+}
+
+func TestWebhookDispatchersDeliverOnce(t *testing.T) {
+	receiver, received := newReceiver(t, http.StatusOK)
+	s, _ := newTestWebhookService(t, 3, receiver.URL)
+
+	const events = 3*deliveryBatch + 5
+	for i := range events {
+		publishTaskCreated(t, s, `{"id":`+strconv.Itoa(i)+`}`)
+	}
+
+	// Dispatchers of several replicas share the deliveries through the claim.
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.dispatchDue(context.Background()); err != nil {
+				t.Errorf("dispatchDue: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[string]int)
+	for _, delivery := range received() {
+		seen[delivery.header.Get(HeaderWebhookDelivery)]++
+	}
+	if len(seen) != events {
+		t.Errorf("receiver got %d distinct deliveries, want %d", len(seen), events)
+	}
+	for id, n := range seen {
+		if n != 1 {
+			t.Errorf("delivery %s was sent %d times", id, n)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 3, want: 2 * time.Minute},
+		{attempts: 7, want: 32 * time.Minute},
+		{attempts: 8, want: retryMaxDelay},
+		{attempts: 100, want: retryMaxDelay},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			if got := retryDelay(tt.attempts); got < tt.want || got > tt.want+tt.want/5 {
+				t.Fatalf("retryDelay(%d) = %s, want %s plus up to 20%%", tt.attempts, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestCreateWebhookRejectsPrivateTargets(t *testing.T) {
+	s := NewWebhookService(&fakeWebhookRepository{}, NewWebhookClient(time.Second, false), 3, false, logger.NewNop())
+
+	targets := []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://[::1]/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.0.0.7/hook",
+		"http://172.16.0.1/hook",
+		"http://192.168.1.1/hook",
+		"http://100.64.0.1/hook",
+		"http://0.0.0.0/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+		"http://[fd00::1]/hook",
+	}
+	for _, target := range targets {
+		_, err := s.CreateWebhook(context.Background(), "alice", &dto.CreateWebhookRequest{
+			URL:    target,
+			Events: []string{models.EventTaskCreated},
+		})
+		if !errors.Is(err, models.ErrForbiddenWebhookTarget) {
+			t.Errorf("CreateWebhook(%s) error = %v, want ErrForbiddenWebhookTarget", target, err)
+		}
+	}
+
+	_, err := s.CreateWebhook(context.Background(), "alice", &dto.CreateWebhookRequest{
+		URL:    "https://93.184.216.34/hook",
+		Events: []string{models.EventTaskCreated},
+	})
+	if err != nil {
+		t.Errorf("CreateWebhook with a public address: %v", err)
+	}
+}
+
+func TestWebhookClientRefusesPrivateAddresses(t *testing.T) {
+	receiver, received := newReceiver(t, http.StatusOK)
+
+	// The name passed the check when the webhook was created, the address it resolves to now is loopback.
+	resp, err := NewWebhookClient(time.Second, false).Post(receiver.URL, "application/json", strings.NewReader("{}"))
+	if err == nil {
+		resp.Body.Close()
+	}
+	if !errors.Is(err, errForbiddenWebhookAddr) {
+		t.Fatalf("Post to loopback error = %v, want errForbiddenWebhookAddr", err)
+	}
+	if n := len(received()); n != 0 {
+		t.Fatalf("receiver got %d requests", n)
+	}
+
+	resp, err = NewWebhookClient(time.Second, true).Post(receiver.URL, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("Post with private targets allowed: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestWebhooksAreScopedToTheirOwner(t *testing.T) {
+	s, _ := newTestWebhookService(t, 3, "http://receiver.internal/hook")
+
+	mine, err := s.GetWebhooks(context.Background(), "alice")
+	if err != nil || len(mine.Webhooks) != 1 {
+		t.Fatalf("GetWebhooks(alice) = %v, %v; want 1 webhook", mine, err)
+	}
+
+	others, err := s.GetWebhooks(context.Background(), "bob")
+	if err != nil || len(others.Webhooks) != 0 {
+		t.Fatalf("GetWebhooks(bob) = %v, %v; want none", others, err)
+	}
+}
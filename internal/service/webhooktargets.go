@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"skillsrock-test-task/internal/models"
+	"syscall"
+	"time"
+)
+
+const webhookDialTimeout = 10 * time.Second
+
+var (
+	errForbiddenWebhookAddr = errors.New("webhook target address is not public")
+
+	// blockedWebhookPrefixes reach the infrastructure rather than a receiver, besides the loopback, link-local,
+	// private and multicast ranges.
+	blockedWebhookPrefixes = []netip.Prefix{
+		netip.MustParsePrefix("0.0.0.0/8"),
+		netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+		netip.MustParsePrefix("192.0.0.0/24"),
+		netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+		netip.MustParsePrefix("64:ff9b::/96"),  // NAT64, embeds an IPv4 address
+		netip.MustParsePrefix("2002::/16"),     // 6to4, embeds an IPv4 address
+	}
+)
+
+// NewWebhookClient is the client deliveries are sent with. Unless private targets are allowed it refuses to
+// connect to addresses that are not public, which are checked after the name is resolved, so a name that
+// resolved to a public address when the webhook was created can not be pointed at the internal network later.
+func NewWebhookClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: webhookDialTimeout}
+	if !allowPrivate {
+		dialer.Control = denyPrivateWebhookAddr
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would connect to the target on behalf of the client, past the check of the dialer.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
+
+func denyPrivateWebhookAddr(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+
+	if !publicWebhookAddr(addrPort.Addr()) {
+		return errForbiddenWebhookAddr
+	}
+
+	return nil
+}
+
+// checkWebhookHost resolves the host of a new webhook and rejects it when any of its addresses is not public.
+func checkWebhookHost(ctx context.Context, resolver *net.Resolver, host string) error {
+	addrs, err := resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil || len(addrs) == 0 {
+		return models.NewFieldError("url", models.ErrForbiddenWebhookTarget)
+	}
+
+	for _, addr := range addrs {
+		if !publicWebhookAddr(addr) {
+			return models.NewFieldError("url", models.ErrForbiddenWebhookTarget)
+		}
+	}
+
+	return nil
+}
+
+func publicWebhookAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+
+	for _, prefix := range blockedWebhookPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
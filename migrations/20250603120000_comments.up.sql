@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS comments (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    reply_to INTEGER REFERENCES comments (id) ON DELETE SET NULL,
+    author TEXT NOT NULL,
+    body TEXT NOT NULL,
+    edited BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS comments_task_id_idx ON comments (task_id, id);
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS attachments_enqueue_orphaned_blob ON attachments;
+DROP FUNCTION IF EXISTS enqueue_orphaned_blob;
+DROP TABLE IF EXISTS orphaned_blobs;
+DROP TABLE IF EXISTS attachments;
\ No newline at end of file
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS attachments (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    storage_key TEXT NOT NULL UNIQUE,
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    checksum TEXT NOT NULL,
+    uploader TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS attachments_task_id_idx ON attachments (task_id);
+
+CREATE TABLE IF NOT EXISTS orphaned_blobs (
+    storage_key TEXT PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE OR REPLACE FUNCTION enqueue_orphaned_blob() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO orphaned_blobs (storage_key) VALUES (OLD.storage_key) ON CONFLICT DO NOTHING;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER attachments_enqueue_orphaned_blob
+    AFTER DELETE ON attachments
+    FOR EACH ROW EXECUTE FUNCTION enqueue_orphaned_blob();
\ No newline at end of file
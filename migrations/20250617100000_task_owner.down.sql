@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS tasks_owner_idx;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS owner;
\ No newline at end of file
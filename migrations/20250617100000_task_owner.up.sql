@@ -0,0 +1,3 @@
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS owner TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS tasks_owner_idx ON tasks (owner);
\ No newline at end of file
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'running', 'succeeded', 'failed')) DEFAULT 'pending',
+    format TEXT NOT NULL,
+    mapping JSONB NOT NULL,
+    dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+    storage_key TEXT NOT NULL,
+    total_rows BIGINT NOT NULL DEFAULT 0,
+    imported_rows BIGINT NOT NULL DEFAULT 0,
+    failed_rows BIGINT NOT NULL DEFAULT 0,
+    errors JSONB NOT NULL DEFAULT '[]',
+    error TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS import_jobs_unfinished_idx ON import_jobs (id) WHERE status IN ('pending', 'running');
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS task_sources;
\ No newline at end of file
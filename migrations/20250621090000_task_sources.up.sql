@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS task_sources (
+    source TEXT NOT NULL,
+    source_id TEXT NOT NULL,
+    task_id INTEGER NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    imported_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (source, source_id)
+);
+
+CREATE INDEX IF NOT EXISTS task_sources_task_id_idx ON task_sources (task_id);
\ No newline at end of file
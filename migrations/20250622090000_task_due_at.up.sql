@@ -0,0 +1,3 @@
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS due_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS tasks_due_at_idx ON tasks (due_at) WHERE due_at IS NOT NULL;
\ No newline at end of file
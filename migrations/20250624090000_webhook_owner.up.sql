@@ -0,0 +1,3 @@
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS owner TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS webhooks_owner_idx ON webhooks (owner);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS outbox_seq_idx;
+DROP INDEX IF EXISTS outbox_unpublished_idx;
+
+ALTER TABLE outbox DROP COLUMN IF EXISTS seq;
+DROP SEQUENCE IF EXISTS outbox_seq;
+
+CREATE INDEX IF NOT EXISTS outbox_unpublished_idx ON outbox (id) WHERE published_at IS NULL;
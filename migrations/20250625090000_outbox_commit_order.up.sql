@@ -0,0 +1,12 @@
+CREATE SEQUENCE IF NOT EXISTS outbox_seq;
+
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS seq BIGINT;
+UPDATE outbox SET seq = id WHERE seq IS NULL;
+SELECT setval('outbox_seq', COALESCE(MAX(id), 0) + 1, false) FROM outbox;
+
+ALTER TABLE outbox ALTER COLUMN seq SET DEFAULT nextval('outbox_seq'), ALTER COLUMN seq SET NOT NULL;
+ALTER SEQUENCE outbox_seq OWNED BY outbox.seq;
+
+DROP INDEX IF EXISTS outbox_unpublished_idx;
+CREATE INDEX IF NOT EXISTS outbox_unpublished_idx ON outbox (seq) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS outbox_seq_idx ON outbox (seq);
@@ -0,0 +1 @@
+ALTER TABLE tasks ALTER COLUMN due_at TYPE TIMESTAMPTZ USING due_at AT TIME ZONE 'UTC';
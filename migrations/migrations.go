@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL migrations, so the binary runs them without the directory.
+package migrations
+
+import "embed"
+
+// FS holds the <version>_<name>.up.sql and .down.sql files.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,129 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"skillsrock-test-task/pkg/blobstore/s3test"
+	"strings"
+	"testing"
+)
+
+func newTestS3Store(t *testing.T, secretKey string) (*S3Store, *s3test.Server) {
+	t.Helper()
+
+	server := s3test.NewServer()
+	t.Cleanup(server.Close)
+
+	store, err := NewS3Store(S3Options{
+		Endpoint:  server.URL,
+		Region:    s3test.Region,
+		Bucket:    s3test.Bucket,
+		AccessKey: s3test.AccessKey,
+		SecretKey: secretKey,
+	})
+	if err != nil {
+		t.Fatalf("NewS3Store: %v", err)
+	}
+
+	return store, server
+}
+
+func readBlob(t *testing.T, store BlobStore, key string, offset, length int64) string {
+	t.Helper()
+
+	r, err := store.Get(context.Background(), key, offset, length)
+	if err != nil {
+		t.Fatalf("Get(%q, %d, %d): %v", key, offset, length, err)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading %q: %v", key, err)
+	}
+
+	return string(data)
+}
+
+func TestS3StoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, server := newTestS3Store(t, s3test.SecretKey)
+
+	const content = "panic: runtime error: index out of range"
+	key := "tasks/1/log file.txt"
+
+	if err := store.Put(ctx, key, strings.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	if data, ok := server.Object(key); !ok || string(data) != content {
+		t.Fatalf("stored object = %q, %v; want %q", data, ok, content)
+	}
+
+	tests := []struct {
+		name           string
+		offset, length int64
+		want           string
+	}{
+		{name: "whole", offset: 0, length: -1, want: content},
+		{name: "bounded", offset: 7, length: 7, want: "runtime"},
+		{name: "tail", offset: 32, length: -1, want: "of range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readBlob(t, store, key, tt.offset, tt.length); got != tt.want {
+				t.Errorf("Get(%d, %d) = %q, want %q", tt.offset, tt.length, got, tt.want)
+			}
+		})
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if _, err := store.Get(ctx, key, 0, -1); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete error = %v, want ErrNotFound", err)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete of a missing blob: %v", err)
+	}
+}
+
+func TestS3StoreEmptyBlob(t *testing.T) {
+	ctx := context.Background()
+	store, server := newTestS3Store(t, s3test.SecretKey)
+
+	if err := store.Put(ctx, "empty", strings.NewReader(""), 0, "text/plain"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	if got := readBlob(t, store, "empty", 0, 0); got != "" {
+		t.Fatalf("Get = %q, want empty", got)
+	}
+
+	requests := server.Requests()
+	last := requests[len(requests)-1]
+	if last.Method != http.MethodHead || last.Header.Get("Range") != "" {
+		t.Fatalf("empty read sent %s with Range %q, want HEAD without Range", last.Method, last.Header.Get("Range"))
+	}
+
+	if _, err := store.Get(ctx, "missing", 0, 0); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get of a missing empty blob error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestS3StoreSignature(t *testing.T) {
+	store, server := newTestS3Store(t, "wrong-secret")
+
+	err := store.Put(context.Background(), "key", strings.NewReader("data"), 4, "text/plain")
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("Put with a wrong secret error = %v, want 403", err)
+	}
+
+	if _, ok := server.Object("key"); ok {
+		t.Fatal("object stored by a request with a wrong signature")
+	}
+}
@@ -0,0 +1,247 @@
+// Package s3test provides a local stand-in for an S3-compatible service, for tests of code that uses
+// blobstore.S3Store. It keeps the objects of one bucket in memory and rejects requests that are not signed
+// with AWS Signature Version 4 by its credentials.
+package s3test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	Region    = "us-east-1"
+	Bucket    = "test-bucket"
+	AccessKey = "test-access-key"
+	SecretKey = "test-secret-key"
+
+	signAlgorithm = "AWS4-HMAC-SHA256"
+	amzDateFormat = "20060102T150405Z"
+
+	// maxClockSkew is how far the signing time may be from the time of the server, as in S3.
+	maxClockSkew = 15 * time.Minute
+)
+
+type object struct {
+	data        []byte
+	contentType string
+}
+
+// Server is an S3 stand-in serving the bucket Bucket of the region Region with path-style addressing.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	objects  map[string]object
+	requests []*http.Request
+}
+
+// NewServer starts a server, it is closed with Close.
+func NewServer() *Server {
+	s := &Server{objects: make(map[string]object)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// Object returns the content of the object under the key.
+func (s *Server) Object(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	obj, ok := s.objects[key]
+	return obj.data, ok
+}
+
+// Requests returns the requests the server accepted, their bodies already read.
+func (s *Server) Requests() []*http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*http.Request(nil), s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := verifySignature(r, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != Bucket || key == "" {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, r)
+
+	switch r.Method {
+	case http.MethodPut:
+		if r.ContentLength < 0 {
+			http.Error(w, "MissingContentLength", http.StatusLengthRequired)
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.objects[key] = object{data: data, contentType: r.Header.Get("Content-Type")}
+
+	case http.MethodGet, http.MethodHead:
+		obj, ok := s.objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+
+		start, end, err := parseRange(r.Header.Get("Range"), int64(len(obj.data)))
+		if err != nil {
+			http.Error(w, "InvalidRange", http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Header().Set("Content-Length", strconv.FormatInt(end-start, 10))
+		status := http.StatusOK
+		if r.Header.Get("Range") != "" {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end-1, len(obj.data)))
+			status = http.StatusPartialContent
+		}
+		w.WriteHeader(status)
+
+		if r.Method == http.MethodGet {
+			io.Copy(w, bytes.NewReader(obj.data[start:end]))
+		}
+
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "MethodNotAllowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// parseRange resolves the "bytes=first-last" and "bytes=first-" ranges S3 supports to [start, end).
+func parseRange(header string, size int64) (start, end int64, err error) {
+	if header == "" {
+		return 0, size, nil
+	}
+
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid range %q", header)
+	}
+
+	first, last, _ := strings.Cut(spec, "-")
+	start, err = strconv.ParseInt(first, 10, 64)
+	if err != nil || start >= size {
+		return 0, 0, fmt.Errorf("invalid range %q", header)
+	}
+
+	if last == "" {
+		return start, size, nil
+	}
+
+	end, err = strconv.ParseInt(last, 10, 64)
+	if err != nil || end < start {
+		return 0, 0, fmt.Errorf("invalid range %q", header)
+	}
+
+	return start, min(end+1, size), nil
+}
+
+// verifySignature recomputes the signature of the request from its headers and the credentials of the server.
+func verifySignature(r *http.Request, now time.Time) error {
+	fields, ok := strings.CutPrefix(r.Header.Get("Authorization"), signAlgorithm+" ")
+	if !ok {
+		return fmt.Errorf("missing %s authorization", signAlgorithm)
+	}
+
+	auth := make(map[string]string)
+	for _, field := range strings.Split(fields, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+		auth[name] = value
+	}
+
+	amzDate := r.Header.Get("X-Amz-Date")
+	signedAt, err := time.Parse(amzDateFormat, amzDate)
+	if err != nil {
+		return fmt.Errorf("invalid X-Amz-Date %q", amzDate)
+	}
+	if d := now.Sub(signedAt); d > maxClockSkew || d < -maxClockSkew {
+		return fmt.Errorf("request signed at %s is too far from %s", signedAt, now)
+	}
+
+	day := signedAt.Format("20060102")
+	scope := day + "/" + Region + "/s3/aws4_request"
+	if auth["Credential"] != AccessKey+"/"+scope {
+		return fmt.Errorf("invalid credential %q", auth["Credential"])
+	}
+
+	signedHeaders := strings.Split(auth["SignedHeaders"], ";")
+	var canonicalHeaders strings.Builder
+	for _, h := range signedHeaders {
+		value := r.Header.Get(h)
+		if h == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(h + ":" + strings.TrimSpace(value) + "\n")
+	}
+	for _, required := range []string{"host", "x-amz-date", "x-amz-content-sha256"} {
+		if !strings.Contains(";"+auth["SignedHeaders"]+";", ";"+required+";") {
+			return fmt.Errorf("header %s is not signed", required)
+		}
+	}
+	if r.Header.Get("Range") != "" && !strings.Contains(auth["SignedHeaders"], "range") {
+		return fmt.Errorf("header range is not signed")
+	}
+
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		r.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		auth["SignedHeaders"],
+		r.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+
+	stringToSign := strings.Join([]string{signAlgorithm, amzDate, scope, hexSHA256(canonicalRequest)}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+SecretKey), day)
+	key = hmacSHA256(key, Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	if !hmac.Equal([]byte(signature), []byte(auth["Signature"])) {
+		return fmt.Errorf("signature does not match")
+	}
+
+	return nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
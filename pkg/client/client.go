@@ -0,0 +1,260 @@
+// Package client is the Go SDK of the tasks API, see Client.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	apiPrefix = "/api/v1"
+	userAgent = "skillsrock-test-task-client"
+
+	headerIdempotencyKey = "Idempotency-Key"
+	headerLastWrite      = "X-Last-Write"
+
+	defaultMaxRetries = 3
+	defaultMinBackoff = 100 * time.Millisecond
+	defaultMaxBackoff = 2 * time.Second
+	defaultTimeout    = 30 * time.Second
+
+	// maxErrorBodySize bounds how much of an error response is read.
+	maxErrorBodySize = 64 << 10
+)
+
+// Client calls the REST API of the service. It is safe for concurrent use.
+//
+// Failed requests are retried when the server is unavailable or overloaded (429, 502, 503, 504)
+// or the connection failed. Only requests that are safe to repeat are retried: GET, PUT and DELETE,
+// and CreateTask, which sends an Idempotency-Key header so that a retried task is created once.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	token      string
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	// lastWrite is echoed to the server, so that reads after a write are not served by a replica without it.
+	lastWrite atomic.Pointer[string]
+}
+
+type Option func(*Client)
+
+// WithHTTPClient replaces the default client, which times out after 30 seconds.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithToken sends the access token as a Bearer token with every request.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithRetries sets how many times a failed request is retried, 0 disables retries. The delay before
+// a retry doubles with every attempt, starting at minBackoff and capped at maxBackoff, a Retry-After
+// header of the response takes precedence.
+func WithRetries(maxRetries int, minBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = max(0, maxRetries)
+		c.minBackoff = minBackoff
+		c.maxBackoff = max(minBackoff, maxBackoff)
+	}
+}
+
+// New creates a client of the service at baseURL, for example http://localhost:8080.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("invalid base url %q", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    parsed,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		maxRetries: defaultMaxRetries,
+		minBackoff: defaultMinBackoff,
+		maxBackoff: defaultMaxBackoff,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   interface{}
+	// form is sent as the body instead, with its multipart content type.
+	form        []byte
+	contentType string
+	header      http.Header
+	// retry allows retrying a request that is not idempotent by its method.
+	retry bool
+}
+
+// do sends the request, retrying it when allowed, and decodes a successful response into out.
+func (c *Client) do(ctx context.Context, req *request, out interface{}) error {
+	res, err := c.roundTrip(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return decode(res, out)
+}
+
+// roundTrip sends the request, retrying it when allowed. The caller must close the body of the response.
+func (c *Client) roundTrip(ctx context.Context, req *request) (*http.Response, error) {
+	body := req.form
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+	}
+
+	retry := req.retry || req.method == http.MethodGet || req.method == http.MethodPut || req.method == http.MethodDelete
+
+	for attempt := 0; ; attempt++ {
+		res, err := c.send(ctx, req, body)
+		if err != nil {
+			if ctx.Err() != nil || !retry || attempt >= c.maxRetries {
+				return nil, err
+			}
+			if err := c.wait(ctx, attempt, ""); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if retry && attempt < c.maxRetries && isRetryable(res.StatusCode) {
+			retryAfter := res.Header.Get("Retry-After")
+			drain(res)
+			if err := c.wait(ctx, attempt, retryAfter); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		return res, nil
+	}
+}
+
+func (c *Client) send(ctx context.Context, req *request, body []byte) (*http.Response, error) {
+	u := *c.baseURL
+	u.Path += apiPrefix + req.path
+	u.RawQuery = req.query.Encode()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range req.header {
+		httpReq.Header[key] = values
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("User-Agent", userAgent)
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	} else if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if lastWrite := c.lastWrite.Load(); lastWrite != nil {
+		httpReq.Header.Set(headerLastWrite, *lastWrite)
+	}
+
+	res, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastWrite := res.Header.Get(headerLastWrite); lastWrite != "" {
+		c.lastWrite.Store(&lastWrite)
+	}
+
+	return res, nil
+}
+
+// wait sleeps before the next attempt. The backoff is jittered so that clients which failed together
+// do not retry together.
+func (c *Client) wait(ctx context.Context, attempt int, retryAfter string) error {
+	delay := c.maxBackoff
+	if shift := min(attempt, 30); c.minBackoff<<shift < c.maxBackoff {
+		delay = c.minBackoff << shift
+	}
+	delay = delay/2 + rand.N(delay/2+1)
+
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		delay = time.Duration(seconds) * time.Second
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func isRetryable(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+func decode(res *http.Response, out interface{}) error {
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		return newAPIError(res)
+	}
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, res.Body)
+		return nil
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response body: %w", err)
+	}
+
+	return nil
+}
+
+// drain reads the rest of the body, so that the connection can be reused.
+func drain(res *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxErrorBodySize))
+	_ = res.Body.Close()
+}
@@ -0,0 +1,386 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"skillsrock-test-task/internal/delivery/http/v1/handler"
+	"skillsrock-test-task/internal/delivery/routes"
+	"skillsrock-test-task/internal/dto"
+	"skillsrock-test-task/internal/models"
+	"skillsrock-test-task/internal/service"
+	"skillsrock-test-task/pkg/logger"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const testToken = "test-token"
+
+// fakeTaskService keeps the tasks in memory. The first failures calls fail with the error of the server
+// shutting down, which the API reports as 503.
+type fakeTaskService struct {
+	mu       sync.Mutex
+	tasks    map[uint64]*models.Task
+	nextID   uint64
+	failures int
+	calls    int
+}
+
+func newFakeTaskService() *fakeTaskService {
+	return &fakeTaskService{tasks: make(map[uint64]*models.Task)}
+}
+
+func (s *fakeTaskService) fail() error {
+	s.calls++
+	if s.failures > 0 {
+		s.failures--
+		return models.ErrStreamClosed
+	}
+	return nil
+}
+
+func (s *fakeTaskService) CreateTask(_ context.Context, req *dto.CreateTaskRequest) (*dto.CreateTaskResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.fail(); err != nil {
+		return nil, err
+	}
+
+	s.nextID++
+	now := time.Now().UTC()
+	s.tasks[s.nextID] = &models.Task{
+		ID:          s.nextID,
+		Title:       req.Title,
+		Description: req.Description,
+		Status:      "new",
+		Owner:       req.Owner,
+		DueAt:       req.DueAt,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	return &dto.CreateTaskResponse{ID: s.nextID, Status: "new", CreatedAt: now}, nil
+}
+
+func (s *fakeTaskService) GetTaskByID(_ context.Context, id string) (*dto.GetTaskByIDResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.fail(); err != nil {
+		return nil, err
+	}
+
+	taskID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, models.ErrFailedToParseID
+	}
+	task, ok := s.tasks[taskID]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+
+	return &dto.GetTaskByIDResponse{Task: task}, nil
+}
+
+func (s *fakeTaskService) GetTasks(context.Context, string, string, *models.TaskFilter) (*dto.GetTasksResponse, error) {
+	return nil, models.ErrNotFound
+}
+
+func (s *fakeTaskService) ExportTasks(*models.TaskFilter) (dto.TaskExport, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *fakeTaskService) GetCalendar(context.Context, *dto.GetCalendarRequest) (*dto.GetCalendarResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *fakeTaskService) DeleteTask(context.Context, string) error {
+	return errors.New("not implemented")
+}
+
+func (s *fakeTaskService) UpdateTask(context.Context, string, *dto.UpdateTaskRequest) error {
+	return errors.New("not implemented")
+}
+
+func (s *fakeTaskService) MoveTask(context.Context, string, string) error {
+	return errors.New("not implemented")
+}
+
+func (s *fakeTaskService) BulkTasks(context.Context, *dto.BulkTasksRequest) (*dto.BulkTasksResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+// fakeIdempotencyRepository keeps the idempotency keys in memory and remembers the keys it was asked to reserve.
+type fakeIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]*models.IdempotencyRecord
+	keys    []string
+}
+
+func (r *fakeIdempotencyRepository) ReserveKey(_ context.Context, record *models.IdempotencyRecord) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Fiber reuses the memory of the strings of a request, the key is kept past it.
+	record.Key = strings.Clone(record.Key)
+
+	r.keys = append(r.keys, record.Key)
+	if _, ok := r.records[record.Scope+" "+record.Key]; ok {
+		return false, nil
+	}
+	r.records[record.Scope+" "+record.Key] = record
+
+	return true, nil
+}
+
+func (r *fakeIdempotencyRepository) GetKey(_ context.Context, scope, key string) (*models.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, ok := r.records[scope+" "+key]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+
+	return record, nil
+}
+
+func (r *fakeIdempotencyRepository) CompleteKey(_ context.Context, record *models.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.records[record.Scope+" "+record.Key]
+	if !ok {
+		return models.ErrNotFound
+	}
+	stored.Completed = true
+	stored.StatusCode = record.StatusCode
+	stored.ContentType = record.ContentType
+	stored.Body = record.Body
+
+	return nil
+}
+
+func (r *fakeIdempotencyRepository) ReleaseKey(_ context.Context, scope, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.records, scope+" "+key)
+	return nil
+}
+
+func (r *fakeIdempotencyRepository) DeleteExpiredKeys(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+type fakeAuthenticator struct{}
+
+func (fakeAuthenticator) Authenticate(token string) (string, error) {
+	if token != testToken {
+		return "", models.ErrUnauthorized
+	}
+	return "tester", nil
+}
+
+// fakeRateLimiter rejects the first limited requests with the given Retry-After.
+type fakeRateLimiter struct {
+	mu         sync.Mutex
+	limited    int
+	retryAfter time.Duration
+}
+
+func (l *fakeRateLimiter) Take(context.Context, string, bool, string) (*models.RateLimitResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	res := &models.RateLimitResult{
+		Allowed: l.limited == 0,
+		Limit:   models.RateLimit{Burst: 1, Period: time.Second},
+	}
+	if !res.Allowed {
+		l.limited--
+		res.RetryAfter = l.retryAfter
+	}
+
+	return res, nil
+}
+
+type testServer struct {
+	tasks       *fakeTaskService
+	idempotency *fakeIdempotencyRepository
+	url         string
+}
+
+// newTestServer serves the routes of the API with the fake services on a local port.
+func newTestServer(t *testing.T, limiter *fakeRateLimiter) *testServer {
+	t.Helper()
+
+	log := logger.NewNop()
+	s := &testServer{
+		tasks:       newFakeTaskService(),
+		idempotency: &fakeIdempotencyRepository{records: make(map[string]*models.IdempotencyRecord)},
+	}
+
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+		ErrorHandler:          handler.ErrorHandler(log),
+	})
+	h := handler.NewHandler(s.tasks, nil, nil, nil, nil, nil, nil, log, 1<<20)
+
+	opts := routes.Options{RateLimitBy: "key"}
+	if limiter != nil {
+		opts.RateLimiter = limiter
+	}
+	routes.RegistrateRoutes(app, log, h, nil, service.NewIdempotencyService(s.idempotency, time.Hour), fakeAuthenticator{}, opts)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go app.Listener(lis)
+	t.Cleanup(func() { _ = app.Shutdown() })
+
+	s.url = "http://" + lis.Addr().String()
+
+	return s
+}
+
+func newTestClient(t *testing.T, url string, maxRetries int) *Client {
+	t.Helper()
+
+	c, err := New(url, WithToken(testToken), WithRetries(maxRetries, time.Millisecond, 5*time.Millisecond))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	return c
+}
+
+func TestClientRetriesUnavailable(t *testing.T) {
+	ctx := context.Background()
+	server := newTestServer(t, nil)
+	c := newTestClient(t, server.url, 3)
+
+	created, err := c.CreateTask(ctx, &CreateTaskRequest{Title: "Write tests", Owner: "tester"})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+
+	server.tasks.failures = 2
+	server.tasks.calls = 0
+
+	task, err := c.GetTask(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if task.ID != created.ID || task.Title != "Write tests" || task.Owner != "tester" || task.Status != "new" {
+		t.Fatalf("GetTask = %+v, want the created task", task)
+	}
+	if server.tasks.calls != 3 {
+		t.Fatalf("GetTask took %d attempts, want 3", server.tasks.calls)
+	}
+}
+
+func TestClientGivesUpAfterMaxRetries(t *testing.T) {
+	server := newTestServer(t, nil)
+	c := newTestClient(t, server.url, 2)
+
+	server.tasks.failures = 10
+
+	_, err := c.GetTask(context.Background(), 1)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || apiErr.Code != "shutting_down" {
+		t.Fatalf("GetTask error = %v, want a 503 shutting_down APIError", err)
+	}
+	if server.tasks.calls != 3 {
+		t.Fatalf("GetTask took %d attempts, want 3", server.tasks.calls)
+	}
+}
+
+func TestClientHonoursRetryAfter(t *testing.T) {
+	limiter := &fakeRateLimiter{limited: 1, retryAfter: time.Second}
+	server := newTestServer(t, limiter)
+	c := newTestClient(t, server.url, 1)
+
+	start := time.Now()
+	if _, err := c.CreateTask(context.Background(), &CreateTaskRequest{Title: "Rate limited"}); err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+
+	// The backoff of the client is at most 5ms, only the Retry-After of the 429 makes it wait a second.
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("CreateTask retried after %s, want the Retry-After of 1s", elapsed)
+	}
+}
+
+func TestClientCreateTaskIdempotencyKey(t *testing.T) {
+	server := newTestServer(t, nil)
+	c := newTestClient(t, server.url, 3)
+
+	server.tasks.failures = 1
+
+	created, err := c.CreateTask(context.Background(), &CreateTaskRequest{Title: "Created once"})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	if created.ID != 1 || len(server.tasks.tasks) != 1 {
+		t.Fatalf("CreateTask created task %d of %d, want one task", created.ID, len(server.tasks.tasks))
+	}
+
+	keys := server.idempotency.keys
+	if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Fatalf("idempotency keys = %q, want the same key for both attempts", keys)
+	}
+
+	if _, err := c.CreateTask(context.Background(), &CreateTaskRequest{Title: "Created once"}); err != nil {
+		t.Fatalf("second CreateTask: %v", err)
+	}
+	if keys := server.idempotency.keys; keys[2] == keys[0] {
+		t.Fatalf("second CreateTask reused the key %q", keys[2])
+	}
+}
+
+func TestClientDecodesErrors(t *testing.T) {
+	ctx := context.Background()
+	server := newTestServer(t, nil)
+	c := newTestClient(t, server.url, 0)
+
+	_, err := c.GetTask(ctx, 42)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("GetTask error = %v, want an APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound || apiErr.Code != "not_found" || apiErr.RequestID == "" {
+		t.Fatalf("GetTask error = %+v, want a 404 not_found with a request ID", apiErr)
+	}
+	if !errors.Is(err, ErrNotFound) || errors.Is(err, ErrValidation) {
+		t.Fatalf("GetTask error %v does not match only ErrNotFound", err)
+	}
+
+	_, err = c.CreateTask(ctx, &CreateTaskRequest{Title: " "})
+
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("CreateTask error = %v, want an APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != "validation_failed" {
+		t.Fatalf("CreateTask error = %+v, want a 400 validation_failed", apiErr)
+	}
+	if len(apiErr.Fields) != 1 || apiErr.Fields[0].Field != "title" || apiErr.Fields[0].Code != "required" {
+		t.Fatalf("CreateTask fields = %+v, want title required", apiErr.Fields)
+	}
+	if !errors.Is(err, ErrValidation) || !errors.Is(err, ErrRequired) || errors.Is(err, ErrNotFound) {
+		t.Fatalf("CreateTask error %v does not match ErrValidation and ErrRequired", err)
+	}
+	if len(server.tasks.tasks) != 0 {
+		t.Fatal("an invalid task was created")
+	}
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"skillsrock-test-task/internal/models"
+)
+
+// The errors the API reports, an *APIError matches them with errors.Is.
+var (
+	ErrNotFound               = models.ErrNotFound
+	ErrFailedToParseID        = models.ErrFailedToParseID
+	ErrFailedToParsePage      = models.ErrFailedToParsePage
+	ErrFailedToParseLimit     = models.ErrFailedToParseLimit
+	ErrInvalidStatus          = models.ErrInvalidStatus
+	ErrInvalidIdempotencyKey  = models.ErrInvalidIdempotencyKey
+	ErrIdempotencyKeyReused   = models.ErrIdempotencyKeyReused
+	ErrIdempotencyKeyInFlight = models.ErrIdempotencyKeyInFlight
+	ErrUnauthorized           = models.ErrUnauthorized
+	ErrInvalidExportFormat    = models.ErrInvalidExportFormat
+	ErrInvalidExportColumns   = models.ErrInvalidExportColumns
+	ErrEmptyTitle             = models.ErrEmptyTitle
+	ErrInvalidImportFormat    = models.ErrInvalidImportFormat
+	ErrInvalidImportFile      = models.ErrInvalidImportFile
+	ErrInvalidImportMapping   = models.ErrInvalidImportMapping
+	ErrFileTooLarge           = models.ErrFileTooLarge
+	ErrUnknownImportSource    = models.ErrUnknownImportSource
+	ErrInvalidBody            = models.ErrInvalidBody
+	ErrValidation             = models.ErrValidation
+	ErrRequired               = models.ErrRequired
+	ErrTooLong                = models.ErrTooLong
+	ErrInvalidValue           = models.ErrInvalidValue
+	ErrUnknownField           = models.ErrUnknownField
+	ErrRateLimited            = models.ErrRateLimited
+)
+
+var knownErrors = []*models.Error{
+	ErrNotFound,
+	ErrFailedToParseID,
+	ErrFailedToParsePage,
+	ErrFailedToParseLimit,
+	ErrInvalidStatus,
+	ErrInvalidIdempotencyKey,
+	ErrIdempotencyKeyReused,
+	ErrIdempotencyKeyInFlight,
+	ErrUnauthorized,
+	ErrInvalidExportFormat,
+	ErrInvalidExportColumns,
+	ErrEmptyTitle,
+	ErrInvalidImportFormat,
+	ErrInvalidImportFile,
+	ErrInvalidImportMapping,
+	ErrFileTooLarge,
+	ErrUnknownImportSource,
+	ErrInvalidBody,
+	ErrValidation,
+	ErrRequired,
+	ErrTooLong,
+	ErrInvalidValue,
+	ErrUnknownField,
+	ErrRateLimited,
+}
+
+// APIError is returned for responses with an error status.
+type APIError struct {
+	StatusCode int
+	// Code is the machine-readable code of the problem, empty when the body was not a problem.
+	Code string
+	// Message is the detail of the problem, or the status text when the body had none.
+	Message string
+	// RequestID identifies the request in the logs of the server.
+	RequestID string
+	// Fields lists the invalid fields of a request that failed validation.
+	Fields []FieldError
+
+	errs []error
+}
+
+// FieldError tells why a field of a request is invalid.
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// problem is the application/problem+json body of error responses.
+type problem struct {
+	Title    string `json:"title"`
+	Detail   string `json:"detail"`
+	Instance string `json:"instance"`
+	Code     string `json:"code"`
+	Errors   []struct {
+		Field  string `json:"field"`
+		Code   string `json:"code"`
+		Detail string `json:"detail"`
+	} `json:"errors"`
+}
+
+func newAPIError(res *http.Response) *APIError {
+	var body problem
+	_ = json.NewDecoder(io.LimitReader(res.Body, maxErrorBodySize)).Decode(&body)
+
+	apiErr := &APIError{
+		StatusCode: res.StatusCode,
+		Code:       body.Code,
+		Message:    cmp.Or(body.Detail, body.Title, http.StatusText(res.StatusCode)),
+		RequestID:  cmp.Or(body.Instance, res.Header.Get("X-Request-ID")),
+	}
+
+	if known := knownError(body.Code); known != nil {
+		apiErr.errs = append(apiErr.errs, known)
+	}
+	for _, field := range body.Errors {
+		apiErr.Fields = append(apiErr.Fields, FieldError{Field: field.Field, Code: field.Code, Message: field.Detail})
+		if known := knownError(field.Code); known != nil {
+			apiErr.errs = append(apiErr.errs, known)
+		}
+	}
+
+	// A 404 without a known code comes from a route that does not exist.
+	if len(apiErr.errs) == 0 && res.StatusCode == http.StatusNotFound {
+		apiErr.errs = append(apiErr.errs, ErrNotFound)
+	}
+
+	return apiErr
+}
+
+func knownError(code string) error {
+	for _, known := range knownErrors {
+		if code != "" && code == known.Code {
+			return known
+		}
+	}
+	return nil
+}
+
+func (e *APIError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("tasks api: %d %s (request %s)", e.StatusCode, e.Message, e.RequestID)
+	}
+	return fmt.Sprintf("tasks api: %d %s", e.StatusCode, e.Message)
+}
+
+// Unwrap returns the errors of the models package matching the code of the problem and of its fields.
+func (e *APIError) Unwrap() []error {
+	return e.errs
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"skillsrock-test-task/internal/dto"
+	"skillsrock-test-task/internal/models"
+	"strconv"
+)
+
+// The trackers whose exports can be imported.
+const (
+	SourceTrello = models.SourceTrello
+	SourceJira   = models.SourceJira
+	SourceGitHub = models.SourceGitHub
+)
+
+type ExternalImportReport = models.ExternalImportReport
+
+// ImportFromOptions change how the items of an export are imported.
+type ImportFromOptions struct {
+	// Mapping gives the status of states of the tracker, e.g. "In Review": "in_progress". The server
+	// guesses the status of the other states.
+	Mapping map[string]string
+	// DryRun only reports what the import would change.
+	DryRun bool
+}
+
+// ImportFrom imports the JSON export of a tracker, source is SourceTrello, SourceJira or SourceGitHub.
+// Importing an export again updates the tasks imported before, so the request is retried like a GET.
+func (c *Client) ImportFrom(ctx context.Context, source string, export io.Reader, opts ImportFromOptions) (*ExternalImportReport, error) {
+	var form bytes.Buffer
+	w := multipart.NewWriter(&form)
+
+	if len(opts.Mapping) > 0 {
+		mapping, err := json.Marshal(opts.Mapping)
+		if err != nil {
+			return nil, err
+		}
+		if err := w.WriteField("mapping", string(mapping)); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.WriteField("dry_run", strconv.FormatBool(opts.DryRun)); err != nil {
+		return nil, err
+	}
+
+	file, err := w.CreateFormFile("file", source+".json")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(file, export); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	var res dto.ImportFromTrackerResponse
+	err = c.do(ctx, &request{
+		method:      http.MethodPost,
+		path:        "/tasks/import/" + source,
+		form:        form.Bytes(),
+		contentType: w.FormDataContentType(),
+		retry:       true,
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Report, nil
+}
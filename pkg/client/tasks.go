@@ -0,0 +1,201 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"skillsrock-test-task/internal/dto"
+	"skillsrock-test-task/internal/models"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// The types of the API are shared with the server.
+type (
+	Task               = models.Task
+	CreateTaskRequest  = dto.CreateTaskRequest
+	CreateTaskResponse = dto.CreateTaskResponse
+	UpdateTaskRequest  = dto.UpdateTaskRequest
+)
+
+// ListTasksOptions selects the tasks to list, zero values are left to the server defaults.
+type ListTasksOptions struct {
+	// Page is the first page to return, pages start at 1.
+	Page  uint64
+	Limit uint64
+
+	Status string
+	Owner  string
+}
+
+// ExportOptions selects the tasks and the file format of an export, zero values are left to the server defaults.
+type ExportOptions struct {
+	// Format is csv, json or ndjson.
+	Format string
+	// Columns are the exported fields in their order, e.g. id, title and status.
+	Columns []string
+	Gzip    bool
+
+	Status string
+	Owner  string
+}
+
+// CreateTask creates a task. Retries of the request reuse its idempotency key, so the task is created once.
+func (c *Client) CreateTask(ctx context.Context, task *CreateTaskRequest) (*CreateTaskResponse, error) {
+	var res CreateTaskResponse
+	err := c.do(ctx, &request{
+		method: http.MethodPost,
+		path:   "/tasks",
+		body:   task,
+		header: http.Header{headerIdempotencyKey: {uuid.NewString()}},
+		retry:  true,
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *Client) GetTask(ctx context.Context, id uint64) (*Task, error) {
+	var res dto.GetTaskByIDResponse
+	err := c.do(ctx, &request{
+		method: http.MethodGet,
+		path:   "/tasks/" + strconv.FormatUint(id, 10),
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Task, nil
+}
+
+func (c *Client) UpdateTask(ctx context.Context, id uint64, task *UpdateTaskRequest) error {
+	return c.do(ctx, &request{
+		method: http.MethodPut,
+		path:   "/tasks/" + strconv.FormatUint(id, 10),
+		body:   task,
+	}, nil)
+}
+
+func (c *Client) DeleteTask(ctx context.Context, id uint64) error {
+	return c.do(ctx, &request{
+		method: http.MethodDelete,
+		path:   "/tasks/" + strconv.FormatUint(id, 10),
+	}, nil)
+}
+
+// GetTasks returns a single page of tasks, a page past the last task is empty.
+func (c *Client) GetTasks(ctx context.Context, opts ListTasksOptions) ([]*Task, error) {
+	query := url.Values{}
+	if opts.Page > 0 {
+		query.Set("page", strconv.FormatUint(opts.Page, 10))
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.FormatUint(opts.Limit, 10))
+	}
+	if opts.Status != "" {
+		query.Set("status", opts.Status)
+	}
+	if opts.Owner != "" {
+		query.Set("owner", opts.Owner)
+	}
+
+	var res dto.GetTasksResponse
+	err := c.do(ctx, &request{
+		method: http.MethodGet,
+		path:   "/tasks",
+		query:  query,
+	}, &res)
+	if err != nil {
+		// The API answers an empty page with 404.
+		if errors.Is(err, ErrNotFound) {
+			return []*Task{}, nil
+		}
+		return nil, err
+	}
+
+	return res.Tasks, nil
+}
+
+// ListTasks iterates over the pages of tasks starting at opts.Page, it stops after the last page
+// or the first error:
+//
+//	for tasks, err := range c.ListTasks(ctx, client.ListTasksOptions{Limit: 50}) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func (c *Client) ListTasks(ctx context.Context, opts ListTasksOptions) iter.Seq2[[]*Task, error] {
+	return func(yield func([]*Task, error) bool) {
+		page := max(opts.Page, 1)
+
+		for {
+			pageOpts := opts
+			pageOpts.Page = page
+
+			tasks, err := c.GetTasks(ctx, pageOpts)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if len(tasks) == 0 {
+				return
+			}
+
+			if !yield(tasks, nil) {
+				return
+			}
+
+			// A short page is the last one, the default limit is not known here.
+			if opts.Limit > 0 && uint64(len(tasks)) < opts.Limit {
+				return
+			}
+
+			page++
+		}
+	}
+}
+
+// ExportTasks returns the exported file while the server streams it, the caller must close it. The export
+// is bounded by the timeout of the HTTP client, which may have to be raised for large exports.
+func (c *Client) ExportTasks(ctx context.Context, opts ExportOptions) (io.ReadCloser, error) {
+	query := url.Values{}
+	if opts.Format != "" {
+		query.Set("format", opts.Format)
+	}
+	if len(opts.Columns) > 0 {
+		query.Set("columns", strings.Join(opts.Columns, ","))
+	}
+	if opts.Gzip {
+		query.Set("gzip", "true")
+	}
+	if opts.Status != "" {
+		query.Set("status", opts.Status)
+	}
+	if opts.Owner != "" {
+		query.Set("owner", opts.Owner)
+	}
+
+	res, err := c.roundTrip(ctx, &request{
+		method: http.MethodGet,
+		path:   "/tasks/export",
+		query:  query,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		defer res.Body.Close()
+		return nil, newAPIError(res)
+	}
+
+	return res.Body, nil
+}
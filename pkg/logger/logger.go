@@ -98,6 +98,13 @@ func New() (Logger, error) {
 	}, nil
 }
 
+// NewNop returns a logger that writes nothing, for tests.
+func NewNop() Logger {
+	return &logger{
+		logger: zap.NewNop(),
+	}
+}
+
 func (l logger) Stop() error {
 	_ = l.logger.Sync()
 
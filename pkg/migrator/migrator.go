@@ -1,26 +1,179 @@
 package migrator
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
 	"skillsrock-test-task/internal/config"
+	"skillsrock-test-task/internal/database/postgres"
+	"skillsrock-test-task/migrations"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
 )
 
-func Start(cfg *config.Config) error {
-	dbURL := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s", cfg.Postgres.User, cfg.Postgres.Password, cfg.Postgres.Host, cfg.Postgres.Port, cfg.Postgres.Name, cfg.Postgres.SSLMode)
+const (
+	// lockKey is the advisory lock held while migrating. The driver of migrate takes a lock of its own around
+	// every command, this one makes the instances that start together wait for each other as long as the
+	// lock timeout allows, not the 15 seconds of migrate.
+	lockKey int64 = 0x736b696c6c73 // "skills"
 
-	m, err := migrate.New("file://"+cfg.MigrationsPath, dbURL)
+	// versionLayout names the migrations after the time they were created, like the existing ones.
+	versionLayout = "20060102150405"
+)
+
+var (
+	ErrLockTimeout = errors.New("another instance is migrating, timed out waiting for it")
+	ErrInvalidName = errors.New("migration name must be lowercase letters, digits and underscores")
+
+	migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+)
+
+// Migrator migrates the database with the migrations built into the binary, or with the ones of the
+// directory when MIGRATIONS_PATH is set.
+type Migrator struct {
+	m           *migrate.Migrate
+	conn        *pgx.Conn
+	lockTimeout time.Duration
+}
+
+func New(ctx context.Context, cfg *config.Config) (*Migrator, error) {
+	dsn := postgres.DSN(cfg.Postgres)
+
+	var (
+		m   *migrate.Migrate
+		err error
+	)
+	if cfg.Migrations.Path != "" {
+		m, err = migrate.New("file://"+cfg.Migrations.Path, dsn)
+	} else {
+		source, sourceErr := iofs.New(migrations.FS, ".")
+		if sourceErr != nil {
+			return nil, fmt.Errorf("failed to read the embedded migrations: %w", sourceErr)
+		}
+		m, err = migrate.NewWithSourceInstance("iofs", source, dsn)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration: %w", err)
+	}
+	m.LockTimeout = cfg.Migrations.LockTimeout
+
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		m.Close()
+		return nil, fmt.Errorf("failed to connect for the migration lock: %w", err)
+	}
+
+	return &Migrator{
+		m:           m,
+		conn:        conn,
+		lockTimeout: cfg.Migrations.LockTimeout,
+	}, nil
+}
+
+// Start applies the migrations that are not applied yet, it runs when the server starts.
+func Start(ctx context.Context, cfg *config.Config) error {
+	m, err := New(ctx, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to create migration: %w", err)
+		return err
 	}
+	defer m.Close()
 
-	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+	if err := m.Up(ctx, 0); err != nil {
 		return fmt.Errorf("failed to make migration up: %w", err)
 	}
 
 	return nil
 }
+
+// Up applies n migrations, or all of them when n is 0. Having nothing to apply is not an error.
+func (m *Migrator) Up(ctx context.Context, n int) error {
+	return m.locked(ctx, func() error {
+		if n == 0 {
+			return m.m.Up()
+		}
+		return m.m.Steps(n)
+	})
+}
+
+// Down rolls the last n migrations back.
+func (m *Migrator) Down(ctx context.Context, n int) error {
+	return m.locked(ctx, func() error {
+		return m.m.Steps(-n)
+	})
+}
+
+// Goto migrates up or down to the version.
+func (m *Migrator) Goto(ctx context.Context, version uint) error {
+	return m.locked(ctx, func() error {
+		return m.m.Migrate(version)
+	})
+}
+
+// Force sets the version without migrating, after a failed migration was cleaned up by hand. A version of -1
+// means no migration is applied.
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	return m.locked(ctx, func() error {
+		return m.m.Force(version)
+	})
+}
+
+// Version returns the applied version, dirty when its migration failed. It is migrate.ErrNilVersion when no
+// migration is applied.
+func (m *Migrator) Version() (version uint, dirty bool, err error) {
+	return m.m.Version()
+}
+
+func (m *Migrator) Close() {
+	m.m.Close()
+	m.conn.Close(context.Background())
+}
+
+// locked runs the command holding the migration lock. Nothing to migrate is not an error.
+func (m *Migrator) locked(ctx context.Context, fn func() error) error {
+	lockCtx, cancel := context.WithTimeout(ctx, m.lockTimeout)
+	defer cancel()
+
+	if _, err := m.conn.Exec(lockCtx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		if errors.Is(lockCtx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%w after %s", ErrLockTimeout, m.lockTimeout)
+		}
+		return fmt.Errorf("failed to take the migration lock: %w", err)
+	}
+	defer m.conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+
+	if err := fn(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	return nil
+}
+
+// Create writes the empty up and down files of a new migration to the directory and returns their paths.
+func Create(dir, name string, now time.Time) ([]string, error) {
+	if !migrationName.MatchString(name) {
+		return nil, ErrInvalidName
+	}
+
+	base := filepath.Join(dir, now.UTC().Format(versionLayout)+"_"+name)
+	paths := []string{base + ".up.sql", base + ".down.sql"}
+
+	for _, path := range paths {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		if err := f.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	return paths, nil
+}